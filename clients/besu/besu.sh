@@ -155,7 +155,10 @@ RPCFLAGS="$RPCFLAGS --rpc-ws-enabled --rpc-ws-api=DEBUG,ETH,NET,WEB3,ADMIN --rpc
 
 # Enable merge support if needed
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
-    echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+    # Use the default secret unless the simulator provides one.
+    if [ ! -f /jwtsecret ]; then
+        echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+    fi
     RPCFLAGS="$RPCFLAGS --engine-host-allowlist=* --engine-jwt-secret /jwtsecret"
 fi
 
@@ -74,7 +74,7 @@ if [ "$HIVE_CLIQUE_PRIVATEKEY" != "" ]; then
 fi
 
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
-    FLAGS="$FLAGS --jwt-secret ./jwtsecret"
+    FLAGS="$FLAGS --jwt-secret /jwtsecret"
 fi
 
 # Load the test chain if present
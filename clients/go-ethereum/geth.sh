@@ -14,6 +14,7 @@
 #  - HIVE_BOOTNODE                enode URL of the remote bootstrap node
 #  - HIVE_NETWORK_ID              network ID number to use for the eth protocol
 #  - HIVE_NODETYPE                sync and pruning selector (archive, full, light)
+#  - HIVE_SYNC_MODE               sync mode of the node (full, snap, checkpoint)
 #
 # Forks:
 #
@@ -81,6 +82,14 @@ fi
 if [ "$HIVE_NODETYPE" == "" ]; then
     FLAGS="$FLAGS --syncmode snap"
 fi
+# The sync mode overrides the one of the node type. Checkpoint sync is snap sync,
+# which starts from the finalized block given by the consensus layer.
+if [ "$HIVE_SYNC_MODE" == "full" ]; then
+    FLAGS="$FLAGS --syncmode full"
+fi
+if [ "$HIVE_SYNC_MODE" == "snap" ] || [ "$HIVE_SYNC_MODE" == "checkpoint" ]; then
+    FLAGS="$FLAGS --syncmode snap"
+fi
 
 # Configure the chain.
 mv /genesis.json /genesis-input.json
@@ -151,7 +160,10 @@ FLAGS="$FLAGS --http --http.addr=0.0.0.0 --http.port=8545 --http.api=admin,debug
 FLAGS="$FLAGS --ws --ws.addr=0.0.0.0 --ws.origins \"*\" --ws.api=admin,debug,eth,miner,net,personal,txpool,web3"
 
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
-    echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+    # Use the default secret unless the simulator provides one.
+    if [ ! -f /jwtsecret ]; then
+        echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+    fi
     FLAGS="$FLAGS --authrpc.addr=0.0.0.0 --authrpc.port=8551 --authrpc.jwtsecret /jwtsecret"
 fi
 
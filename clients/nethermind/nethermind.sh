@@ -50,6 +50,10 @@ set -e
 # Generate JWT file if necessary
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
     JWT_SECRET="0x7365637265747365637265747365637265747365637265747365637265747365"
+    # Use the secret provided by the simulator, if any.
+    if [ -f /jwtsecret ]; then
+        JWT_SECRET=$(cat /jwtsecret)
+    fi
     echo -n $JWT_SECRET > /jwt.secret
 fi
 
@@ -127,7 +127,10 @@ fi
 
 # Configure engine api
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
-  echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+  # Use the default secret unless the simulator provides one.
+  if [ ! -f /jwtsecret ]; then
+    echo "0x7365637265747365637265747365637265747365637265747365637265747365" > /jwtsecret
+  fi
   FLAGS="$FLAGS --engine-api:true --engine-api-address:0.0.0.0 --engine-api-port:8551 --jwt-secret:/jwtsecret"
 fi
 
@@ -159,6 +159,10 @@ fi
 
 if [ "$HIVE_TERMINAL_TOTAL_DIFFICULTY" != "" ]; then
     JWT_SECRET="7365637265747365637265747365637265747365637265747365637265747365"
+    # Use the secret provided by the simulator, if any.
+    if [ -f /jwtsecret ]; then
+        JWT_SECRET=$(cat /jwtsecret)
+    fi
     echo -n $JWT_SECRET > /jwt.secret
     FLAGS="$FLAGS --authrpc.addr=0.0.0.0 --authrpc.jwtsecret=/jwt.secret"
 fi
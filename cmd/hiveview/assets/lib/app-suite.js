@@ -110,6 +110,11 @@ function showSuiteData(data, suiteID) {
         $('#testsuite_clients').html(html.makeDefinitionList(data.clientVersions));
     }
 
+    // Load build info.
+    if (data.buildInfo) {
+        showBuildInfo(routes.resultsRoot + data.buildInfo, data);
+    }
+
     // Convert test cases to list.
     let cases = [];
     for (var k in data.testCases) {
@@ -307,6 +312,78 @@ function testHasClients(testData) {
     return testData.clientInfo && Object.getOwnPropertyNames(testData.clientInfo).length > 0;
 }
 
+// showBuildInfo loads the build info file of the run and displays the entries
+// of clients used in the suite.
+function showBuildInfo(url, suiteData) {
+    $.ajax({
+        type: 'GET',
+        url: url,
+        dataType: 'json',
+        success: function(info) {
+            let used = suiteData.clientVersions || {};
+            let builds = (info.clients || []).filter(function (b) { return b.name in used; });
+            builds = builds.concat(info.simulators || []);
+            if (builds.length == 0) {
+                return;
+            }
+            let content = $('#testsuite_build_content');
+            for (let b of builds) {
+                content.append($('<h6>').text(b.name));
+                content.append(html.makeDefinitionList(buildInfoFields(b)));
+            }
+            let raw = html.makeLink(url, 'build-info.json');
+            content.append(raw);
+            $('#testsuite_build').show();
+        },
+        error: function(xhr, status, error) {
+            console.log('error fetching build info:', error);
+        },
+    });
+}
+
+function buildInfoFields(b) {
+    let fields = {'Image': b.image};
+    if (b.imageID) {
+        fields['Image ID'] = b.imageID;
+    }
+    if (b.repoDigests && b.repoDigests.length > 0) {
+        fields['Digests'] = b.repoDigests.join(', ');
+    }
+    if (b.version) {
+        fields['Version'] = b.version;
+    }
+    if (b.sourceCommit) {
+        fields['Source commit'] = b.sourceCommit;
+    }
+    if (b.hiveCommit) {
+        fields['Hive commit'] = b.hiveCommit;
+    }
+    if (b.dockerfile) {
+        fields['Dockerfile'] = b.dockerfile;
+    }
+    for (let key in b.buildArgs || {}) {
+        fields['Build arg ' + key] = b.buildArgs[key];
+    }
+    for (let base of b.baseImages || []) {
+        let digest = base.repoDigests && base.repoDigests.length > 0 ? base.repoDigests[0] : base.imageID;
+        fields['Base ' + base.name] = digest || 'unknown';
+    }
+    if (b.buildTime) {
+        fields['Build time'] = formatDuration(b.buildTime / 1e6);
+    }
+    return fields;
+}
+
+// testHasTimeline reports whether any client log of the test has timestamps.
+function testHasTimeline(testData) {
+    if (!testHasClients(testData)) {
+        return false;
+    }
+    return Object.values(testData.clientInfo).some(function (c) {
+        return c.logFormat === 'jsonl';
+    });
+}
+
 // formatClientLogsList turns the clientInfo part of a test into a list of links.
 function formatClientLogsList(suiteData, testIndex, clientInfo) {
     let links = [];
@@ -351,6 +428,12 @@ function formatTestDetails(suiteData, row) {
         p.innerHTML = '<b>Clients:</b> ' + formatClientLogsList(suiteData, d.testIndex, d.clientInfo);
         container.appendChild(p);
     }
+    if (testHasTimeline(d)) {
+        let p = document.createElement('p');
+        let url = routes.testTimeline(suiteData.suiteID, suiteData.name, d.testIndex);
+        p.innerHTML = '<b>Timeline:</b> ' + html.makeLink(url, 'test output and client logs').outerHTML;
+        container.appendChild(p);
+    }
     if (!row.column('duration:name').responsiveHidden()) {
         let p = document.createElement('p');
         p.innerHTML = '<b>Duration:</b> ' + formatDuration(d.duration);
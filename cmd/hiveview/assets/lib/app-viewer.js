@@ -34,12 +34,22 @@ $(document).ready(function () {
         return;
     }
 
+    // Check if we're supposed to show the test timeline.
+    if (queryParam('showtimeline') === '1') {
+        if (!suiteFile || !testIndex) {
+            showError('Invalid parameters! Missing \'suitefile\' or \'testid\' in URL.');
+            return;
+        }
+        fetchTimeline(routes.resultsRoot + suiteFile, testIndex, line);
+        return;
+    }
+
     // Check for file name.
     let file = queryParam('file');
     if (file) {
         $('#fileload').val(file);
         showText('Loading file...');
-        fetchFile(file, line);
+        fetchFile(file, line, queryParam('follow') === '1');
         return;
     }
 
@@ -117,34 +127,55 @@ function showRawLink(url, text) {
     raw.show();
 }
 
+function showFollowLink(file) {
+    let params = new URLSearchParams(document.location.search);
+    params.set('file', file);
+    params.set('follow', '1');
+    let link = $('#follow-url');
+    link.attr('href', '?' + params.toString());
+    link.show();
+}
+
 // showText sets the content of the viewer.
 function showText(text) {
-    let contentArea = document.getElementById('file-content');
-    let gutter = document.getElementById('gutter');
-
-    // Clear content.
-    contentArea.innerHTML = '';
-    gutter.innerHTML = '';
+    clearText();
 
     // Add the lines.
     let lines = text.split('\n');
-    for (let i = 0; i < lines.length; i++) {
-        // Avoid showing empty last line when there is a newline at the end.
-        if (i === lines.length-1 && lines[i] == "") {
-            break;
-        }
-        appendLine(contentArea, gutter, i + 1, lines[i]);
+    // Avoid showing empty last line when there is a newline at the end.
+    if (lines[lines.length-1] == "") {
+        lines.pop();
     }
+    appendLines(lines, 1);
 
     // Set meta-info.
     let meta = $('#meta');
     meta.text(lines.length + ' Lines, ' + formatBytes(text.length));
+}
+
+// clearText removes all content of the viewer.
+function clearText() {
+    document.getElementById('file-content').innerHTML = '';
+    document.getElementById('gutter').innerHTML = '';
 
     // Ensure viewer is visible.
     $('#viewer-header').show();
     $('#viewer').show();
 }
 
+// appendLines adds lines to the viewer. The first line gets the given number.
+function appendLines(lines, firstNumber) {
+    let contentArea = document.getElementById('file-content');
+    let gutter = document.getElementById('gutter');
+    let contentFrag = document.createDocumentFragment();
+    let gutterFrag = document.createDocumentFragment();
+    for (let i = 0; i < lines.length; i++) {
+        appendLine(contentFrag, gutterFrag, firstNumber + i, lines[i]);
+    }
+    contentArea.appendChild(contentFrag);
+    gutter.appendChild(gutterFrag);
+}
+
 function appendLine(contentArea, gutter, number, text) {
     let num = document.createElement('span');
     num.setAttribute('id', 'L' + number);
@@ -163,21 +194,88 @@ function lineNumberClicked() {
     history.replaceState(null, null, '#' + $(this).attr('id'));
 }
 
-// fetchFile loads up a new file to view
-async function fetchFile(url, line /* optional jump to line */ ) {
+// fetchFile loads up a new file to view. Large files are loaded in chunks as the
+// user scrolls down. When follow is set, lines appended to the file are shown as
+// they arrive.
+async function fetchFile(url, line /* optional jump to line */, follow) {
     let resultsRE = new RegExp('^' + routes.resultsRoot);
-    let text;
+    let title = url.replace(resultsRE, '');
+    let file = new testlog.ChunkLoader(url, follow);
+    showRawLink(url);
+    if (!follow && resultsRE.test(url)) {
+        showFollowLink(url);
+    }
+    showTitle(follow ? 'Following:' : null, title);
+    clearText();
+
+    // Load chunks until the requested line is available.
     try {
-        showRawLink(url);
-        text = await load(url, 'text');
+        do {
+            await loadChunk(file);
+        } while (!file.atEOF() && line && file.lineCount < line);
     } catch (err) {
         showError(`Failed to load ${url}`, err);
         return;
     }
-    let title = url.replace(resultsRE, '');
-    showTitle(null, title);
-    showText(text);
     setHL(line, true);
+
+    // Load more when scrolling near the end of the loaded content.
+    $(window).on('scroll', async function () {
+        let remaining = document.documentElement.scrollHeight - window.scrollY - window.innerHeight;
+        if (remaining > 2 * window.innerHeight || file.atEOF() || file.loading) {
+            return;
+        }
+        try {
+            await loadChunk(file);
+        } catch (err) {
+            console.error('chunk load failed:', err);
+        }
+        if (file.atEOF() && follow) {
+            followFile(file, url.replace(resultsRE, routes.followRoot));
+        }
+    });
+    if (file.atEOF() && follow) {
+        followFile(file, url.replace(resultsRE, routes.followRoot));
+    }
+}
+
+// loadChunk loads the next chunk of file and adds its lines to the viewer.
+async function loadChunk(file) {
+    let first = file.lineCount + 1;
+    let lines = await file.nextChunk();
+    appendLines(lines, first);
+    showFileMeta(file);
+}
+
+// followFile subscribes to the follow endpoint and appends new lines to the viewer.
+function followFile(file, url) {
+    if (file.following) {
+        return;
+    }
+    file.following = true;
+    let source = new EventSource(url + '?offset=' + file.offset);
+    source.onmessage = function (ev) {
+        let data = JSON.parse(ev.data);
+        let first = file.lineCount + 1;
+        appendLines(file.pushText(data.data, data.offset), first);
+        showFileMeta(file);
+    };
+    source.onerror = function (ev) {
+        console.error('follow stream error:', ev);
+        if (source.readyState === EventSource.CLOSED) {
+            $('#meta').append(' (follow stopped)');
+        }
+    };
+}
+
+function showFileMeta(file) {
+    var text = file.lineCount + ' Lines, ' + formatBytes(file.offset);
+    if (file.size !== null && file.offset < file.size) {
+        text += ' of ' + formatBytes(file.size) + ' loaded';
+    } else if (file.following) {
+        text += ' (following)';
+    }
+    $('#meta').text(text);
 }
 
 // fetchTestLog loads the suite file and displays the output of a test.
@@ -220,6 +318,59 @@ async function fetchTestLog(suiteFile, testIndex, line) {
     setHL(line, true);
 }
 
+// fetchTimeline shows the test log interleaved with the timestamped logs
+// of all clients in the test.
+async function fetchTimeline(suiteFile, testIndex, line) {
+    let data;
+    try {
+        data = await load(suiteFile, 'json');
+    } catch(err) {
+        showError(`Can't load suite file: ${suiteFile}`, err);
+        return;
+    }
+    let test = data.testCases && data.testCases[testIndex];
+    if (!test) {
+        showError('Invalid test data returned by server: ' + JSON.stringify(data, null, 2));
+        return;
+    }
+
+    let entries = [];
+    try {
+        // Add the test log. Entries without a recorded time are placed at the test end.
+        let logtext = test.summaryResult.details || '';
+        if (!logtext && test.summaryResult.log) {
+            let loader = new testlog.Loader(routes.resultsRoot + data.testDetailsLog, test.summaryResult.log);
+            logtext = await loader.text();
+        }
+        let times = test.summaryResult.logTimes || [{offset: 0, time: test.end}];
+        entries = entries.concat(testlog.splitTimedText(logtext, times, 'test'));
+
+        // Add the client logs.
+        for (let id in test.clientInfo) {
+            let client = test.clientInfo[id];
+            if (client.logFormat !== 'jsonl') {
+                continue;
+            }
+            let text = await load(routes.resultsRoot + client.logFile, 'text');
+            entries = entries.concat(testlog.parseJSONLog(text, client.name + ' ' + id));
+        }
+    } catch (err) {
+        showError('Loading timeline failed.', err);
+        return;
+    }
+    entries.push({time: new Date(test.start), source: 'test', text: '-- test started'});
+    entries.push({time: new Date(test.end), source: 'test', text: '-- test ended'});
+
+    // Sort by time. The sort is stable, so lines with equal time keep their order.
+    entries.sort(function (a, b) { return a.time - b.time; });
+    let lines = entries.map(function (e) {
+        return e.time.toISOString().substring(11, 23) + ' [' + e.source + '] ' + e.text;
+    });
+    showTitle('Timeline:', test.name);
+    showText(lines.join('\n'));
+    setHL(line, true);
+}
+
 async function load(url, dataType) {
     return $.ajax({url, dataType, xhr: common.newXhrWithProgressBar});
 }
@@ -1,4 +1,5 @@
 export const resultsRoot = '/results/';
+export const followRoot = '/follow/';
 
 // This object has constructor function for various app-internal URLs.
 export function simulatorLog(suiteID, suiteName, file) {
@@ -20,6 +21,16 @@ export function testLog(suiteID, suiteName, testIndex) {
     return '/viewer.html?' + params.toString();
 }
 
+export function testTimeline(suiteID, suiteName, testIndex) {
+    let params = new URLSearchParams({
+        'suiteid': suiteID,
+        'suitename': suiteName,
+        'testid': testIndex,
+        'showtimeline': '1',
+    });
+    return '/viewer.html?' + params.toString();
+}
+
 export function clientLog(suiteID, suiteName, testIndex, file) {
     let params = new URLSearchParams({
         'suiteid': suiteID,
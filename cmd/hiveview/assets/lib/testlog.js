@@ -42,6 +42,41 @@ export function splitHeadTail(text, maxLines) {
     return {head, tail, hiddenLines};
 }
 
+// splitTimedText splits a test log into timeline entries. The times array
+// contains {offset, time} objects, where offset is a byte offset into text.
+export function splitTimedText(text, times, source) {
+    let bytes = new TextEncoder().encode(text);
+    let decoder = new TextDecoder('utf-8');
+    let entries = [];
+    for (let i = 0; i < times.length; i++) {
+        let end = (i+1 < times.length) ? times[i+1].offset : bytes.length;
+        let part = decoder.decode(bytes.subarray(times[i].offset, end));
+        let time = new Date(times[i].time);
+        for (let line of part.replace(/\n$/, '').split('\n')) {
+            entries.push({time, source, text: line});
+        }
+    }
+    return entries;
+}
+
+// parseJSONLog parses a client log in JSON-lines format into timeline entries.
+export function parseJSONLog(text, source) {
+    let entries = [];
+    for (let line of text.split('\n')) {
+        if (line === '') {
+            continue;
+        }
+        try {
+            let obj = JSON.parse(line);
+            let src = obj.stream === 'stderr' ? source + ' stderr' : source;
+            entries.push({time: new Date(obj.time), source: src, text: obj.text});
+        } catch (err) {
+            console.error('invalid client log line:', line);
+        }
+    }
+    return entries;
+}
+
 // countLines returns the number of lines in the given string.
 function countLines(text) {
     var lines = 0, offset = 0;
@@ -325,3 +360,94 @@ class ReverseBuffer {
         return line + '\n';
     }
 }
+
+const CHUNK_LOADER_SIZE = 1048576;
+
+// ChunkLoader loads a text file incrementally using HTTP range requests, so
+// that very large files can be viewed without downloading them fully.
+export class ChunkLoader {
+    constructor(url, follow) {
+        this.url = url;
+        this.follow = follow;   // when set, the last partial line is held back
+        this.offset = 0;        // number of bytes loaded
+        this.size = null;       // total file size, if known
+        this.lineCount = 0;     // number of complete lines returned
+        this.loading = false;
+        this.following = false;
+    }
+
+    _decoder = new TextDecoder('utf-8');
+    _partial = ''; // unfinished line text
+
+    // atEOF returns true when the whole file was loaded.
+    atEOF() {
+        return this.size !== null && this.offset >= this.size;
+    }
+
+    // nextChunk fetches the next chunk of the file and returns its lines.
+    async nextChunk() {
+        this.loading = true;
+        try {
+            return await this._fetchChunk();
+        } finally {
+            this.loading = false;
+        }
+    }
+
+    async _fetchChunk() {
+        let end = this.offset + CHUNK_LOADER_SIZE - 1;
+        let options = {method: 'GET', headers: {'Range': 'bytes=' + this.offset + '-' + end}};
+        let response = await fetch(this.url, options);
+        if (response.status === 416) {
+            // Range not satisfiable: the file is empty.
+            this.size = this.offset;
+            return this._push(new Uint8Array(0));
+        }
+        if (!response.ok) {
+            let status = `${response.status} ${response.statusText}`;
+            throw new Error(`load ${this.url} failed: ${status}`);
+        }
+
+        let data = new Uint8Array(await response.arrayBuffer());
+        if (response.status === 206) {
+            let m = /\/(\d+)$/.exec(response.headers.get('content-range') || '');
+            this.size = m ? parseInt(m[1]) : null;
+        } else {
+            // The server sent the whole file. This happens for compressed logs.
+            this.size = data.length;
+            data = data.subarray(this.offset);
+        }
+        this.offset += data.length;
+        if (data.length === 0 && this.size === null) {
+            this.size = this.offset;
+        }
+        return this._push(data);
+    }
+
+    // pushText adds text received from the follow stream at the given file offset.
+    pushText(text, offset) {
+        if (offset !== this.offset) {
+            console.warn('follow stream offset mismatch:', offset, this.offset);
+        }
+        this.offset = offset + new TextEncoder().encode(text).length;
+        this.size = this.offset;
+        return this._lines(this._partial + text);
+    }
+
+    _push(data) {
+        let final = this.atEOF() && !this.follow;
+        let text = this._partial + this._decoder.decode(data, {stream: !final});
+        return this._lines(text, final);
+    }
+
+    _lines(text, final) {
+        let lines = text.split('\n');
+        this._partial = lines.pop();
+        if (final && this._partial !== '') {
+            lines.push(this._partial);
+            this._partial = '';
+        }
+        this.lineCount += lines.length;
+        return lines;
+    }
+}
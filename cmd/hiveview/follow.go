@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	followPollInterval = 500 * time.Millisecond
+	followMaxChunk     = 256 * 1024
+	followKeepAlive    = 15 * time.Second
+)
+
+// serveFollow streams data appended to a log file as server-sent events.
+// This is used by the viewer to follow client logs while tests are running.
+//
+// The 'offset' query parameter sets the file position where streaming starts.
+// A negative offset is relative to the end of the file. Every event carries
+// the file position of its data, and only complete lines are sent.
+type serveFollow struct {
+	fsys         fs.FS
+	pollInterval time.Duration
+}
+
+// followEvent is the JSON payload of a single event.
+type followEvent struct {
+	Offset int64  `json:"offset"`
+	Data   string `json:"data"`
+}
+
+func (h serveFollow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	offset, err := parseOffset(r.URL.Query().Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := h.fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	reader, ok := file.(io.ReaderAt)
+	if !ok {
+		http.Error(w, "file does not support random access", http.StatusInternalServerError)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Resolve the start offset.
+	if offset < 0 {
+		stat, err := file.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		offset += stat.Size()
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var (
+		poll      = time.NewTicker(h.interval())
+		lastWrite = time.Now()
+		buf       = make([]byte, followMaxChunk)
+	)
+	defer poll.Stop()
+	for {
+		n, err := reader.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+		chunk := followChunk(buf[:n], n == len(buf))
+		if len(chunk) > 0 {
+			if err := writeFollowEvent(w, followEvent{Offset: offset, Data: string(chunk)}); err != nil {
+				return
+			}
+			flusher.Flush()
+			offset += int64(len(chunk))
+			lastWrite = time.Now()
+			continue
+		}
+		// Keep the connection alive through proxies.
+		if time.Since(lastWrite) > followKeepAlive {
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// followChunk returns the part of the data which is sent in an event. Only
+// complete lines are sent, unless a single line exceeds the buffer. In that case,
+// the full buffer is sent up to the last complete UTF-8 character.
+func followChunk(data []byte, full bool) []byte {
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return data[:i+1]
+	}
+	if !full {
+		return nil
+	}
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func (h serveFollow) interval() time.Duration {
+	if h.pollInterval == 0 {
+		return followPollInterval
+	}
+	return h.pollInterval
+}
+
+func writeFollowEvent(w io.Writer, ev followEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+func parseOffset(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	offset, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	return offset, nil
+}
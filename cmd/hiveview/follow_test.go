@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "client.log")
+	if err := os.WriteFile(file, []byte("line 1\nline 2\npartial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := serveFollow{fsys: os.DirFS(dir), pollInterval: 10 * time.Millisecond}
+	srv := httptest.NewServer(http.StripPrefix("/follow/", h))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/follow/client.log?offset=-14", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("content-type"); ct != "text/event-stream" {
+		t.Fatalf("wrong content-type %q", ct)
+	}
+	events := bufio.NewScanner(resp.Body)
+
+	// The first event should contain the complete lines after the tail offset.
+	ev := nextFollowEvent(t, events)
+	if ev.Offset != 7 || ev.Data != "line 2\n" {
+		t.Fatalf("wrong first event: %+v", ev)
+	}
+
+	// Complete the partial line.
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(" line 3\n")
+	f.Close()
+	ev = nextFollowEvent(t, events)
+	if ev.Offset != 14 || ev.Data != "partial line 3\n" {
+		t.Fatalf("wrong second event: %+v", ev)
+	}
+}
+
+// TestFollowLongLine checks that a line longer than the read buffer is sent in
+// pieces, which aren't split inside of a UTF-8 character.
+func TestFollowLongLine(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("a", followMaxChunk-1)
+	content := "first\n" + long + "\u00e9tail\n"
+	if err := os.WriteFile(filepath.Join(dir, "client.log"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := serveFollow{fsys: os.DirFS(dir), pollInterval: 10 * time.Millisecond}
+	srv := httptest.NewServer(http.StripPrefix("/follow/", h))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/follow/client.log", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewScanner(resp.Body)
+	events.Buffer(nil, 2*followMaxChunk)
+
+	// The first read fills the buffer, but only the complete line is sent.
+	if ev := nextFollowEvent(t, events); ev.Offset != 0 || ev.Data != "first\n" {
+		t.Fatalf("wrong first event: %+v", ev)
+	}
+	// The long line is cut before the two-byte character at the buffer boundary.
+	if ev := nextFollowEvent(t, events); ev.Offset != 6 || ev.Data != long {
+		t.Fatalf("wrong second event: offset %d, %d bytes", ev.Offset, len(ev.Data))
+	}
+	if ev := nextFollowEvent(t, events); ev.Offset != int64(6+len(long)) || ev.Data != "\u00e9tail\n" {
+		t.Fatalf("wrong third event: %+v", ev)
+	}
+}
+
+func TestFollowNotFound(t *testing.T) {
+	h := serveFollow{fsys: os.DirFS(t.TempDir())}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/missing.log", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("wrong status %d", rec.Code)
+	}
+}
+
+func nextFollowEvent(t *testing.T, s *bufio.Scanner) followEvent {
+	t.Helper()
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev followEvent
+		if err := json.Unmarshal([]byte(line[6:]), &ev); err != nil {
+			t.Fatal("invalid event:", err)
+		}
+		return ev
+	}
+	t.Fatal("event stream ended:", s.Err())
+	return followEvent{}
+}
@@ -1,76 +1,320 @@
 package main
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ethereum/hive/internal/libhive"
+	"gopkg.in/yaml.v3"
 )
 
-func logdirGC(dir string, cutoff time.Time, keepMin int) error {
-	var (
-		fsys       = os.DirFS(dir)
-		usedFiles  = make(map[string]struct{})
-		keptSuites = 0
-		oldest     time.Time
-	)
+// gcConfig is the log retention policy used by -gc.
+//
+// The top-level settings apply to all suites. Rules can override them for
+// specific suites and clients. The first matching rule wins, and zero-valued
+// fields of a rule fall back to the top-level setting.
+type gcConfig struct {
+	gcPolicy `yaml:",inline"`
+
+	// KeepMin is the global minimum number of suite runs to keep.
+	KeepMin int      `yaml:"keep_min"`
+	Rules   []gcRule `yaml:"rules"`
+}
+
+// gcPolicy holds the retention settings for a group of suite runs.
+type gcPolicy struct {
+	// Keep is the time interval of runs to keep.
+	Keep time.Duration `yaml:"keep"`
+	// KeepFailed is the time interval of runs with failed tests to keep.
+	// It only takes effect when it is longer than Keep.
+	KeepFailed time.Duration `yaml:"keep_failed"`
+	// KeepLast is the number of most recent runs to keep for each combination
+	// of suite name and client set, regardless of their age.
+	KeepLast int `yaml:"keep_last"`
+	// CompressAfter is the age after which client logs of kept runs are
+	// compressed with gzip. Zero disables compression.
+	CompressAfter time.Duration `yaml:"compress_after"`
+}
+
+// gcRule is a policy override for matching suites.
+type gcRule struct {
+	// Suite is a regular expression matched against the suite name.
+	Suite string `yaml:"suite"`
+	// Client is a regular expression matched against the client names of a run.
+	// The rule matches when any of the clients matches.
+	Client string `yaml:"client"`
+
+	gcPolicy `yaml:",inline"`
+
+	suiteRE  *regexp.Regexp
+	clientRE *regexp.Regexp
+}
+
+// loadGCConfig reads a policy file. The settings in the file override the
+// given defaults.
+func loadGCConfig(file string, defaults gcConfig) (*gcConfig, error) {
+	cfg := defaults
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid gc config %s: %v", file, err)
+		}
+	}
+	if err := cfg.init(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// init compiles the rule expressions.
+func (cfg *gcConfig) init() (err error) {
+	for i := range cfg.Rules {
+		r := &cfg.Rules[i]
+		if r.Suite != "" {
+			if r.suiteRE, err = regexp.Compile(r.Suite); err != nil {
+				return fmt.Errorf("rule %d: invalid suite pattern: %v", i, err)
+			}
+		}
+		if r.Client != "" {
+			if r.clientRE, err = regexp.Compile(r.Client); err != nil {
+				return fmt.Errorf("rule %d: invalid client pattern: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// policyFor returns the policy that applies to a suite run.
+func (cfg *gcConfig) policyFor(suite *libhive.TestSuite, clients []string) gcPolicy {
+	for _, r := range cfg.Rules {
+		if !r.matches(suite, clients) {
+			continue
+		}
+		p := cfg.gcPolicy
+		if r.Keep != 0 {
+			p.Keep = r.Keep
+		}
+		if r.KeepFailed != 0 {
+			p.KeepFailed = r.KeepFailed
+		}
+		if r.KeepLast != 0 {
+			p.KeepLast = r.KeepLast
+		}
+		if r.CompressAfter != 0 {
+			p.CompressAfter = r.CompressAfter
+		}
+		return p
+	}
+	return cfg.gcPolicy
+}
+
+func (r *gcRule) matches(suite *libhive.TestSuite, clients []string) bool {
+	if r.suiteRE != nil && !r.suiteRE.MatchString(suite.Name) {
+		return false
+	}
+	if r.clientRE != nil {
+		for _, c := range clients {
+			if r.clientRE.MatchString(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// gcPlan is the result of applying the retention policy to a log directory.
+type gcPlan struct {
+	keptSuites    int
+	deletedSuites []string
+	oldest        time.Time
+	usedFiles     map[string]struct{}
+	compress      []string // client logs to be compressed
+	delete        []string // files to be deleted
+	deleteSize    int64
+}
+
+// planGC determines which files should be deleted and compressed.
+func planGC(fsys fs.FS, cfg *gcConfig, now time.Time) (*gcPlan, error) {
+	plan := &gcPlan{usedFiles: make(map[string]struct{})}
+	runs := make(map[string]int)
 
 	// Avoid deleting the status/version file.
-	usedFiles["hive.json"] = struct{}{}
+	plan.usedFiles["hive.json"] = struct{}{}
 
 	// Walk all suite files and pouplate the usedFiles set.
 	err := walkSummaryFiles(fsys, ".", func(suite *libhive.TestSuite, fi fs.FileInfo) error {
-		// Skip when too old and when above the minimum.
+		var (
+			clients = suiteClients(suite)
+			policy  = cfg.policyFor(suite, clients)
+			age     = now.Sub(suiteStart(suite))
+			group   = suite.Name + "\x00" + strings.Join(clients, ",")
+		)
 		// Note we rely on getting called in descending time order here.
-		if suiteStart(suite).Before(cutoff) && keptSuites >= keepMin {
+		runs[group]++
+		if !policy.keeps(age, suiteFailed(suite), runs[group]) && plan.keptSuites >= cfg.KeepMin {
+			plan.deletedSuites = append(plan.deletedSuites, fi.Name())
 			return nil
 		}
-		if oldest.IsZero() || suiteStart(suite).Before(oldest) {
-			oldest = suiteStart(suite)
+		if plan.oldest.IsZero() || suiteStart(suite).Before(plan.oldest) {
+			plan.oldest = suiteStart(suite)
 		}
 
 		// Add suite files and client logs.
-		keptSuites++
-		usedFiles[fi.Name()] = struct{}{}
-		usedFiles[suite.SimulatorLog] = struct{}{}
+		plan.keptSuites++
+		plan.usedFiles[fi.Name()] = struct{}{}
+		plan.usedFiles[suite.SimulatorLog] = struct{}{}
 		if suite.TestDetailsLog != "" {
-			usedFiles[suite.TestDetailsLog] = struct{}{}
+			plan.usedFiles[suite.TestDetailsLog] = struct{}{}
+		}
+		if suite.BuildInfo != "" {
+			plan.usedFiles[suite.BuildInfo] = struct{}{}
 		}
+		compress := policy.CompressAfter > 0 && age > policy.CompressAfter
 		for _, test := range suite.TestCases {
 			for _, client := range test.ClientInfo {
-				usedFiles[client.LogFile] = struct{}{}
+				plan.usedFiles[client.LogFile] = struct{}{}
+				if compress {
+					plan.compress = append(plan.compress, client.LogFile)
+				}
 			}
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Printf("keeping %d suites (%d files)\n", keptSuites, len(usedFiles))
-	fmt.Println("oldest suite date:", oldest)
-
-	// Delete all files which aren't in usedFiles.
-	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+	// Collect all files which aren't in usedFiles.
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Ignore scan errors.
 		}
 		if d.IsDir() {
 			return nil // Don't delete directories.
 		}
-		if _, used := usedFiles[path]; !used {
-			file := filepath.Join(dir, filepath.FromSlash(path))
-			// fmt.Println("rm", file)
-			err := os.Remove(file)
-			if err != nil {
-				fmt.Println("error:", err)
-			}
+		if plan.isUsed(path) {
+			return nil
+		}
+		plan.delete = append(plan.delete, path)
+		if info, err := d.Info(); err == nil {
+			plan.deleteSize += info.Size()
 		}
 		return nil
 	})
+	return plan, err
+}
+
+// keeps reports whether a suite run should be kept. The run number counts the
+// runs of the same suite and client set, starting at 1 for the most recent one.
+func (p gcPolicy) keeps(age time.Duration, failed bool, run int) bool {
+	limit := p.Keep
+	if failed && p.KeepFailed > limit {
+		limit = p.KeepFailed
+	}
+	return age < limit || run <= p.KeepLast
+}
+
+// isUsed reports whether the given file belongs to a kept suite. Compressed
+// client logs are considered used when the uncompressed log is.
+func (plan *gcPlan) isUsed(path string) bool {
+	if _, ok := plan.usedFiles[path]; ok {
+		return true
+	}
+	_, ok := plan.usedFiles[strings.TrimSuffix(path, ".gz")]
+	return ok
+}
+
+// report prints a summary of the plan.
+func (plan *gcPlan) report(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "keeping %d suites (%d files)\n", plan.keptSuites, len(plan.usedFiles))
+	fmt.Fprintln(w, "oldest suite date:", plan.oldest)
+	fmt.Fprintf(w, "deleting %d suites, %d files (%s)\n", len(plan.deletedSuites), len(plan.delete), formatSize(plan.deleteSize))
+	fmt.Fprintf(w, "compressing up to %d client logs\n", len(plan.compress))
+	if !verbose {
+		return
+	}
+	for _, name := range plan.deletedSuites {
+		fmt.Fprintln(w, "delete suite", name)
+	}
+	for _, file := range plan.delete {
+		fmt.Fprintln(w, "rm", file)
+	}
+	for _, file := range plan.compress {
+		fmt.Fprintln(w, "gzip", file)
+	}
+}
+
+// logdirGC applies the retention policy to the log directory.
+// When dryRun is set, it only reports what would be deleted.
+func logdirGC(dir string, cfg *gcConfig, dryRun bool) error {
+	plan, err := planGC(os.DirFS(dir), cfg, time.Now())
+	if err != nil {
+		return err
+	}
+	plan.report(os.Stdout, dryRun)
+	if dryRun {
+		return nil
+	}
+
+	for _, path := range plan.delete {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.Remove(file); err != nil {
+			fmt.Println("error:", err)
+		}
+	}
+	for _, path := range plan.compress {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := gzipFile(file); err != nil && !os.IsNotExist(err) {
+			fmt.Println("error:", err)
+		}
+	}
+	return nil
+}
+
+// gzipFile replaces file with a compressed copy at file + ".gz".
+func gzipFile(file string) error {
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := file + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, file+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(file)
 }
 
 func suiteStart(suite *libhive.TestSuite) time.Time {
@@ -79,3 +323,40 @@ func suiteStart(suite *libhive.TestSuite) time.Time {
 	}
 	return time.Time{}
 }
+
+// suiteClients returns the sorted names of all clients used in a suite.
+func suiteClients(suite *libhive.TestSuite) []string {
+	var clients []string
+	for _, test := range suite.TestCases {
+		for _, client := range test.ClientInfo {
+			if !contains(clients, client.Name) {
+				clients = append(clients, client.Name)
+			}
+		}
+	}
+	sort.Strings(clients)
+	return clients
+}
+
+// suiteFailed reports whether any test in the suite has failed.
+func suiteFailed(suite *libhive.TestSuite) bool {
+	for _, test := range suite.TestCases {
+		if !test.SummaryResult.Pass {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for n := n / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/hive/internal/libhive"
+)
+
+func TestPlanGC(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		now = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+		day = 24 * time.Hour
+	)
+	// Suite files are named like hive names them, so that the newest-first walk
+	// order of the summary files matches the start times.
+	writeSuite(t, dir, "a", "engine", "geth", now.Add(-2*day), true)
+	var (
+		b = writeSuite(t, dir, "b", "engine", "geth", now.Add(-10*day), true)
+		c = writeSuite(t, dir, "c", "engine", "geth", now.Add(-11*day), false)
+		d = writeSuite(t, dir, "d", "rpc", "kakarot", now.Add(-20*day), true)
+		e = writeSuite(t, dir, "e", "rpc", "kakarot", now.Add(-21*day), true)
+		g = writeSuite(t, dir, "g", "rpc", "kakarot", now.Add(-22*day), true)
+		f = writeSuite(t, dir, "f", "sync", "geth", now.Add(-30*day), true)
+	)
+	writeFile(t, dir, "geth/client-"+f+".log.gz")
+	writeFile(t, dir, "stray.txt")
+
+	cfg := &gcConfig{
+		gcPolicy: gcPolicy{Keep: 5 * day, KeepFailed: 15 * day, KeepLast: 1, CompressAfter: 3 * day},
+		Rules: []gcRule{
+			{Suite: "^rpc$", gcPolicy: gcPolicy{KeepLast: 2}},
+			{Client: "geth", Suite: "^sync$", gcPolicy: gcPolicy{CompressAfter: 100 * day}},
+		},
+	}
+	if err := cfg.init(); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := planGC(os.DirFS(dir), cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a: recent. b: old and passing. c: failed, within keep_failed.
+	// d+e: last two rpc runs, g: older rpc run. f: last sync run.
+	sort.Strings(plan.deletedSuites)
+	wantDeleted := []string{b + ".json", g + ".json"}
+	sort.Strings(wantDeleted)
+	if !reflect.DeepEqual(plan.deletedSuites, wantDeleted) {
+		t.Errorf("wrong deleted suites: %v", plan.deletedSuites)
+	}
+	sort.Strings(plan.delete)
+	wantDelete := []string{
+		b + ".json", "details/" + b + ".log", "engine/sim-" + b + ".log", "geth/client-" + b + ".log",
+		g + ".json", "details/" + g + ".log", "kakarot/client-" + g + ".log", "rpc/sim-" + g + ".log",
+		"stray.txt",
+	}
+	sort.Strings(wantDelete)
+	if !reflect.DeepEqual(plan.delete, wantDelete) {
+		t.Errorf("wrong deleted files: %v", plan.delete)
+	}
+	sort.Strings(plan.compress)
+	wantCompress := []string{"geth/client-" + c + ".log", "kakarot/client-" + d + ".log", "kakarot/client-" + e + ".log"}
+	sort.Strings(wantCompress)
+	if !reflect.DeepEqual(plan.compress, wantCompress) {
+		t.Errorf("wrong compressed files: %v", plan.compress)
+	}
+	// Check compression keeps the content.
+	if err := logdirGC(dir, cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kakarot", "client-"+d+".log.gz")); err != nil {
+		t.Fatal("compressed log missing:", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kakarot", "client-"+d+".log")); !os.IsNotExist(err) {
+		t.Fatal("uncompressed log not removed")
+	}
+}
+
+// writeSuite writes a suite file and its logs, and returns the name of the suite
+// file without extension. The client log of the sync suite is left out, it is
+// written compressed by the test.
+func writeSuite(t *testing.T, dir, tag, name, client string, start time.Time, pass bool) string {
+	id := fmt.Sprintf("%d-%s", start.Unix(), tag)
+	suite := libhive.TestSuite{
+		Name:           name,
+		SimulatorLog:   fmt.Sprintf("%s/sim-%s.log", name, id),
+		TestDetailsLog: fmt.Sprintf("details/%s.log", id),
+		TestCases: map[libhive.TestID]*libhive.TestCase{
+			1: {
+				Start:         start,
+				SummaryResult: libhive.TestResult{Pass: pass},
+				ClientInfo: map[string]*libhive.ClientInfo{
+					"c1": {Name: client, LogFile: fmt.Sprintf("%s/client-%s.log", client, id)},
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(&suite)
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, suite.SimulatorLog)
+	writeFile(t, dir, suite.TestDetailsLog)
+	if name != "sync" {
+		writeFile(t, dir, suite.TestCases[1].ClientInfo["c1"].LogFile)
+	}
+	return id
+}
+
+func writeFile(t *testing.T, dir, name string) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("log output\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -25,6 +25,8 @@ func main() {
 		gc             = flag.Bool("gc", false, "Deletes old log files")
 		gcKeepInterval = flag.Duration("keep", 5*durationMonth, "Time interval of past log files to keep (for -gc)")
 		gcKeepMin      = flag.Int("keep-min", 10, "Minmum number of suite outputs to keep (for -gc)")
+		gcConfigFile   = flag.String("gc.config", "", "Path to YAML file with log retention rules (for -gc)")
+		gcDryRun       = flag.Bool("gc.dryrun", false, "Reports files that would be deleted without touching them (for -gc)")
 		config         serverConfig
 	)
 	flag.StringVar(&config.listenAddr, "addr", "0.0.0.0:8080", "HTTP server listen address")
@@ -41,8 +43,15 @@ func main() {
 		fsys := os.DirFS(config.logDir)
 		generateListing(fsys, ".", os.Stdout)
 	case *gc:
-		cutoff := time.Now().Add(-*gcKeepInterval)
-		logdirGC(config.logDir, cutoff, *gcKeepMin)
+		defaults := gcConfig{KeepMin: *gcKeepMin}
+		defaults.Keep = *gcKeepInterval
+		gcConfig, err := loadGCConfig(*gcConfigFile, defaults)
+		if err != nil {
+			log.Fatalf("-gc.config: %v", err)
+		}
+		if err := logdirGC(config.logDir, gcConfig, *gcDryRun); err != nil {
+			log.Fatal(err)
+		}
 	case *deploy:
 		doDeploy(&config)
 	default:
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"compress/gzip"
 	"embed"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -50,12 +55,13 @@ func runServer(config serverConfig) {
 	// Create handlers.
 	deployFS := newDeployFS(assetFS, &config)
 	logDirFS := os.DirFS(config.logDir)
-	logHandler := http.FileServer(http.FS(logDirFS))
+	logHandler := serveLogs{fsys: logDirFS}
 	listingHandler := serveListing{fsys: logDirFS}
 
 	mux := mux.NewRouter()
 	mux.Handle("/listing.jsonl", listingHandler).Methods("GET")
 	mux.PathPrefix("/results").Handler(http.StripPrefix("/results/", logHandler))
+	mux.PathPrefix("/follow/").Handler(http.StripPrefix("/follow/", serveFollow{fsys: logDirFS})).Methods("GET")
 	mux.PathPrefix("/").Handler(serveFiles{deployFS})
 
 	// Start the server.
@@ -78,6 +84,109 @@ func (h serveListing) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveLogs serves files from the log directory. Client logs which have been
+// compressed by -gc are served transparently from their .gz file.
+type serveLogs struct{ fsys fs.FS }
+
+func (h serveLogs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if _, err := fs.Stat(h.fsys, name); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		http.FileServer(http.FS(h.fsys)).ServeHTTP(w, r)
+		return
+	}
+	file, err := h.fsys.Open(name + ".gz")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("content-type", "text/plain; charset=utf-8")
+	w.Header().Add("vary", "accept-encoding")
+	if r.Header.Get("range") == "" && acceptsGzip(r) {
+		w.Header().Set("content-encoding", "gzip")
+		io.Copy(w, file)
+		return
+	}
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.Header.Get("range") == "" {
+		io.Copy(w, zr)
+		return
+	}
+	// Ranges refer to the uncompressed log, which is decompressed again for
+	// every request instead of being held in memory.
+	seeker, ok := file.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "log file is not seekable", http.StatusInternalServerError)
+		return
+	}
+	var modTime time.Time
+	if info, err := file.Stat(); err == nil {
+		modTime = info.ModTime()
+	}
+	http.ServeContent(w, r, name, modTime, &gzipSeeker{file: seeker, zr: zr, size: -1})
+}
+
+// gzipSeeker makes the uncompressed content of a gzip file seekable. Seeking
+// forward skips over the content, and seeking backward restarts decompression
+// from the beginning of the file.
+type gzipSeeker struct {
+	file io.ReadSeeker
+	zr   *gzip.Reader
+	pos  int64 // offset in the uncompressed content
+	size int64 // size of the uncompressed content, -1 if unknown
+}
+
+func (s *gzipSeeker) Read(p []byte) (int, error) {
+	n, err := s.zr.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+func (s *gzipSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		if s.size < 0 {
+			if _, err := io.Copy(io.Discard, s); err != nil {
+				return 0, err
+			}
+			s.size = s.pos
+		}
+		offset += s.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek to negative offset")
+	}
+	if offset < s.pos {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if err := s.zr.Reset(s.file); err != nil {
+			return 0, err
+		}
+		s.pos = 0
+	}
+	if _, err := io.CopyN(io.Discard, s, offset-s.pos); err != nil && err != io.EOF {
+		return 0, err
+	}
+	return offset, nil
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("accept-encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
 type serveFiles struct{ fsys fs.FS }
 
 func (h serveFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
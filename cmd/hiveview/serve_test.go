@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeCompressedLogs(t *testing.T) {
+	const content = "line 1\nline 2\nline 3\n"
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(content))
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "client.log.gz"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := serveLogs{fsys: os.DirFS(dir)}
+
+	get := func(name string, header map[string]string) *http.Response {
+		req := httptest.NewRequest("GET", "/"+name, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+	body := func(resp *http.Response) string {
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	// Without gzip support, the log is decompressed.
+	resp := get("client.log", nil)
+	if resp.StatusCode != 200 || body(resp) != content {
+		t.Fatalf("wrong response %d for plain request", resp.StatusCode)
+	}
+
+	// With gzip support, the compressed file is sent as is.
+	resp = get("client.log", map[string]string{"accept-encoding": "gzip, deflate"})
+	if resp.Header.Get("content-encoding") != "gzip" {
+		t.Fatal("compressed log not sent with gzip encoding")
+	}
+	if got := body(resp); got != buf.String() {
+		t.Fatal("wrong compressed content")
+	}
+
+	// Ranges refer to the uncompressed log, even when gzip is accepted.
+	resp = get("client.log", map[string]string{"accept-encoding": "gzip", "range": "bytes=7-12"})
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("wrong status %d for range request", resp.StatusCode)
+	}
+	if resp.Header.Get("content-encoding") != "" {
+		t.Fatal("range response has content encoding")
+	}
+	if got := body(resp); got != "line 2" {
+		t.Fatalf("wrong range content %q", got)
+	}
+	if got := resp.Header.Get("content-range"); got != "bytes 7-12/21" {
+		t.Fatalf("wrong content-range %q", got)
+	}
+
+	// Suffix ranges need the size of the uncompressed log.
+	resp = get("client.log", map[string]string{"range": "bytes=-7"})
+	if got := body(resp); resp.StatusCode != http.StatusPartialContent || got != "line 3\n" {
+		t.Fatalf("wrong suffix range response %d %q", resp.StatusCode, got)
+	}
+	// Multiple ranges are served out of order.
+	resp = get("client.log", map[string]string{"range": "bytes=14-19,0-5"})
+	if got := body(resp); resp.StatusCode != http.StatusPartialContent || !strings.Contains(got, "line 3") || !strings.Contains(got, "line 1") {
+		t.Fatalf("wrong multi-range response %d %q", resp.StatusCode, got)
+	}
+
+	if resp := get("missing.log", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("wrong status %d for missing log", resp.StatusCode)
+	}
+}
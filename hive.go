@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -47,12 +48,21 @@ func main() {
 			"If a very long chain is imported, this timeout may need to be quite large.\n"+
 			"A lower value means that hive won't wait as long in case the node crashes and\n"+
 			"never opens the RPC port.")
+
+		clientLogFormat = flag.String("client.logformat", libhive.LogFormatText, "Format of client log files. Use \"jsonl\" to store\n"+
+			"timestamped JSON lines with separate stdout/stderr streams.")
+
+		simBuildArgs = make(buildArgsFlag)
 	)
+	flag.Var(simBuildArgs, "sim.buildarg", "Build `argument` of simulator images, given as name=value. Can be given multiple times.")
 
 	// Parse the flags and configure the logger.
 	flag.Parse()
 	log15.Root().SetHandler(log15.LvlFilterHandler(log15.Lvl(*loglevelFlag), log15.StreamHandler(os.Stderr, log15.TerminalFormat())))
 
+	if *clientLogFormat != libhive.LogFormatText && *clientLogFormat != libhive.LogFormatJSONL {
+		fatal("-client.logformat: unknown log format", *clientLogFormat)
+	}
 	if *simTestLimit > 0 {
 		log15.Warn("Option --sim.testlimit is deprecated and will have no effect.")
 	}
@@ -114,6 +124,7 @@ func main() {
 		SimRandomSeed:      *simRandomSeed,
 		SimDurationLimit:   *simTimeLimit,
 		ClientStartTimeout: *clientTimeout,
+		ClientLogFormat:    *clientLogFormat,
 	}
 	runner := libhive.NewRunner(inv, builder, cb)
 
@@ -138,7 +149,7 @@ func main() {
 	}
 
 	// Build clients and simulators.
-	if err := runner.Build(ctx, clientList, simList); err != nil {
+	if err := runner.Build(ctx, clientList, simList, simBuildArgs); err != nil {
 		fatal(err)
 	}
 	if *simDevMode {
@@ -180,6 +191,27 @@ func parseClientsFile(inv *libhive.Inventory, file string) ([]libhive.ClientDesi
 	return libhive.ParseClientListYAML(inv, f)
 }
 
+// buildArgsFlag collects docker build arguments given as name=value.
+type buildArgsFlag map[string]string
+
+func (f buildArgsFlag) String() string {
+	args := make([]string, 0, len(f))
+	for name, value := range f {
+		args = append(args, name+"="+value)
+	}
+	sort.Strings(args)
+	return strings.Join(args, ",")
+}
+
+func (f buildArgsFlag) Set(arg string) error {
+	name, value, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid build argument %q, want name=value", arg)
+	}
+	f[name] = value
+	return nil
+}
+
 func flagIsSet(name string) bool {
 	var found bool
 	flag.Visit(func(f *flag.Flag) {
@@ -0,0 +1,169 @@
+package hiveproxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsPath is the path where the proxy frontend serves request metrics.
+const MetricsPath = "/metrics"
+
+// latencyBuckets are the upper bounds of the request latency histogram, in seconds.
+// Starting clients can take a long time, so the buckets go up to a few minutes.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// routeParams maps API path elements to the name of the parameter following them.
+var routeParams = map[string]string{
+	"testsuite": "{suite}",
+	"test":      "{test}",
+	"node":      "{node}",
+	"network":   "{network}",
+}
+
+// routeKey identifies a simulation API route.
+type routeKey struct {
+	sim    string
+	method string
+	route  string
+}
+
+// routeStats holds the metrics of a single route.
+type routeStats struct {
+	count   uint64
+	errors  uint64
+	sum     float64
+	buckets []uint64
+}
+
+// requestMetrics collects per-route request metrics of the proxy frontend.
+type requestMetrics struct {
+	mu     sync.Mutex
+	routes map[routeKey]*routeStats
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{routes: make(map[routeKey]*routeStats)}
+}
+
+// observe records a finished request. Requests answered with a server error
+// status are counted as errors.
+func (m *requestMetrics) observe(r *http.Request, status int, elapsed time.Duration) {
+	sim, path, _ := splitSimPath(r.URL.Path)
+	key := routeKey{sim: sim, method: r.Method, route: normalizeRoute(path)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.routes[key]
+	if st == nil {
+		st = &routeStats{buckets: make([]uint64, len(latencyBuckets))}
+		m.routes[key] = st
+	}
+	st.count++
+	if status >= 500 {
+		st.errors++
+	}
+	seconds := elapsed.Seconds()
+	st.sum += seconds
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			st.buckets[i]++
+		}
+	}
+}
+
+// writeTo writes the metrics in Prometheus text format.
+func (m *requestMetrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]routeKey, 0, len(m.routes))
+	for k := range m.routes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.sim != b.sim {
+			return a.sim < b.sim
+		}
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+
+	fmt.Fprintln(w, "# HELP hiveproxy_requests_total Number of simulation API requests.")
+	fmt.Fprintln(w, "# TYPE hiveproxy_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "hiveproxy_requests_total{%s} %d\n", k.labels(), m.routes[k].count)
+	}
+	fmt.Fprintln(w, "# HELP hiveproxy_request_errors_total Number of simulation API requests that failed with a server error.")
+	fmt.Fprintln(w, "# TYPE hiveproxy_request_errors_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "hiveproxy_request_errors_total{%s} %d\n", k.labels(), m.routes[k].errors)
+	}
+	fmt.Fprintln(w, "# HELP hiveproxy_request_duration_seconds Latency of simulation API requests.")
+	fmt.Fprintln(w, "# TYPE hiveproxy_request_duration_seconds histogram")
+	for _, k := range keys {
+		st, labels := m.routes[k], k.labels()
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "hiveproxy_request_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, le, st.buckets[i])
+		}
+		fmt.Fprintf(w, "hiveproxy_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, st.count)
+		fmt.Fprintf(w, "hiveproxy_request_duration_seconds_sum{%s} %g\n", labels, st.sum)
+		fmt.Fprintf(w, "hiveproxy_request_duration_seconds_count{%s} %d\n", labels, st.count)
+	}
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("sim=%q,method=%q,route=%q", k.sim, k.method, k.route)
+}
+
+// normalizeRoute replaces the parameters in an API path with placeholders, e.g.
+// /testsuite/1/test/2/node turns into /testsuite/{suite}/test/{test}/node.
+func normalizeRoute(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case parts[i-1] == "{network}":
+			parts[i] = "{node}"
+		case routeParams[parts[i-1]] != "":
+			parts[i] = routeParams[parts[i-1]]
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// metricsHandler wraps the frontend handler, recording request metrics and
+// serving them on MetricsPath.
+type metricsHandler struct {
+	next    http.Handler
+	metrics *requestMetrics
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == MetricsPath {
+		w.Header().Set("content-type", "text/plain; version=0.0.4")
+		h.metrics.writeTo(w)
+		return
+	}
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(sw, r)
+	h.metrics.observe(r, sw.status, time.Since(start))
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -9,6 +9,10 @@
 // The frontend also has auxiliary functions which can be triggered by the backend via
 // RPC. Specifically, it can run TCP endpoint probes, which are used by hive to confirm
 // that the client container has started.
+//
+// A single proxy can serve the APIs of multiple concurrent simulations, see SimMux. The
+// frontend records request counts, latencies and errors per simulation and API route,
+// and serves them in Prometheus text format at /metrics.
 package hiveproxy
 
 import (
@@ -146,7 +150,7 @@ func RunFrontend(r io.Reader, w io.WriteCloser, listener net.Listener) (*Proxy,
 			return mux.Open()
 		},
 	}
-	p.httpsrv.Handler = &httputil.ReverseProxy{
+	revproxy := &httputil.ReverseProxy{
 		Director: func(req *http.Request) {
 			// This is needed to make http.Transport forward the request.
 			req.URL.Scheme = "http"
@@ -154,6 +158,7 @@ func RunFrontend(r io.Reader, w io.WriteCloser, listener net.Listener) (*Proxy,
 		},
 		Transport: transport,
 	}
+	p.httpsrv.Handler = &metricsHandler{next: revproxy, metrics: newRequestMetrics()}
 	go p.serve(listener)
 	return p, nil
 }
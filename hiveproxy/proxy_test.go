@@ -5,6 +5,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -28,6 +29,78 @@ func TestProxyHTTP(t *testing.T) {
 	}
 }
 
+func TestProxyMultipleSimulations(t *testing.T) {
+	mux := NewSimMux()
+	id1 := mux.Add(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "sim1 "+r.URL.Path)
+	}))
+	p := runProxyPair(t, mux)
+	defer p.close()
+
+	base := "http://" + p.lis.Addr().String()
+	// With a single simulation, requests without prefix are accepted.
+	if body := httpGet(t, base+"/clients"); body != "sim1 /clients" {
+		t.Fatalf("wrong response %q", body)
+	}
+
+	id2 := mux.Add(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "sim2 "+r.URL.Path)
+	}))
+	if body := httpGet(t, base+SimPath(id1)+"/testsuite/1/test"); body != "sim1 /testsuite/1/test" {
+		t.Fatalf("wrong response %q", body)
+	}
+	if body := httpGet(t, base+SimPath(id2)+"/testsuite/3/test/4/node"); body != "sim2 /testsuite/3/test/4/node" {
+		t.Fatalf("wrong response %q", body)
+	}
+
+	// Check the metrics.
+	metrics := httpGet(t, base+MetricsPath)
+	for _, want := range []string{
+		`hiveproxy_requests_total{sim="",method="GET",route="/clients"} 1`,
+		`hiveproxy_requests_total{sim="1",method="GET",route="/testsuite/{suite}/test"} 1`,
+		`hiveproxy_request_errors_total{sim="2",method="GET",route="/testsuite/{suite}/test/{test}/node"} 1`,
+		`hiveproxy_request_duration_seconds_count{sim="2",method="GET",route="/testsuite/{suite}/test/{test}/node"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(metrics)
+	}
+}
+
+func TestNormalizeRoute(t *testing.T) {
+	tests := map[string]string{
+		"/clients":   "/clients",
+		"/testsuite": "/testsuite",
+		"/testsuite/0/test/12/node/a1b2c3d4/exec":  "/testsuite/{suite}/test/{test}/node/{node}/exec",
+		"/testsuite/0/network/test/a1b2c3d4":       "/testsuite/{suite}/network/{network}/{node}",
+		"/testsuite/0/test/12/node/a1b2c3d4/pause": "/testsuite/{suite}/test/{test}/node/{node}/pause",
+		"/testsuite/0/network/network/bridge":      "/testsuite/{suite}/network/{network}/{node}",
+	}
+	for path, want := range tests {
+		if got := normalizeRoute(path); got != want {
+			t.Errorf("normalizeRoute(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func httpGet(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
 func TestProxyCheckLive(t *testing.T) {
 	p := runProxyPair(t, nil)
 	defer p.close()
@@ -0,0 +1,101 @@
+package hiveproxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// simPathPrefix is the path prefix of requests for a specific simulation.
+const simPathPrefix = "/sim/"
+
+// SimMux is a HTTP handler which multiplexes the APIs of multiple concurrent
+// simulations over a single proxy.
+//
+// Requests with path /sim/<id>/... are relayed to the handler registered for id,
+// with the prefix removed. Requests without the prefix are relayed to the only
+// registered handler, and fail if there is more than one.
+type SimMux struct {
+	mu       sync.RWMutex
+	handlers map[string]http.Handler
+	lastID   uint64
+}
+
+// NewSimMux creates an empty multiplexer.
+func NewSimMux() *SimMux {
+	return &SimMux{handlers: make(map[string]http.Handler)}
+}
+
+// Add registers a simulation API handler. It returns the simulation ID.
+func (m *SimMux) Add(h http.Handler) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	id := strconv.FormatUint(m.lastID, 10)
+	m.handlers[id] = h
+	return id
+}
+
+// Remove unregisters the handler of a simulation.
+func (m *SimMux) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.handlers, id)
+}
+
+// Len returns the number of registered simulations.
+func (m *SimMux) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.handlers)
+}
+
+// SimPath returns the path prefix of the given simulation.
+func SimPath(id string) string {
+	return simPathPrefix + id
+}
+
+func (m *SimMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, rest, ok := splitSimPath(r.URL.Path)
+	m.mu.RLock()
+	var h http.Handler
+	if ok {
+		h = m.handlers[id]
+	} else if len(m.handlers) == 1 {
+		for _, handler := range m.handlers {
+			h = handler
+		}
+	}
+	m.mu.RUnlock()
+
+	switch {
+	case h == nil && ok:
+		http.Error(w, "unknown simulation "+id, http.StatusNotFound)
+	case h == nil:
+		http.Error(w, "request does not specify simulation", http.StatusNotFound)
+	case ok:
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		r2.URL = &u
+		r2.URL.Path = rest
+		r2.URL.RawPath = ""
+		h.ServeHTTP(w, r2)
+	default:
+		h.ServeHTTP(w, r)
+	}
+}
+
+// splitSimPath splits a request path into the simulation ID and the API path.
+func splitSimPath(path string) (id, rest string, ok bool) {
+	if !strings.HasPrefix(path, simPathPrefix) {
+		return "", path, false
+	}
+	id, rest, _ = strings.Cut(path[len(simPathPrefix):], "/")
+	if id == "" {
+		return "", path, false
+	}
+	return id, "/" + rest, true
+}
@@ -1,5 +1,7 @@
 package hivesim
 
+import "time"
+
 // SuiteID identifies a test suite context.
 type SuiteID uint32
 
@@ -10,6 +12,16 @@ type TestID uint32
 type TestResult struct {
 	Pass    bool   `json:"pass"`
 	Details string `json:"details"`
+
+	// LogTimes records when each part of Details was logged.
+	LogTimes []TestLogTime `json:"logTimes,omitempty"`
+}
+
+// TestLogTime is the time of a test log entry. The offset is the
+// position of the entry in TestResult.Details.
+type TestLogTime struct {
+	Offset int64     `json:"offset"`
+	Time   time.Time `json:"time"`
 }
 
 // ExecInfo is the result of running a command in a client container.
@@ -1,7 +1,11 @@
 package hivesim
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -12,12 +16,26 @@ import (
 // This is the static secret configured for all execution-layer clients.
 var ENGINEAPI_JWT_SECRET = [32]byte{0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x65}
 
-func jwtAuth(secret [32]byte) rpc.HTTPAuth {
+// ENGINEAPI_JWT_SECRET_FILE is the file from which execution-layer clients read
+// their secret. Clients fall back to ENGINEAPI_JWT_SECRET if it doesn't exist.
+const ENGINEAPI_JWT_SECRET_FILE = "/jwtsecret"
+
+// WithEngineAPISecret configures the secret which authenticates requests to the
+// engine API of an execution-layer client. Use EngineAPIWithSecret to connect to
+// the client.
+func WithEngineAPISecret(secret []byte) StartOption {
+	content := []byte("0x" + hex.EncodeToString(secret))
+	return WithDynamicFile(ENGINEAPI_JWT_SECRET_FILE, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	})
+}
+
+func jwtAuth(secret []byte) rpc.HTTPAuth {
 	return func(h http.Header) error {
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"iat": &jwt.NumericDate{Time: time.Now()},
 		})
-		s, err := token.SignedString(secret[:])
+		s, err := token.SignedString(secret)
 		if err != nil {
 			return fmt.Errorf("failed to create JWT token: %w", err)
 		}
@@ -25,3 +43,16 @@ func jwtAuth(secret [32]byte) rpc.HTTPAuth {
 		return nil
 	}
 }
+
+func dialEngineAPI(ip string, secret []byte) *rpc.Client {
+	auth := rpc.WithHTTPAuth(jwtAuth(secret))
+	url := fmt.Sprintf("http://%v:8551", ip)
+	client, _ := rpc.DialOptions(context.Background(), url, auth)
+	return client
+}
+
+func dialEngineAPIWebSocket(ctx context.Context, ip string, secret []byte) (*rpc.Client, error) {
+	auth := rpc.WithHTTPAuth(jwtAuth(secret))
+	url := fmt.Sprintf("ws://%v:8551", ip)
+	return rpc.DialOptions(ctx, url, auth)
+}
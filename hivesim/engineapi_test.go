@@ -0,0 +1,81 @@
+package hivesim
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestJWTAuth(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	h := make(http.Header)
+	if err := jwtAuth(secret)(h); err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Parse(strings.TrimPrefix(h.Get("Authorization"), "Bearer "), func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	if err != nil {
+		t.Fatal("invalid token:", err)
+	}
+	if _, ok := token.Claims.(jwt.MapClaims)["iat"]; !ok {
+		t.Fatal("token has no iat claim")
+	}
+}
+
+func TestWithEngineAPISecret(t *testing.T) {
+	setup := &clientSetup{files: make(map[string]func() (io.ReadCloser, error))}
+	WithEngineAPISecret([]byte{0x01, 0xab}).apply(setup)
+	src, ok := setup.files[ENGINEAPI_JWT_SECRET_FILE]
+	if !ok {
+		t.Fatal("secret file not added")
+	}
+	r, err := src()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(r)
+	if string(content) != "0x01ab" {
+		t.Fatalf("wrong secret file content: %q", content)
+	}
+}
+
+// TestJWTAuthWebSocket checks that the token is sent with the WebSocket upgrade
+// request.
+func TestJWTAuthWebSocket(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	ws := srv.WebsocketHandler([]string{"*"})
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := jwt.Parse(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), func(token *jwt.Token) (interface{}, error) {
+			return secret, nil
+		}, jwt.WithValidMethods([]string{"HS256"}))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ws.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+	url := "ws://" + strings.TrimPrefix(httpsrv.URL, "http://")
+
+	client, err := rpc.DialOptions(context.Background(), url, rpc.WithHTTPAuth(jwtAuth(secret)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rpc.DialOptions(context.Background(), url, rpc.WithHTTPAuth(jwtAuth([]byte("wrong")))); err == nil {
+		t.Fatal("connection with wrong secret succeeded")
+	}
+}
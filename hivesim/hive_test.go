@@ -2,6 +2,7 @@ package hivesim
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http/httptest"
@@ -400,6 +401,72 @@ func TestStartClientInitialNetworks(t *testing.T) {
 	}
 }
 
+// This test checks that restarting a client stops the container and starts
+// it again with its original options, appending to the log file.
+func TestRestartClient(t *testing.T) {
+	var (
+		calls   []string
+		starts  []libhive.ContainerOptions
+		ipcount byte
+	)
+	tm, srv := newFakeAPI(&fakes.BackendHooks{
+		StartContainer: func(image, containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error) {
+			calls = append(calls, "start")
+			starts = append(starts, opt)
+			ipcount++
+			return &libhive.ContainerInfo{IP: net.IP{192, 0, 2, ipcount}.String()}, nil
+		},
+		StopContainer: func(containerID string) error {
+			calls = append(calls, "stop")
+			return nil
+		},
+	})
+	defer srv.Close()
+	defer tm.Terminate()
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite(&simapi.TestRequest{Name: "suite"}, "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+	testID, err := sim.StartTest(suiteID, &simapi.TestRequest{Name: "test"})
+	if err != nil {
+		t.Fatal("can't start test:", err)
+	}
+	params := map[string]string{"CLIENT": "client-1", "HIVE_FOO": "bar"}
+	clientID, _, err := sim.StartClient(suiteID, testID, params, nil)
+	if err != nil {
+		t.Fatal("can't start client:", err)
+	}
+
+	var resp simapi.StartNodeResponse
+	if err := post(restartURL(srv.URL, suiteID, testID, clientID), nil, &resp); err != nil {
+		t.Fatal("can't restart client:", err)
+	}
+	if resp.ID != clientID || resp.IP != "192.0.2.2" {
+		t.Errorf("wrong response after restart: %+v", resp)
+	}
+	if want := []string{"start", "stop", "start"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("wrong backend calls %v, want %v", calls, want)
+	}
+	first, restart := starts[0], starts[1]
+	if first.AppendLog || !restart.AppendLog {
+		t.Errorf("wrong AppendLog: first start %t, restart %t", first.AppendLog, restart.AppendLog)
+	}
+	if restart.LogFile != first.LogFile || !reflect.DeepEqual(restart.Env, first.Env) || restart.CheckLive != first.CheckLive {
+		t.Errorf("restart options differ from start options:\nstart:   %s\nrestart: %s", spew.Sdump(first), spew.Sdump(restart))
+	}
+
+	// Restarting an unknown client fails.
+	if err := post(restartURL(srv.URL, suiteID, testID, "unknown"), nil, &resp); err == nil {
+		t.Fatal("restarting unknown client did not fail")
+	}
+}
+
+func restartURL(base string, suite SuiteID, test TestID, node string) string {
+	return fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/restart", base, suite, test, node)
+}
+
 func newFakeAPI(hooks *fakes.BackendHooks) (*libhive.TestManager, *httptest.Server) {
 	defs := []*libhive.ClientDefinition{
 		{Name: "client-1", Image: "/ignored/in/api", Version: "client-1-version", Meta: libhive.ClientMetadata{Roles: []string{"eth1"}}},
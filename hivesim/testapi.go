@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/hive/internal/simapi"
@@ -194,12 +195,25 @@ func (c *Client) EngineAPI() *rpc.Client {
 	if c.enginerpc != nil {
 		return c.enginerpc
 	}
-	auth := rpc.WithHTTPAuth(jwtAuth(ENGINEAPI_JWT_SECRET))
-	url := fmt.Sprintf("http://%v:8551", c.IP)
-	c.enginerpc, _ = rpc.DialOptions(context.Background(), url, auth)
+	c.enginerpc = dialEngineAPI(c.IP.String(), ENGINEAPI_JWT_SECRET[:])
 	return c.enginerpc
 }
 
+// EngineAPIWithSecret returns an RPC client connected to the engine API server of an
+// execution-layer client, which authenticates with the given secret. The secret of
+// the client can be configured with WithEngineAPISecret. Unlike the client returned
+// by EngineAPI, the client is not shared and must be closed by the caller.
+func (c *Client) EngineAPIWithSecret(secret []byte) *rpc.Client {
+	return dialEngineAPI(c.IP.String(), secret)
+}
+
+// EngineAPIWebSocket returns an RPC client connected to the engine API server of an
+// execution-layer client over WebSocket. The token is sent with the upgrade request
+// and is signed with the given secret. The client must be closed by the caller.
+func (c *Client) EngineAPIWebSocket(ctx context.Context, secret []byte) (*rpc.Client, error) {
+	return dialEngineAPIWebSocket(ctx, c.IP.String(), secret)
+}
+
 // Exec runs a script in the client container.
 func (c *Client) Exec(command ...string) (*ExecInfo, error) {
 	return c.test.Sim.ClientExec(c.test.SuiteID, c.test.TestID, c.Container, command)
@@ -301,7 +315,7 @@ func (t *T) Logf(format string, values ...interface{}) {
 		format = format + "\n"
 	}
 	fmt.Printf(format, values...)
-	t.result.Details += fmt.Sprintf(format, values...)
+	t.appendDetails(fmt.Sprintf(format, values...))
 }
 
 // Log prints to standard output, which goes to the simulation log file.
@@ -309,7 +323,14 @@ func (t *T) Log(values ...interface{}) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	fmt.Println(values...)
-	t.result.Details += fmt.Sprintln(values...)
+	t.appendDetails(fmt.Sprintln(values...))
+}
+
+// appendDetails adds text to the test log. The caller must hold t.mu.
+func (t *T) appendDetails(text string) {
+	entry := TestLogTime{Offset: int64(len(t.result.Details)), Time: time.Now()}
+	t.result.LogTimes = append(t.result.LogTimes, entry)
+	t.result.Details += text
 }
 
 // Failed reports whether the test has already failed.
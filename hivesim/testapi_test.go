@@ -54,16 +54,18 @@ func TestSuiteReporting(t *testing.T) {
 					Name:        "passing test",
 					Description: "this test passes",
 					SummaryResult: libhive.TestResult{
-						Pass:    true,
-						Details: "message from the passing test\n",
+						Pass:     true,
+						Details:  "message from the passing test\n",
+						LogTimes: []libhive.TestLogTime{{Offset: 0}},
 					},
 				},
 				2: {
 					Name:        "failing test",
 					Description: "this test fails",
 					SummaryResult: libhive.TestResult{
-						Pass:    false,
-						Details: "message from the failing test\n",
+						Pass:     false,
+						Details:  "message from the failing test\n",
+						LogTimes: []libhive.TestLogTime{{Offset: 0}},
 					},
 				},
 			},
@@ -81,6 +83,9 @@ func removeTimestamps(result map[libhive.TestSuiteID]*libhive.TestSuite) {
 		for _, test := range suite.TestCases {
 			test.Start = time.Time{}
 			test.End = time.Time{}
+			for i := range test.SummaryResult.LogTimes {
+				test.SummaryResult.LogTimes[i].Time = time.Time{}
+			}
 		}
 	}
 }
@@ -0,0 +1,380 @@
+// Package vault provides deterministic test accounts for simulators.
+//
+// The accounts of a Vault are derived from a seed, so a simulator can put them into
+// the genesis allocation of its clients and use them in tests without shipping key
+// files. The vault also tracks the nonces of the accounts, which allows tests to send
+// transactions concurrently, and funds new accounts from its funding account.
+//
+//	v := vault.New(nil, chainID)
+//	genesis.Alloc = v.Alloc(10, balance)
+//	...
+//	acc, err := v.CreateAndFund(ctx, ethclient.NewClient(c.RPC()), amount)
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// DefaultGasPrice is the gas price of funding transactions.
+	DefaultGasPrice = big.NewInt(30 * params.GWei)
+
+	// ReplacementBump is the percentage by which Replace increases the fees of a
+	// transaction. Clients reject replacements with a lower bump.
+	ReplacementBump = int64(10)
+
+	// BlobReplacementBump is the percentage by which Replace increases the fees
+	// of a blob transaction. Blob pools require a larger bump than the pool of
+	// other transactions, e.g. go-ethereum requires the fees to be doubled.
+	BlobReplacementBump = int64(100)
+
+	// receiptPollInterval is the interval in which WaitMined checks for the receipt.
+	receiptPollInterval = 500 * time.Millisecond
+)
+
+// Backend is the client API used by the vault. It is implemented by ethclient.Client.
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Account is a test account of the vault.
+type Account struct {
+	Index   uint64
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+}
+
+// DeriveKey returns the private key of the account at the given index. The key is
+// the SHA256 hash of the seed followed by the big-endian index. With an empty seed,
+// the keys are those of the engine simulator test accounts.
+func DeriveKey(seed []byte, index uint64) *ecdsa.PrivateKey {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], index)
+	h := sha256.New()
+	h.Write(seed)
+	h.Write(enc[:])
+	key, err := crypto.ToECDSA(h.Sum(nil))
+	if err != nil {
+		// This happens with negligible probability, for hashes outside of the curve order.
+		panic(fmt.Errorf("invalid key for account %d: %v", index, err))
+	}
+	return key
+}
+
+// Vault holds the test accounts derived from a seed. Account 0 is the funding
+// account, which must be allocated in genesis to use Fund and CreateAndFund.
+// It is safe for concurrent use.
+type Vault struct {
+	seed    []byte
+	chainID *big.Int
+	signer  types.Signer
+
+	// GasPrice is the gas price of funding transactions. It must not be modified
+	// while the vault is in use.
+	GasPrice *big.Int
+
+	mu       sync.Mutex
+	accounts map[uint64]*Account
+	byAddr   map[common.Address]*Account
+	next     uint64                                           // index returned by the next CreateAccount
+	nonces   map[common.Address]uint64                        // next nonce of each sender
+	sent     map[common.Address]map[uint64]*types.Transaction // signed transactions by nonce
+}
+
+// New creates a vault for the given chain. The seed may be nil.
+func New(seed []byte, chainID *big.Int) *Vault {
+	return &Vault{
+		seed:     common.CopyBytes(seed),
+		chainID:  new(big.Int).Set(chainID),
+		signer:   types.LatestSignerForChainID(chainID),
+		GasPrice: new(big.Int).Set(DefaultGasPrice),
+		accounts: make(map[uint64]*Account),
+		byAddr:   make(map[common.Address]*Account),
+		next:     1,
+		nonces:   make(map[common.Address]uint64),
+		sent:     make(map[common.Address]map[uint64]*types.Transaction),
+	}
+}
+
+// ChainID returns the chain ID used for signing.
+func (v *Vault) ChainID() *big.Int {
+	return new(big.Int).Set(v.chainID)
+}
+
+// Signer returns the transaction signer of the vault.
+func (v *Vault) Signer() types.Signer {
+	return v.signer
+}
+
+// Account returns the account at the given index.
+func (v *Vault) Account(index uint64) *Account {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.account(index)
+}
+
+func (v *Vault) account(index uint64) *Account {
+	if acc, ok := v.accounts[index]; ok {
+		return acc
+	}
+	key := DeriveKey(v.seed, index)
+	acc := &Account{Index: index, Key: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	v.accounts[index] = acc
+	v.byAddr[acc.Address] = acc
+	return acc
+}
+
+// Funder returns the funding account.
+func (v *Vault) Funder() *Account {
+	return v.Account(0)
+}
+
+// Lookup returns the account with the given address, if it was derived by the vault.
+func (v *Vault) Lookup(addr common.Address) *Account {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.byAddr[addr]
+}
+
+// Alloc returns a genesis allocation of the first n accounts, including the funding
+// account, with the given balance. Accounts created by CreateAccount afterwards are
+// not part of the allocation.
+func (v *Vault) Alloc(n uint64, balance *big.Int) core.GenesisAlloc {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	alloc := make(core.GenesisAlloc, n)
+	for i := uint64(0); i < n; i++ {
+		alloc[v.account(i).Address] = core.GenesisAccount{Balance: new(big.Int).Set(balance)}
+	}
+	if v.next < n {
+		v.next = n
+	}
+	return alloc
+}
+
+// CreateAccount returns a new account, which isn't used by any other test.
+func (v *Vault) CreateAccount() *Account {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	acc := v.account(v.next)
+	v.next++
+	return acc
+}
+
+// NextNonce reserves the next nonce of an account and returns it.
+func (v *Vault) NextNonce(addr common.Address) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	nonce := v.nonces[addr]
+	v.nonces[addr] = nonce + 1
+	return nonce
+}
+
+// SetNonce sets the next nonce of an account, e.g. after a reorg.
+func (v *Vault) SetNonce(addr common.Address, nonce uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.nonces[addr] = nonce
+}
+
+// SyncNonce sets the next nonce of an account to its pending nonce in the client.
+func (v *Vault) SyncNonce(ctx context.Context, backend Backend, addr common.Address) error {
+	nonce, err := backend.PendingNonceAt(ctx, addr)
+	if err != nil {
+		return err
+	}
+	v.SetNonce(addr, nonce)
+	return nil
+}
+
+// releaseNonce returns a reserved nonce, if no later nonce was reserved.
+func (v *Vault) releaseNonce(addr common.Address, nonce uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.nonces[addr] == nonce+1 {
+		v.nonces[addr] = nonce
+	}
+}
+
+// SignTx signs a transaction with the key of the sender. The nonce of the
+// transaction should be reserved with NextNonce. The signed transaction is
+// remembered, and can be replaced with Replace.
+func (v *Vault) SignTx(sender common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	acc := v.Lookup(sender)
+	if acc == nil {
+		return nil, fmt.Errorf("sender account %v not in vault", sender)
+	}
+	signed, err := types.SignTx(tx, v.signer, acc.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.sent[sender] == nil {
+		v.sent[sender] = make(map[uint64]*types.Transaction)
+	}
+	v.sent[sender][signed.Nonce()] = signed
+	return signed, nil
+}
+
+// Sent returns the last transaction signed by the vault for a sender and nonce.
+func (v *Vault) Sent(sender common.Address, nonce uint64) *types.Transaction {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.sent[sender][nonce]
+}
+
+// Replace returns a replacement of a signed transaction, with the same nonce and
+// all fees increased by ReplacementBump percent, or BlobReplacementBump percent
+// for blob transactions.
+func (v *Vault) Replace(tx *types.Transaction) (*types.Transaction, error) {
+	sender, err := types.Sender(v.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	var data types.TxData
+	switch tx.Type() {
+	case types.LegacyTxType:
+		data = &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bumpFee(tx.GasPrice(), ReplacementBump),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}
+	case types.AccessListTxType:
+		data = &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   bumpFee(tx.GasPrice(), ReplacementBump),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		data = &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  bumpFee(tx.GasTipCap(), ReplacementBump),
+			GasFeeCap:  bumpFee(tx.GasFeeCap(), ReplacementBump),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	case types.BlobTxType:
+		data = &types.BlobTx{
+			ChainID:    uint256.MustFromBig(tx.ChainId()),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  uint256.MustFromBig(bumpFee(tx.GasTipCap(), BlobReplacementBump)),
+			GasFeeCap:  uint256.MustFromBig(bumpFee(tx.GasFeeCap(), BlobReplacementBump)),
+			Gas:        tx.Gas(),
+			To:         *tx.To(),
+			Value:      uint256.MustFromBig(tx.Value()),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			BlobFeeCap: uint256.MustFromBig(bumpFee(tx.BlobGasFeeCap(), BlobReplacementBump)),
+			BlobHashes: tx.BlobHashes(),
+			Sidecar:    tx.BlobTxSidecar(),
+		}
+	default:
+		return nil, fmt.Errorf("can't replace transaction of type %d", tx.Type())
+	}
+	return v.SignTx(sender, types.NewTx(data))
+}
+
+// bumpFee returns the fee increased by the given percentage, and at least by one.
+func bumpFee(fee *big.Int, percent int64) *big.Int {
+	bump := new(big.Int).Mul(fee, big.NewInt(percent))
+	bump.Div(bump, big.NewInt(100))
+	if bump.Sign() == 0 {
+		bump.SetInt64(1)
+	}
+	return bump.Add(bump, fee)
+}
+
+// Fund sends amount wei from the funding account to the given address. It returns
+// the funding transaction, which may not be mined yet.
+func (v *Vault) Fund(ctx context.Context, backend Backend, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	funder := v.Funder()
+	nonce := v.NextNonce(funder.Address)
+	tx, err := v.SignTx(funder.Address, types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: v.GasPrice,
+		Gas:      params.TxGas,
+		To:       &to,
+		Value:    amount,
+	}))
+	if err == nil {
+		err = backend.SendTransaction(ctx, tx)
+	}
+	if err != nil {
+		v.releaseNonce(funder.Address, nonce)
+		return nil, fmt.Errorf("can't send funding transaction: %v", err)
+	}
+	return tx, nil
+}
+
+// CreateAndFund creates a new account and funds it with amount wei. It returns
+// when the funding transaction is mined.
+func (v *Vault) CreateAndFund(ctx context.Context, backend Backend, amount *big.Int) (*Account, error) {
+	acc := v.CreateAccount()
+	tx, err := v.Fund(ctx, backend, acc.Address, amount)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := WaitMined(ctx, backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("funding transaction %v not mined: %v", tx.Hash(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("funding transaction %v failed", tx.Hash())
+	}
+	return acc, nil
+}
+
+// WaitMined waits for the receipt of a transaction.
+func WaitMined(ctx context.Context, backend Backend, tx *types.Transaction) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := backend.TransactionReceipt(ctx, tx.Hash())
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
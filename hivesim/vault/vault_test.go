@@ -0,0 +1,224 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestDeriveKey(t *testing.T) {
+	// Without a seed, the keys match the engine simulator test accounts.
+	want := sha256.Sum256([]byte{0, 0, 0, 0, 0, 0, 0, 5})
+	if got := crypto.FromECDSA(DeriveKey(nil, 5)); common.BytesToHash(got) != want {
+		t.Fatalf("wrong key %x, want %x", got, want)
+	}
+
+	a, b := New([]byte("a"), big.NewInt(1)), New([]byte("a"), big.NewInt(1))
+	if a.Account(3).Address != b.Account(3).Address {
+		t.Fatal("accounts of the same seed differ")
+	}
+	if a.Account(3).Address == New([]byte("b"), big.NewInt(1)).Account(3).Address {
+		t.Fatal("accounts of different seeds are equal")
+	}
+	if a.Lookup(a.Account(3).Address) != a.Account(3) {
+		t.Fatal("account not found by address")
+	}
+}
+
+func TestAlloc(t *testing.T) {
+	v := New(nil, big.NewInt(1))
+	alloc := v.Alloc(3, big.NewInt(100))
+	if len(alloc) != 3 {
+		t.Fatalf("wrong alloc size %d", len(alloc))
+	}
+	if alloc[v.Funder().Address].Balance.Int64() != 100 {
+		t.Fatal("funder not allocated")
+	}
+	// Created accounts must not be part of the allocation.
+	acc := v.CreateAccount()
+	if acc.Index != 3 {
+		t.Fatalf("created account has index %d, want 3", acc.Index)
+	}
+	if _, ok := alloc[acc.Address]; ok {
+		t.Fatal("created account is allocated")
+	}
+}
+
+func TestNonces(t *testing.T) {
+	var (
+		v    = New(nil, big.NewInt(1))
+		addr = v.Account(1).Address
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := v.NextNonce(addr)
+			mu.Lock()
+			seen[n] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 50 {
+		t.Fatalf("got %d distinct nonces, want 50", len(seen))
+	}
+	v.SetNonce(addr, 7)
+	if n := v.NextNonce(addr); n != 7 {
+		t.Fatalf("wrong nonce %d after SetNonce, want 7", n)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	v := New(nil, big.NewInt(1))
+	sender := v.Account(1).Address
+	to := common.Address{0xff}
+	tx, err := v.SignTx(sender, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     v.NextNonce(sender),
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       params.TxGas,
+		To:        &to,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := v.Replace(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement.Nonce() != tx.Nonce() {
+		t.Fatalf("replacement has nonce %d, want %d", replacement.Nonce(), tx.Nonce())
+	}
+	if replacement.GasTipCap().Int64() != 110 || replacement.GasFeeCap().Int64() != 1100 {
+		t.Fatalf("wrong replacement fees %v/%v", replacement.GasTipCap(), replacement.GasFeeCap())
+	}
+	if from, _ := types.Sender(v.Signer(), replacement); from != sender {
+		t.Fatalf("replacement signed by %v, want %v", from, sender)
+	}
+	if v.Sent(sender, tx.Nonce()) != replacement {
+		t.Fatal("replacement not tracked")
+	}
+	if _, err := v.SignTx(common.Address{1}, tx); err == nil {
+		t.Fatal("signed with unknown account")
+	}
+}
+
+func TestReplaceBlobTx(t *testing.T) {
+	v := New(nil, big.NewInt(1))
+	sender := v.Account(1).Address
+	tx, err := v.SignTx(sender, types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(1),
+		Nonce:      v.NextNonce(sender),
+		GasTipCap:  uint256.NewInt(100),
+		GasFeeCap:  uint256.NewInt(1000),
+		Gas:        params.TxGas,
+		To:         common.Address{0xff},
+		BlobFeeCap: uint256.NewInt(10),
+		BlobHashes: []common.Hash{{0x01}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := v.Replace(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blob transaction fees are bumped by BlobReplacementBump, not ReplacementBump.
+	if replacement.GasTipCap().Int64() != 200 || replacement.GasFeeCap().Int64() != 2000 || replacement.BlobGasFeeCap().Int64() != 20 {
+		t.Fatalf("wrong replacement fees %v/%v/%v", replacement.GasTipCap(), replacement.GasFeeCap(), replacement.BlobGasFeeCap())
+	}
+	if len(replacement.BlobHashes()) != 1 || replacement.BlobHashes()[0] != tx.BlobHashes()[0] {
+		t.Fatalf("wrong replacement blob hashes %v", replacement.BlobHashes())
+	}
+	if v.Sent(sender, tx.Nonce()) != replacement {
+		t.Fatal("replacement not tracked")
+	}
+}
+
+type fakeBackend struct {
+	mu      sync.Mutex
+	sendErr error
+	sent    []*types.Transaction
+}
+
+func (b *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 4, nil
+}
+
+func (b *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *fakeBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, tx := range b.sent {
+		if tx.Hash() == hash {
+			return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: hash}, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func TestFund(t *testing.T) {
+	var (
+		v       = New(nil, big.NewInt(1))
+		backend = new(fakeBackend)
+		ctx     = context.Background()
+		funder  = v.Funder().Address
+	)
+	if err := v.SyncNonce(ctx, backend, funder); err != nil {
+		t.Fatal(err)
+	}
+	acc, err := v.CreateAndFund(ctx, backend, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(backend.sent))
+	}
+	tx := backend.sent[0]
+	if *tx.To() != acc.Address || tx.Value().Int64() != 5 || tx.Nonce() != 4 {
+		t.Fatalf("wrong funding transaction: to %v, value %v, nonce %d", tx.To(), tx.Value(), tx.Nonce())
+	}
+
+	// A failed send must not leave a nonce gap.
+	backend.sendErr = errors.New("rejected")
+	if _, err := v.Fund(ctx, backend, acc.Address, big.NewInt(1)); err == nil {
+		t.Fatal("no error for rejected transaction")
+	}
+	if n := v.NextNonce(funder); n != 5 {
+		t.Fatalf("wrong nonce %d after failed send, want 5", n)
+	}
+}
+
+func TestWaitMinedTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	tx := types.NewTx(&types.LegacyTx{GasPrice: new(big.Int), Value: new(big.Int)})
+	if _, err := WaitMined(ctx, new(fakeBackend), tx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wrong error %v", err)
+	}
+}
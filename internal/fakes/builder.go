@@ -10,8 +10,9 @@ import (
 // BuilderHooks can be used to override the behavior of the fake builder.
 type BuilderHooks struct {
 	BuildClientImage    func(context.Context, libhive.ClientDesignator) (string, error)
-	BuildSimulatorImage func(context.Context, string) (string, error)
+	BuildSimulatorImage func(context.Context, string, map[string]string) (string, error)
 	ReadFile            func(ctx context.Context, image string, file string) ([]byte, error)
+	BuildInfo           func(ctx context.Context, image string) (*libhive.BuildInfo, error)
 }
 
 // fakeBuilder implements Backend without docker.
@@ -35,9 +36,9 @@ func (b *fakeBuilder) BuildClientImage(ctx context.Context, client libhive.Clien
 	return "fakebuild/client/" + client.Client + ":latest", nil
 }
 
-func (b *fakeBuilder) BuildSimulatorImage(ctx context.Context, sim string) (string, error) {
+func (b *fakeBuilder) BuildSimulatorImage(ctx context.Context, sim string, buildArgs map[string]string) (string, error) {
 	if b.hooks.BuildSimulatorImage != nil {
-		return b.hooks.BuildSimulatorImage(ctx, sim)
+		return b.hooks.BuildSimulatorImage(ctx, sim, buildArgs)
 	}
 	return "fakebuild/simulator/" + sim + ":latest", nil
 }
@@ -52,3 +53,10 @@ func (b *fakeBuilder) ReadFile(ctx context.Context, image, file string) ([]byte,
 	}
 	return []byte{}, nil
 }
+
+func (b *fakeBuilder) BuildInfo(ctx context.Context, image string) (*libhive.BuildInfo, error) {
+	if b.hooks.BuildInfo != nil {
+		return b.hooks.BuildInfo(ctx, image)
+	}
+	return &libhive.BuildInfo{Image: image, ImageID: "sha256:" + image}, nil
+}
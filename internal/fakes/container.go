@@ -17,6 +17,7 @@ type BackendHooks struct {
 	CreateContainer  func(image string, opt libhive.ContainerOptions) (string, error)
 	StartContainer   func(image, containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error)
 	DeleteContainer  func(containerID string) error
+	StopContainer    func(containerID string) error
 	PauseContainer   func(containerID string) error
 	UnpauseContainer func(containerID string) error
 	RunProgram       func(containerID string, cmd []string) (*libhive.ExecInfo, error)
@@ -54,6 +55,10 @@ func (s apiServer) Addr() net.Addr {
 	return s.addr
 }
 
+func (s apiServer) URL() string {
+	return "http://" + s.addr.String()
+}
+
 // NewBackend creates a new fake container backend.
 func NewContainerBackend(hooks *BackendHooks) libhive.ContainerBackend {
 	b := &fakeBackend{cimg: make(map[string]string)}
@@ -147,6 +152,13 @@ func (b *fakeBackend) DeleteContainer(containerID string) error {
 	return err
 }
 
+func (b *fakeBackend) StopContainer(containerID string) error {
+	if b.hooks.StopContainer != nil {
+		return b.hooks.StopContainer(containerID)
+	}
+	return nil
+}
+
 func (b *fakeBackend) PauseContainer(containerID string) error {
 	if b.hooks.PauseContainer != nil {
 		return b.hooks.PauseContainer(containerID)
@@ -8,10 +8,15 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
+	"golang.org/x/exp/slices"
 	"gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/ethereum/hive/internal/libhive"
@@ -23,6 +28,9 @@ type Builder struct {
 	config        *Config
 	logger        log15.Logger
 	authenticator Authenticator
+
+	mu     sync.Mutex
+	builds map[string]*libhive.BuildInfo // build records by image tag
 }
 
 func NewBuilder(client *docker.Client, cfg *Config, auth Authenticator) *Builder {
@@ -31,6 +39,7 @@ func NewBuilder(client *docker.Client, cfg *Config, auth Authenticator) *Builder
 		config:        cfg,
 		logger:        cfg.Logger,
 		authenticator: auth,
+		builds:        make(map[string]*libhive.BuildInfo),
 	}
 	if b.logger == nil {
 		b.logger = log15.Root()
@@ -58,8 +67,9 @@ func (b *Builder) BuildClientImage(ctx context.Context, client libhive.ClientDes
 	return tag, err
 }
 
-// BuildSimulatorImage builds a docker image of a simulator.
-func (b *Builder) BuildSimulatorImage(ctx context.Context, name string) (string, error) {
+// BuildSimulatorImage builds a docker image of a simulator. The build arguments
+// are passed to all simulators.
+func (b *Builder) BuildSimulatorImage(ctx context.Context, name string, buildArgs map[string]string) (string, error) {
 	dir := b.config.Inventory.SimulatorDirectory(name)
 	buildContextPath := dir
 	buildDockerfile := "Dockerfile"
@@ -76,7 +86,12 @@ func (b *Builder) BuildSimulatorImage(ctx context.Context, name string) (string,
 		}
 	}
 	tag := fmt.Sprintf("hive/simulators/%s:latest", name)
-	err := b.buildImage(ctx, buildContextPath, buildDockerfile, tag, nil)
+	var args []docker.BuildArg
+	for key, value := range buildArgs {
+		args = append(args, docker.BuildArg{Name: key, Value: value})
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].Name < args[j].Name })
+	err := b.buildImage(ctx, buildContextPath, buildDockerfile, tag, args)
 	return tag, err
 }
 
@@ -240,9 +255,143 @@ func (b *Builder) buildImage(ctx context.Context, contextDir, dockerFile, imageT
 	}
 
 	logger.Info("building image", logctx...)
+	start := time.Now()
 	if err := b.client.BuildImage(opts); err != nil {
 		logger.Error("image build failed", "err", err)
 		return err
 	}
+	b.recordBuild(imageTag, context, filepath.Join(context, dockerFile), buildArgs, start)
 	return nil
 }
+
+// recordBuild stores the build parameters of an image for BuildInfo.
+func (b *Builder) recordBuild(imageTag, contextDir, dockerFile string, buildArgs []docker.BuildArg, start time.Time) {
+	info := &libhive.BuildInfo{
+		Image:      imageTag,
+		Dockerfile: filepath.Base(dockerFile),
+		HiveCommit: gitCommit(contextDir),
+		BuildStart: start,
+		BuildTime:  time.Since(start),
+	}
+	args := make(map[string]string, len(buildArgs))
+	for _, arg := range buildArgs {
+		args[arg.Name] = arg.Value
+	}
+	if len(args) > 0 {
+		info.BuildArgs = args
+	}
+	if content, err := os.ReadFile(dockerFile); err == nil {
+		for _, name := range dockerfileBaseImages(string(content), args) {
+			info.BaseImages = append(info.BaseImages, libhive.BaseImageInfo{Name: name})
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.builds[imageTag] = info
+}
+
+// BuildInfo returns provenance information about an image. The build parameters are
+// available for images built by this builder, and the digests are resolved from the
+// local docker image store.
+func (b *Builder) BuildInfo(ctx context.Context, image string) (*libhive.BuildInfo, error) {
+	info := &libhive.BuildInfo{Image: image}
+	b.mu.Lock()
+	if rec := b.builds[image]; rec != nil {
+		*info = *rec
+		info.BaseImages = append([]libhive.BaseImageInfo(nil), rec.BaseImages...)
+	}
+	b.mu.Unlock()
+
+	img, err := b.client.InspectImage(image)
+	if err != nil {
+		return nil, err
+	}
+	info.ImageID = img.ID
+	info.RepoDigests = img.RepoDigests
+	if img.Config != nil {
+		if rev := img.Config.Labels["org.opencontainers.image.revision"]; rev != "" {
+			info.SourceCommit = rev
+		}
+	}
+	for i, base := range info.BaseImages {
+		baseImg, err := b.client.InspectImage(base.Name)
+		if err != nil {
+			b.logger.Debug("can't inspect base image", "image", base.Name, "err", err)
+			continue
+		}
+		info.BaseImages[i].ImageID = baseImg.ID
+		info.BaseImages[i].RepoDigests = baseImg.RepoDigests
+	}
+	return info, nil
+}
+
+// gitCommit returns the git commit of a build context directory. The suffix
+// "-dirty" is added when the directory contains uncommitted changes. It returns
+// the empty string when the directory isn't in a git repository.
+func gitCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(out))
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", ".").Output()
+	if err == nil && len(bytes.TrimSpace(status)) > 0 {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+// dockerfileBaseImages returns the external images referenced by FROM instructions in a
+// Dockerfile. Build arguments are substituted, and references to earlier build stages
+// are skipped.
+func dockerfileBaseImages(dockerfile string, buildArgs map[string]string) []string {
+	var (
+		args     = make(map[string]string)
+		stages   = make(map[string]bool)
+		images   []string
+		seenFrom bool
+	)
+	for key, value := range buildArgs {
+		args[key] = value
+	}
+	expand := func(s string) string {
+		return os.Expand(s, func(key string) string { return args[key] })
+	}
+	for _, line := range strings.Split(dockerfile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// Only ARGs before the first FROM can be used in FROM, and values
+			// given as build arguments take precedence over defaults.
+			if seenFrom {
+				continue
+			}
+			if key, value, ok := strings.Cut(fields[1], "="); ok {
+				if _, set := args[key]; !set {
+					args[key] = strings.Trim(value, `"'`)
+				}
+			}
+		case "FROM":
+			seenFrom = true
+			fields = fields[1:]
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:] // skip flags like --platform
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			name := expand(fields[0])
+			if name != "scratch" && !stages[strings.ToLower(name)] && !slices.Contains(images, name) {
+				images = append(images, name)
+			}
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+				stages[strings.ToLower(fields[2])] = true
+			}
+		}
+	}
+	return images
+}
@@ -0,0 +1,66 @@
+package libdocker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDockerfileBaseImages(t *testing.T) {
+	dockerfile := `
+ARG baseimage=ethereum/client-go
+ARG tag=latest
+
+FROM golang:1.20-alpine as builder
+RUN go build ./cmd/geth
+
+FROM --platform=linux/amd64 $baseimage:${tag} AS final
+COPY --from=builder /geth /geth
+
+FROM builder
+ARG tag=ignored
+FROM scratch
+`
+	images := dockerfileBaseImages(dockerfile, map[string]string{"tag": "v1.13.5"})
+	want := []string{"golang:1.20-alpine", "ethereum/client-go:v1.13.5"}
+	if !reflect.DeepEqual(images, want) {
+		t.Fatalf("wrong base images %q, want %q", images, want)
+	}
+}
+
+func TestGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if commit := gitCommit(dir); commit != "" {
+		t.Fatalf("got commit %q outside of repository", commit)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "Dockerfile")
+	git("commit", "-q", "-m", "initial")
+	head := git("rev-parse", "HEAD")
+	if commit := gitCommit(dir); commit != head {
+		t.Fatalf("wrong commit %q, want %q", commit, head)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if commit := gitCommit(dir); commit != head+"-dirty" {
+		t.Fatalf("wrong commit %q for modified directory", commit)
+	}
+}
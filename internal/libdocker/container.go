@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -20,12 +21,18 @@ import (
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
+// stopGracePeriod is the time in seconds a container is given to exit
+// before it is killed by StopContainer.
+const stopGracePeriod = 10
+
 type ContainerBackend struct {
 	client *docker.Client
 	config *Config
 	logger log15.Logger
 
-	proxy *hiveproxy.Proxy
+	proxy          *hiveproxy.Proxy
+	proxyMu        sync.Mutex
+	proxyContainer *proxyContainer // shared by all simulation API servers
 }
 
 func NewContainerBackend(c *docker.Client, cfg *Config) *ContainerBackend {
@@ -202,6 +209,17 @@ func (b *ContainerBackend) DeleteContainer(containerID string) error {
 	return err
 }
 
+// StopContainer stops the given container without removing it. The container
+// receives SIGTERM and is killed if it has not exited after the grace period.
+func (b *ContainerBackend) StopContainer(containerID string) error {
+	b.logger.Debug("stopping container", "container", containerID[:8])
+	err := b.client.StopContainer(containerID, stopGracePeriod)
+	if err != nil {
+		b.logger.Error("can't stop container", "container", containerID[:8], "err", err)
+	}
+	return err
+}
+
 // PauseContainer pauses the given container.
 func (b *ContainerBackend) PauseContainer(containerID string) error {
 	b.logger.Debug("pausing container", "container", containerID[:8])
@@ -379,21 +397,43 @@ func (b *ContainerBackend) runContainer(ctx context.Context, logger log15.Logger
 		if err := os.MkdirAll(filepath.Dir(opts.LogFile), 0755); err != nil {
 			return nil, err
 		}
-		log, err := os.OpenFile(opts.LogFile, os.O_WRONLY|os.O_CREATE|os.O_SYNC|os.O_TRUNC, 0644)
+		flags := os.O_WRONLY | os.O_CREATE | os.O_SYNC | os.O_TRUNC
+		if opts.AppendLog {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_SYNC | os.O_APPEND
+		}
+		log, err := os.OpenFile(opts.LogFile, flags, 0644)
 		if err != nil {
 			return nil, err
 		}
-		closer.addFile(log)
 		outStream = log
 
+		// In JSONL mode, stdout and stderr are written as separate timestamped streams.
+		// Note the stream writers must be closed before the file.
+		var jsonErr io.Writer
+		if opts.LogFormat == libhive.LogFormatJSONL {
+			var mu sync.Mutex
+			stdout := newJSONLogWriter(log, &mu, "stdout")
+			stderr := newJSONLogWriter(log, &mu, "stderr")
+			closer.addFile(stdout)
+			closer.addFile(stderr)
+			outStream, jsonErr = stdout, stderr
+		}
+		closer.addFile(log)
+
 		// If console logging was requested, tee the output and tag it with the container id.
 		if b.config.ContainerOutput != nil {
 			prefixer := newLinePrefixWriter(b.config.ContainerOutput, fmt.Sprintf("[%s] ", id[:8]))
 			closer.addFile(prefixer)
-			outStream = io.MultiWriter(log, prefixer)
+			outStream = io.MultiWriter(outStream, prefixer)
+			if jsonErr != nil {
+				jsonErr = io.MultiWriter(jsonErr, prefixer)
+			}
 		}
-		// In LogFile mode, stderr is redirected to stdout.
+		// In LogFile mode, stderr is redirected to stdout unless it is logged separately.
 		errStream = outStream
+		if jsonErr != nil {
+			errStream = jsonErr
+		}
 	}
 
 	// Configure the streams and attach.
@@ -512,3 +552,59 @@ func (w *linePrefixWriter) Close() error {
 	w.buf = nil
 	return err
 }
+
+// jsonLogWriter wraps a writer, writing each line as a timestamped JSON object.
+// Multiple jsonLogWriters can share the underlying writer using a common mutex.
+type jsonLogWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	stream string
+	buf    []byte // holds current incomplete line
+	now    func() time.Time
+}
+
+func newJSONLogWriter(w io.Writer, mu *sync.Mutex, stream string) *jsonLogWriter {
+	return &jsonLogWriter{w: w, mu: mu, stream: stream, now: time.Now}
+}
+
+func (w *jsonLogWriter) Write(b []byte) (int, error) {
+	var (
+		n   = len(b)
+		err error
+	)
+	for len(b) > 0 {
+		nl := bytes.IndexByte(b, '\n')
+		if nl < 0 {
+			w.buf = append(w.buf, b...)
+			break
+		}
+		w.buf = append(w.buf, b[:nl]...)
+		if werr := w.flush(); werr != nil {
+			err = werr
+		}
+		b = b[nl+1:]
+	}
+	return n, err
+}
+
+func (w *jsonLogWriter) flush() error {
+	line := libhive.ClientLogLine{Time: w.now().UTC(), Stream: w.stream, Text: string(w.buf)}
+	w.buf = w.buf[:0]
+	enc, err := json.Marshal(&line)
+	if err != nil {
+		return err
+	}
+	enc = append(enc, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(enc)
+	return err
+}
+
+// Close flushes the last line.
+func (w *jsonLogWriter) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	return w.flush()
+}
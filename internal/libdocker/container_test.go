@@ -0,0 +1,65 @@
+package libdocker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/hive/internal/libhive"
+)
+
+func TestJSONLogWriter(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		mu    sync.Mutex
+		clock = time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	)
+	stdout := newJSONLogWriter(&buf, &mu, "stdout")
+	stderr := newJSONLogWriter(&buf, &mu, "stderr")
+	for _, w := range []*jsonLogWriter{stdout, stderr} {
+		w.now = func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		}
+	}
+
+	// Lines are split across writes, and writes contain multiple lines.
+	stdout.Write([]byte("first "))
+	stdout.Write([]byte("line\nsecond line\nthi"))
+	stderr.Write([]byte("error\n"))
+	stdout.Write([]byte("rd line\n\npartial"))
+	if err := stdout.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stderr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []libhive.ClientLogLine
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		var line libhive.ClientLogLine
+		if err := json.Unmarshal(s.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", s.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	ts := func(sec int) time.Time {
+		return time.Date(2024, 1, 1, 11, 0, sec, 0, time.UTC)
+	}
+	want := []libhive.ClientLogLine{
+		{Time: ts(1), Stream: "stdout", Text: "first line"},
+		{Time: ts(2), Stream: "stdout", Text: "second line"},
+		{Time: ts(3), Stream: "stderr", Text: "error"},
+		{Time: ts(4), Stream: "stdout", Text: "third line"},
+		{Time: ts(5), Stream: "stdout", Text: ""},
+		{Time: ts(6), Stream: "stdout", Text: "partial"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("wrong log lines:\n got %+v\nwant %+v", lines, want)
+	}
+}
@@ -19,8 +19,27 @@ func (cb *ContainerBackend) Build(ctx context.Context, b libhive.Builder) error
 	return b.BuildImage(ctx, hiveproxyTag, hiveproxy.Source)
 }
 
-// ServeAPI starts the API server.
+// ServeAPI starts the API server. All simulations share a single proxy container, which
+// is launched by the first call and stopped when the last API server is closed.
 func (cb *ContainerBackend) ServeAPI(ctx context.Context, h http.Handler) (libhive.APIServer, error) {
+	cb.proxyMu.Lock()
+	defer cb.proxyMu.Unlock()
+
+	if cb.proxyContainer == nil {
+		c, err := cb.startProxy(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cb.proxyContainer = c
+	}
+	c := cb.proxyContainer
+	srv := &proxyAPIServer{cb: cb, container: c, simID: c.mux.Add(h)}
+	log15.Info("simulation API registered in hiveproxy", "container", c.containerID[:12], "url", srv.URL())
+	return srv, nil
+}
+
+// startProxy launches the hiveproxy container.
+func (cb *ContainerBackend) startProxy(ctx context.Context) (*proxyContainer, error) {
 	inR, inW := io.Pipe()
 	outR, outW := io.Pipe()
 
@@ -33,11 +52,12 @@ func (cb *ContainerBackend) ServeAPI(ctx context.Context, h http.Handler) (libhi
 	// Launch the proxy server before starting the container.
 	var (
 		proxy     *hiveproxy.Proxy
+		mux       = hiveproxy.NewSimMux()
 		proxyErrC = make(chan error, 1)
 	)
 	go func() {
 		var err error
-		proxy, err = hiveproxy.RunBackend(outR, inW, h)
+		proxy, err = hiveproxy.RunBackend(outR, inW, mux)
 		if err != nil {
 			log15.Error("proxy backend startup failed", "err", err)
 		}
@@ -52,17 +72,11 @@ func (cb *ContainerBackend) ServeAPI(ctx context.Context, h http.Handler) (libhi
 	}
 
 	// Proxy server should come up.
-	select {
-	case err := <-proxyErrC:
-		if err != nil {
-			cb.DeleteContainer(id)
-			return nil, err
-		}
+	if err := <-proxyErrC; err != nil {
+		cb.DeleteContainer(id)
+		return nil, err
 	}
 
-	// Register proxy in ContainerBackend, so it can be used for CheckLive.
-	cb.proxy = proxy
-
 	srv := &proxyContainer{
 		cb:              cb,
 		containerID:     id,
@@ -71,6 +85,7 @@ func (cb *ContainerBackend) ServeAPI(ctx context.Context, h http.Handler) (libhi
 		containerStdin:  inR,
 		containerStdout: outW,
 		proxy:           proxy,
+		mux:             mux,
 	}
 
 	// Register proxy in ContainerBackend, so it can be used for CheckLive.
@@ -79,6 +94,41 @@ func (cb *ContainerBackend) ServeAPI(ctx context.Context, h http.Handler) (libhi
 	return srv, nil
 }
 
+// proxyAPIServer is the API server of a single simulation.
+type proxyAPIServer struct {
+	cb        *ContainerBackend
+	container *proxyContainer
+	simID     string
+	closing   sync.Once
+	closeErr  error
+}
+
+// Addr returns the listening address of the proxy server.
+func (s *proxyAPIServer) Addr() net.Addr {
+	return s.container.Addr()
+}
+
+// URL returns the base URL of the simulation API.
+func (s *proxyAPIServer) URL() string {
+	return "http://" + s.Addr().String() + hiveproxy.SimPath(s.simID)
+}
+
+// Close unregisters the simulation from the proxy. The proxy container
+// is stopped when no simulations remain.
+func (s *proxyAPIServer) Close() error {
+	s.closing.Do(func() {
+		s.cb.proxyMu.Lock()
+		defer s.cb.proxyMu.Unlock()
+
+		s.container.mux.Remove(s.simID)
+		if s.container.mux.Len() == 0 && s.cb.proxyContainer == s.container {
+			s.cb.proxyContainer = nil
+			s.closeErr = s.container.Close()
+		}
+	})
+	return s.closeErr
+}
+
 type proxyContainer struct {
 	cb *ContainerBackend
 
@@ -88,6 +138,7 @@ type proxyContainer struct {
 	containerStdout *io.PipeWriter
 	containerWait   func()
 	proxy           *hiveproxy.Proxy
+	mux             *hiveproxy.SimMux
 
 	stopping sync.Once
 	stopErr  error
@@ -37,6 +37,7 @@ func newSimulationAPI(b ContainerBackend, env SimEnv, tm *TestManager) http.Hand
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}", api.getNodeStatus).Methods("GET")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node", api.startClient).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}", api.stopClient).Methods("DELETE")
+	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/restart", api.restartClient).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/pause", api.pauseClient).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/pause", api.unpauseClient).Methods("DELETE")
 	router.HandleFunc("/testsuite/{suite}/test", api.startTest).Methods("POST")
@@ -231,11 +232,7 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set up the timeout.
-	timeout := api.env.ClientStartTimeout
-	if timeout == 0 {
-		timeout = defaultStartTimeout
-	}
-	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	ctx, cancel := context.WithTimeout(r.Context(), api.startTimeout())
 	defer cancel()
 
 	// Create the client container.
@@ -252,6 +249,7 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 	// so it can only be set after creating the container.
 	logPath, logFilePath := api.clientLogFilePaths(clientDef.Name, containerID)
 	options.LogFile = logFilePath
+	options.LogFormat = api.env.ClientLogFormat
 
 	// Connect to the networks if requested, so it is started already joined to each one.
 	for _, network := range networks {
@@ -283,7 +281,9 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 			Name:           clientDef.Name,
 			InstantiatedAt: time.Now(),
 			LogFile:        logPath,
+			LogFormat:      options.LogFormat,
 			wait:           info.Wait,
+			options:        options,
 		}
 
 		// Add client version to the test suite.
@@ -309,6 +309,14 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 	serveJSON(w, &simapi.StartNodeResponse{ID: info.ID, IP: info.IP})
 }
 
+// startTimeout returns the time allowed for a client container to come online.
+func (api *simAPI) startTimeout() time.Duration {
+	if api.env.ClientStartTimeout == 0 {
+		return defaultStartTimeout
+	}
+	return api.env.ClientStartTimeout
+}
+
 // clientLogFilePaths determines the log file path of a client container.
 // Note that jsonPath gets written to the result JSON and always uses '/' as the separator.
 // The filePath is passed to the docker backend and uses the platform separator.
@@ -362,6 +370,30 @@ func (api *simAPI) stopClient(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// restartClient stops a client container and starts it again.
+func (api *simAPI) restartClient(w http.ResponseWriter, r *http.Request) {
+	_, testID, err := api.requestSuiteAndTest(r)
+	if err != nil {
+		serveError(w, err, http.StatusBadRequest)
+		return
+	}
+	node := mux.Vars(r)["node"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), api.startTimeout())
+	defer cancel()
+	info, err := api.tm.RestartNode(ctx, testID, node)
+	switch {
+	case err == ErrNoSuchNode:
+		serveError(w, err, http.StatusNotFound)
+	case err != nil:
+		log15.Error("API: could not restart client", "node", node, "error", err)
+		serveError(w, err, http.StatusInternalServerError)
+	default:
+		log15.Info("API: client restarted", "test", testID, "container", node)
+		serveJSON(w, &simapi.StartNodeResponse{ID: info.ID, IP: info.IP})
+	}
+}
+
 // pauseClient pauses a client container.
 func (api *simAPI) pauseClient(w http.ResponseWriter, r *http.Request) {
 	_, testID, err := api.requestSuiteAndTest(r)
@@ -28,8 +28,9 @@ type TestSuite struct {
 	ClientVersions map[string]string    `json:"clientVersions"`
 	TestCases      map[TestID]*TestCase `json:"testCases"`
 
-	SimulatorLog   string `json:"simLog"`         // path to simulator log-file simulator. (may be shared with multiple suites)
-	TestDetailsLog string `json:"testDetailsLog"` // the test details output file
+	SimulatorLog   string `json:"simLog"`              // path to simulator log-file simulator. (may be shared with multiple suites)
+	TestDetailsLog string `json:"testDetailsLog"`      // the test details output file
+	BuildInfo      string `json:"buildInfo,omitempty"` // path to build info file of the hive run
 
 	testDetailsFile *os.File
 	testLogOffset   int64
@@ -54,6 +55,9 @@ type TestResult struct {
 	// suite's TestDetailsLog file ("log").
 	Details    string          `json:"details,omitempty"`
 	LogOffsets *TestLogOffsets `json:"log,omitempty"`
+
+	// LogTimes records when each part of the test log was written.
+	LogTimes []TestLogTime `json:"logTimes,omitempty"`
 }
 
 type TestLogOffsets struct {
@@ -61,6 +65,13 @@ type TestLogOffsets struct {
 	End   int64 `json:"end"`
 }
 
+// TestLogTime is the time of a test log entry. The offset is relative to the
+// beginning of the test log.
+type TestLogTime struct {
+	Offset int64     `json:"offset"`
+	Time   time.Time `json:"time"`
+}
+
 // ClientInfo describes a client that participated in a test case.
 type ClientInfo struct {
 	ID             string    `json:"id"`
@@ -68,8 +79,25 @@ type ClientInfo struct {
 	Name           string    `json:"name"`
 	InstantiatedAt time.Time `json:"instantiatedAt"`
 	LogFile        string    `json:"logFile"` //Absolute path to the logfile.
+	LogFormat      string    `json:"logFormat,omitempty"`
+
+	wait    func()
+	options ContainerOptions // launch options, reused when restarting
+}
+
+// Client log formats.
+const (
+	// LogFormatText is the raw container output, with stdout and stderr merged.
+	LogFormatText = "text"
+	// LogFormatJSONL stores each line of output as a ClientLogLine.
+	LogFormatJSONL = "jsonl"
+)
 
-	wait func()
+// ClientLogLine is a line of client output in LogFormatJSONL.
+type ClientLogLine struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"` // "stdout" or "stderr"
+	Text   string    `json:"text"`
 }
 
 // HiveInstance contains information about hive itself.
@@ -79,6 +107,35 @@ type HiveInstance struct {
 	BuildDate    string `json:"buildDate"`
 }
 
+// BuildInfo records the provenance of a docker image built by hive.
+type BuildInfo struct {
+	Name         string            `json:"name"`
+	Image        string            `json:"image"`
+	ImageID      string            `json:"imageID,omitempty"`
+	RepoDigests  []string          `json:"repoDigests,omitempty"`
+	BaseImages   []BaseImageInfo   `json:"baseImages,omitempty"`
+	Dockerfile   string            `json:"dockerfile,omitempty"`
+	BuildArgs    map[string]string `json:"buildArgs,omitempty"`
+	SourceCommit string            `json:"sourceCommit,omitempty"` // org.opencontainers.image.revision label
+	HiveCommit   string            `json:"hiveCommit,omitempty"`   // git commit of the hive checkout containing the image definition
+	Version      string            `json:"version,omitempty"`      // content of /version.txt
+	BuildStart   time.Time         `json:"buildStart"`
+	BuildTime    time.Duration     `json:"buildTime"` // in nanoseconds
+}
+
+// BaseImageInfo describes an image used in a FROM instruction.
+type BaseImageInfo struct {
+	Name        string   `json:"name"`
+	ImageID     string   `json:"imageID,omitempty"`
+	RepoDigests []string `json:"repoDigests,omitempty"`
+}
+
+// RunBuildInfo is the content of the build info file written for each hive run.
+type RunBuildInfo struct {
+	Clients    []*BuildInfo `json:"clients"`
+	Simulators []*BuildInfo `json:"simulators"`
+}
+
 // ClientDefinition is served by the /clients API endpoint to list the available clients
 type ClientDefinition struct {
 	Name    string         `json:"name"`
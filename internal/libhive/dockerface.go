@@ -23,6 +23,7 @@ type ContainerBackend interface {
 	CreateContainer(ctx context.Context, image string, opt ContainerOptions) (string, error)
 	StartContainer(ctx context.Context, containerID string, opt ContainerOptions) (*ContainerInfo, error)
 	DeleteContainer(containerID string) error
+	StopContainer(containerID string) error
 	PauseContainer(containerID string) error
 	UnpauseContainer(containerID string) error
 
@@ -41,6 +42,7 @@ type ContainerBackend interface {
 // APIServer is a handle for the HTTP API server.
 type APIServer interface {
 	Addr() net.Addr // returns the listening address of the HTTP server
+	URL() string    // returns the base URL of the simulation API
 	Close() error   // stops the server
 }
 
@@ -61,6 +63,13 @@ type ContainerOptions struct {
 	LogFile string
 	Output  io.WriteCloser
 
+	// LogFormat selects the format of LogFile. See LogFormatText and LogFormatJSONL.
+	LogFormat string
+
+	// AppendLog requests appending to LogFile instead of truncating it. This is
+	// used when a stopped container is started again.
+	AppendLog bool
+
 	// Input: if set, container stdin draws from the given reader.
 	Input io.ReadCloser
 }
@@ -81,11 +90,14 @@ type ContainerInfo struct {
 // Builder can build docker images of clients and simulators.
 type Builder interface {
 	BuildClientImage(ctx context.Context, client ClientDesignator) (string, error)
-	BuildSimulatorImage(ctx context.Context, name string) (string, error)
+	BuildSimulatorImage(ctx context.Context, name string, buildArgs map[string]string) (string, error)
 	BuildImage(ctx context.Context, name string, fsys fs.FS) error
 
 	// ReadFile returns the content of a file in the given image.
 	ReadFile(ctx context.Context, image, path string) ([]byte, error)
+
+	// BuildInfo returns provenance information about an image.
+	BuildInfo(ctx context.Context, image string) (*BuildInfo, error)
 }
 
 // ClientMetadata is metadata to describe the client in more detail, configured with a YAML file in the client dir.
@@ -31,6 +31,10 @@ type Runner struct {
 	// This holds the image names of all built simulators.
 	simImages  map[string]string
 	clientDefs []*ClientDefinition
+
+	// Provenance of built images, and the file it was written to.
+	buildInfo     RunBuildInfo
+	buildInfoFile string
 }
 
 func NewRunner(inv Inventory, b Builder, cb ContainerBackend) *Runner {
@@ -41,15 +45,16 @@ func NewRunner(inv Inventory, b Builder, cb ContainerBackend) *Runner {
 	}
 }
 
-// Build builds client and simulator images.
-func (r *Runner) Build(ctx context.Context, clientList []ClientDesignator, simList []string) error {
+// Build builds client and simulator images. The build arguments are used for all
+// simulator images.
+func (r *Runner) Build(ctx context.Context, clientList []ClientDesignator, simList []string, simBuildArgs map[string]string) error {
 	if err := r.container.Build(ctx, r.builder); err != nil {
 		return err
 	}
 	if err := r.buildClients(ctx, clientList); err != nil {
 		return err
 	}
-	return r.buildSimulators(ctx, simList)
+	return r.buildSimulators(ctx, simList, simBuildArgs)
 }
 
 // buildClients builds client images.
@@ -78,6 +83,10 @@ func (r *Runner) buildClients(ctx context.Context, clientList []ClientDesignator
 			Image:   image,
 			Meta:    r.inv.Clients[client.Client].Meta,
 		}
+		if info := r.imageBuildInfo(ctx, client.Name(), image); info != nil {
+			info.Version = r.clientDefs[i].Version
+			r.buildInfo.Clients = append(r.buildInfo.Clients, info)
+		}
 	}
 	if !anyBuilt {
 		return errors.New("all clients failed to build")
@@ -86,25 +95,40 @@ func (r *Runner) buildClients(ctx context.Context, clientList []ClientDesignator
 }
 
 // buildSimulators builds simulator images.
-func (r *Runner) buildSimulators(ctx context.Context, simList []string) error {
+func (r *Runner) buildSimulators(ctx context.Context, simList []string, buildArgs map[string]string) error {
 	r.simImages = make(map[string]string)
 
 	log15.Info(fmt.Sprintf("building %d simulators...", len(simList)))
 	for _, sim := range simList {
-		image, err := r.builder.BuildSimulatorImage(ctx, sim)
+		image, err := r.builder.BuildSimulatorImage(ctx, sim, buildArgs)
 		if err != nil {
 			return err
 		}
 		r.simImages[sim] = image
+		if info := r.imageBuildInfo(ctx, sim, image); info != nil {
+			r.buildInfo.Simulators = append(r.buildInfo.Simulators, info)
+		}
 	}
 	return nil
 }
 
+// imageBuildInfo gets the provenance information of a built image.
+func (r *Runner) imageBuildInfo(ctx context.Context, name, image string) *BuildInfo {
+	info, err := r.builder.BuildInfo(ctx, image)
+	if err != nil {
+		log15.Warn("can't get build info of "+name, "image", image, "err", err)
+		return nil
+	}
+	info.Name = name
+	return info
+}
+
 func (r *Runner) Run(ctx context.Context, sim string, env SimEnv) (SimResult, error) {
 	if err := createWorkspace(env.LogDir); err != nil {
 		return SimResult{}, err
 	}
 	writeInstanceInfo(env.LogDir)
+	env.BuildInfoFile = r.writeBuildInfo(env.LogDir)
 	return r.run(ctx, sim, env)
 }
 
@@ -117,6 +141,7 @@ func (r *Runner) RunDevMode(ctx context.Context, env SimEnv, endpoint string) er
 	if err := createWorkspace(env.LogDir); err != nil {
 		return err
 	}
+	env.BuildInfoFile = r.writeBuildInfo(env.LogDir)
 	clientDefs := make([]*ClientDefinition, 0)
 	for _, def := range r.clientDefs {
 		clientDefs = append(clientDefs, def)
@@ -201,7 +226,7 @@ func (r *Runner) run(ctx context.Context, sim string, env SimEnv) (SimResult, er
 	// Create the simulator container.
 	opts := ContainerOptions{
 		Env: map[string]string{
-			"HIVE_SIMULATOR":    "http://" + server.Addr().String(),
+			"HIVE_SIMULATOR":    server.URL(),
 			"HIVE_PARALLELISM":  strconv.Itoa(env.SimParallelism),
 			"HIVE_LOGLEVEL":     strconv.Itoa(env.SimLogLevel),
 			"HIVE_TEST_PATTERN": env.SimTestPattern,
@@ -318,6 +343,28 @@ func writeInstanceInfo(logdir string) {
 	}
 }
 
+// writeBuildInfo writes the build info file of the run. The file is written
+// once, and shared by all simulations of the run. It returns the path of the file
+// relative to logdir, or the empty string if it could not be written.
+func (r *Runner) writeBuildInfo(logdir string) string {
+	if r.buildInfoFile != "" {
+		return r.buildInfoFile
+	}
+	file := fmt.Sprintf("builds/%d-build-info.json", time.Now().Unix())
+	path := filepath.Join(logdir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log15.Warn("can't create build info directory", "err", err)
+		return ""
+	}
+	enc, _ := json.MarshalIndent(&r.buildInfo, "", "  ")
+	if err := os.WriteFile(path, enc, 0644); err != nil {
+		log15.Warn("can't write build info", "err", err)
+		return ""
+	}
+	r.buildInfoFile = file
+	return file
+}
+
 func hiveVersion() (commit, date string) {
 	if buildInfo, ok := debug.ReadBuildInfo(); ok {
 		for _, v := range buildInfo.Settings {
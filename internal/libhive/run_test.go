@@ -2,6 +2,7 @@ package libhive_test
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -21,7 +22,15 @@ func TestRunner(t *testing.T) {
 	)
 
 	inv := makeTestInventory()
-	b := fakes.NewBuilder(&fakes.BuilderHooks{})
+	simBuildArgs := map[string]string{"forks": "Cancun"}
+	b := fakes.NewBuilder(&fakes.BuilderHooks{
+		BuildSimulatorImage: func(ctx context.Context, sim string, buildArgs map[string]string) (string, error) {
+			if !reflect.DeepEqual(buildArgs, simBuildArgs) {
+				t.Errorf("wrong build args for %s: %v", sim, buildArgs)
+			}
+			return "fakebuild/simulator/" + sim + ":latest", nil
+		},
+	})
 	cb := fakes.NewContainerBackend(&fakes.BackendHooks{
 		StartContainer: func(image, containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error) {
 			t.Logf("StartContainer(image=%s, id=%s)", image, containerID)
@@ -48,7 +57,7 @@ func TestRunner(t *testing.T) {
 		simOpt  = libhive.SimEnv{LogDir: t.TempDir(), ClientList: simClients}
 		ctx     = context.Background()
 	)
-	if err := runner.Build(ctx, allClients, simList); err != nil {
+	if err := runner.Build(ctx, allClients, simList, simBuildArgs); err != nil {
 		t.Fatal("Build() failed:", err)
 	}
 	if _, err := runner.Run(context.Background(), "sim-1", simOpt); err != nil {
@@ -57,6 +66,23 @@ func TestRunner(t *testing.T) {
 
 	content, _ := os.ReadFile(filepath.Join(simOpt.LogDir, "hive.json"))
 	t.Logf("hive.json content: %s", content)
+
+	// Check the build info file.
+	files, _ := filepath.Glob(filepath.Join(simOpt.LogDir, "builds", "*-build-info.json"))
+	if len(files) != 1 {
+		t.Fatal("expected one build info file, got", files)
+	}
+	var buildInfo libhive.RunBuildInfo
+	content, _ = os.ReadFile(files[0])
+	if err := json.Unmarshal(content, &buildInfo); err != nil {
+		t.Fatal("invalid build info:", err)
+	}
+	if len(buildInfo.Clients) != len(allClients) || len(buildInfo.Simulators) != len(simList) {
+		t.Fatalf("wrong build info content: %s", content)
+	}
+	if buildInfo.Simulators[0].Name != "sim-1" {
+		t.Fatalf("wrong simulator name %q in build info", buildInfo.Simulators[0].Name)
+	}
 }
 
 func makeTestInventory() libhive.Inventory {
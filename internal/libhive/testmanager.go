@@ -1,6 +1,7 @@
 package libhive
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
@@ -48,6 +49,13 @@ type SimEnv struct {
 	// This configures the amount of time the simulation waits
 	// for the client to open port 8545 after launching the container.
 	ClientStartTimeout time.Duration
+
+	// This is the format of client log files (LogFormatText or LogFormatJSONL).
+	ClientLogFormat string
+
+	// This is the build info file of the run, relative to LogDir.
+	// It is set by Runner.
+	BuildInfoFile string
 }
 
 // SimResult summarizes the results of a simulation run.
@@ -402,6 +410,7 @@ func (manager *TestManager) StartTestSuite(name string, description string) (Tes
 		TestCases:       make(map[TestID]*TestCase),
 		SimulatorLog:    manager.simLogFile,
 		TestDetailsLog:  testLogPath,
+		BuildInfo:       manager.config.BuildInfoFile,
 		testDetailsFile: testLogFile,
 	}
 	manager.testSuiteCounter++
@@ -542,6 +551,55 @@ func (manager *TestManager) StopNode(testID TestID, nodeID string) error {
 	return nil
 }
 
+// RestartNode stops a client container and starts it again with the same launch
+// options. The container's file system is kept, and its output is appended to the
+// existing log file. The returned ClientInfo carries the IP address of the restarted
+// container, which may differ from the one assigned at first start.
+func (manager *TestManager) RestartNode(ctx context.Context, testID TestID, nodeID string) (*ClientInfo, error) {
+	manager.testCaseMutex.Lock()
+	testCase, ok := manager.runningTestCases[testID]
+	if !ok {
+		manager.testCaseMutex.Unlock()
+		return nil, ErrNoSuchNode
+	}
+	nodeInfo, ok := testCase.ClientInfo[nodeID]
+	if !ok {
+		manager.testCaseMutex.Unlock()
+		return nil, ErrNoSuchNode
+	}
+	wait := nodeInfo.wait
+	if wait == nil {
+		manager.testCaseMutex.Unlock()
+		return nil, errors.New("client is not running")
+	}
+	nodeInfo.wait = nil
+	options := nodeInfo.options
+	manager.testCaseMutex.Unlock()
+
+	// Stop the container and wait for its output to be flushed. The lock is
+	// not held here because the client may take a while to shut down and start.
+	if err := manager.backend.StopContainer(nodeInfo.ID); err != nil {
+		return nil, fmt.Errorf("unable to stop client: %v", err)
+	}
+	wait()
+
+	// Start it again.
+	options.AppendLog = true
+	info, err := manager.backend.StartContainer(ctx, nodeInfo.ID, options)
+
+	manager.testCaseMutex.Lock()
+	defer manager.testCaseMutex.Unlock()
+	if info != nil {
+		nodeInfo.IP = info.IP
+		nodeInfo.wait = info.Wait
+	}
+	if err != nil {
+		return nil, fmt.Errorf("client did not restart: %v", err)
+	}
+	restarted := *nodeInfo
+	return &restarted, nil
+}
+
 // PauseNode pauses a client container.
 func (manager *TestManager) PauseNode(testID TestID, nodeID string) error {
 	manager.testCaseMutex.Lock()
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fixtureIndex lists the tests of the fixture files, so files without selected
+// tests can be skipped without decoding them. The index is created when the
+// simulator image is built, and entries of changed files are ignored.
+type fixtureIndex struct {
+	Files map[string]*indexedFile `json:"files"`
+}
+
+// indexedFile is the index entry of a fixture file, by its path.
+type indexedFile struct {
+	Size    int64          `json:"size"`
+	ModTime time.Time      `json:"modTime"`
+	Tests   []*indexedTest `json:"tests"`
+}
+
+type indexedTest struct {
+	Name string `json:"name"`
+	Fork string `json:"fork"`
+}
+
+// loadIndex reads the index file. A missing file is an empty index.
+func loadIndex(file string) (*fixtureIndex, error) {
+	index := &fixtureIndex{Files: make(map[string]*indexedFile)}
+	if file == "" {
+		return index, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("invalid index %s: %v", file, err)
+	}
+	if index.Files == nil {
+		index.Files = make(map[string]*indexedFile)
+	}
+	return index, nil
+}
+
+// write stores the index in a file.
+func (idx *fixtureIndex) write(file string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// lookup returns the indexed tests of a file, if the file is unchanged.
+func (idx *fixtureIndex) lookup(path string, info os.FileInfo) ([]*indexedTest, bool) {
+	f, ok := idx.Files[path]
+	if !ok || f.Size != info.Size() || !f.ModTime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	return f.Tests, true
+}
+
+// add indexes the tests of a file.
+func (idx *fixtureIndex) add(path string, info os.FileInfo, tests map[string]json.RawMessage) {
+	f := &indexedFile{Size: info.Size(), ModTime: info.ModTime()}
+	for name, raw := range tests {
+		var test struct {
+			Network string `json:"network"`
+		}
+		json.Unmarshal(raw, &test)
+		f.Tests = append(f.Tests, &indexedTest{Name: name, Fork: test.Network})
+	}
+	idx.Files[path] = f
+}
+
+// buildIndex indexes every fixture file in root.
+func buildIndex(root string) (*fixtureIndex, error) {
+	index := &fixtureIndex{Files: make(map[string]*indexedFile)}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			return nil
+		}
+		var tests map[string]json.RawMessage
+		if err := common.LoadJSON(path, &tests); err != nil {
+			fmt.Printf("skipping invalid test file %s: %v\n", path, err)
+			return nil
+		}
+		index.add(path, info, tests)
+		return nil
+	})
+	return index, err
+}
+
+// forkSet is the set of forks selected for testing. An empty set selects all forks.
+type forkSet map[string]bool
+
+// forksFromEnv returns the forks in the comma-separated FORKS variable.
+func forksFromEnv() forkSet {
+	forks := make(forkSet)
+	for _, fork := range strings.Split(os.Getenv("FORKS"), ",") {
+		if fork = strings.TrimSpace(fork); fork != "" {
+			forks[fork] = true
+		}
+	}
+	return forks
+}
+
+func (s forkSet) String() string {
+	forks := make([]string, 0, len(s))
+	for fork := range s {
+		forks = append(forks, fork)
+	}
+	sort.Strings(forks)
+	return strings.Join(forks, ",")
+}
+
+func (s forkSet) has(fork string) bool {
+	return len(s) == 0 || s[fork]
+}
+
+// anySelected reports whether any of the indexed tests is of a selected fork.
+func (s forkSet) anySelected(tests []*indexedTest) bool {
+	for _, test := range tests {
+		if s.has(test.Fork) {
+			return true
+		}
+	}
+	return false
+}
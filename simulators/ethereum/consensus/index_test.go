@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureIndex(t *testing.T) {
+	root := t.TempDir() + "/"
+	if err := os.MkdirAll(filepath.Join(root, "ValidBlocks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "ValidBlocks", "test.json")
+	content := `{"a_London": {"network": "London"}, "a_Shanghai": {"network": "Shanghai"}}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := buildIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexFile := filepath.Join(t.TempDir(), "index.json")
+	if err := index.write(indexFile); err != nil {
+		t.Fatal(err)
+	}
+	index, err = loadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, ok := index.lookup(file, info)
+	if !ok || len(tests) != 2 {
+		t.Fatalf("wrong indexed tests %v", tests)
+	}
+	if !(forkSet{"Shanghai": true}).anySelected(tests) {
+		t.Error("Shanghai test not selected")
+	}
+	if (forkSet{"Cancun": true}).anySelected(tests) {
+		t.Error("test selected for Cancun")
+	}
+	if !(forkSet{}).anySelected(tests) {
+		t.Error("test not selected without forks")
+	}
+
+	// Entries of changed files are ignored.
+	if err := os.WriteFile(file, []byte(content+" "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = os.Stat(file)
+	if _, ok := index.lookup(file, info); ok {
+		t.Error("index entry of changed file used")
+	}
+
+	// A missing index is empty.
+	index, err = loadIndex(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(index.Files) != 0 {
+		t.Errorf("wrong missing index %v (err %v)", index, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/sha1"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,6 +21,16 @@ import (
 )
 
 func main() {
+	indexFile := flag.String("index", "", "write the fixture index of $TESTPATH to the file and exit")
+	flag.Parse()
+	if *indexFile != "" {
+		if err := writeIndex(*indexFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	suite := hivesim.Suite{
 		Name: "consensus",
 		Description: "The 'consensus' test suite executes BlockchainTests from the " +
@@ -62,6 +73,17 @@ func loaderTest(t *hivesim.T) {
 	}
 	fileRoot := fmt.Sprintf("%s/BlockchainTests/", testPath)
 
+	// Load the fixture index and the selected forks.
+	index, err := loadIndex(os.Getenv("FIXTURE_INDEX"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("fixture index: %d files", len(index.Files))
+	forks := forksFromEnv()
+	if len(forks) > 0 {
+		t.Log("forks:", forks)
+	}
+
 	// Spawn workers.
 	var wg sync.WaitGroup
 	var testCh = make(chan *testcase)
@@ -87,7 +109,7 @@ func loaderTest(t *hivesim.T) {
 	re := regexp.MustCompile(testPattern)
 
 	// Deliver test cases.
-	loadTests(t, fileRoot, re, func(tc testcase) {
+	loadTests(t, fileRoot, re, index, forks, func(tc testcase) {
 		for _, client := range clientTypes {
 			if !client.HasRole("eth1") {
 				continue
@@ -115,8 +137,24 @@ func testLink(filepath string) string {
 	return fmt.Sprintf("https://github.com/ethereum/tests/blob/develop/%v", path)
 }
 
+// writeIndex indexes the fixture files in $TESTPATH.
+func writeIndex(file string) error {
+	testPath, isset := os.LookupEnv("TESTPATH")
+	if !isset {
+		return fmt.Errorf("$TESTPATH not set")
+	}
+	index, err := buildIndex(fmt.Sprintf("%s/BlockchainTests/", testPath))
+	if err != nil {
+		return err
+	}
+	return index.write(file)
+}
+
 // loadTests loads the files in 'root', running the given function for each test.
-func loadTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(testcase)) {
+// Files whose tests are indexed are only loaded if they contain tests of the
+// selected forks.
+func loadTests(t *hivesim.T, root string, re *regexp.Regexp, index *fixtureIndex, forks forkSet, fn func(testcase)) {
+	var skipped int
 	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			t.Logf("unable to walk path: %s", err)
@@ -133,6 +171,10 @@ func loadTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(testcase))
 			fmt.Println("skip", pathname)
 			return nil // skip
 		}
+		if tests, ok := index.lookup(path, info); ok && !forks.anySelected(tests) {
+			skipped++
+			return nil
+		}
 
 		var tests map[string]BlockTest
 		if err := common.LoadJSON(path, &tests); err != nil {
@@ -141,6 +183,9 @@ func loadTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(testcase))
 		}
 
 		for name, blocktest := range tests {
+			if !forks.has(blocktest.json.Network) {
+				continue
+			}
 			tc := testcase{blockTest: blocktest, name: name, filepath: path}
 			if err := tc.validate(); err != nil {
 				t.Errorf("test validation failed for %s: %v", tc.name, err)
@@ -150,6 +195,9 @@ func loadTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(testcase))
 		}
 		return nil
 	})
+	if skipped > 0 {
+		t.Logf("skipped %d indexed files without tests of the selected forks", skipped)
+	}
 }
 
 type testcase struct {
@@ -2,7 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"os"
+	"strings"
 
 	api "github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/core"
@@ -16,6 +19,7 @@ import (
 type Eth interface {
 	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
 	BlockNumber(ctx context.Context) (uint64, error)
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
@@ -38,16 +42,20 @@ type Engine interface {
 	GetPayloadV1(ctx context.Context, payloadId *api.PayloadID) (typ.ExecutableData, error)
 	GetPayloadV2(ctx context.Context, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, error)
 	GetPayloadV3(ctx context.Context, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error)
+	GetPayloadV4(ctx context.Context, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error)
 	GetPayload(ctx context.Context, version int, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error)
 
 	NewPayload(ctx context.Context, version int, payload *typ.ExecutableData) (api.PayloadStatusV1, error)
 	NewPayloadV1(ctx context.Context, payload *typ.ExecutableData) (api.PayloadStatusV1, error)
 	NewPayloadV2(ctx context.Context, payload *typ.ExecutableData) (api.PayloadStatusV1, error)
 	NewPayloadV3(ctx context.Context, payload *typ.ExecutableData) (api.PayloadStatusV1, error)
+	NewPayloadV4(ctx context.Context, payload *typ.ExecutableData) (api.PayloadStatusV1, error)
 
 	GetPayloadBodiesByRangeV1(ctx context.Context, start uint64, count uint64) ([]*typ.ExecutionPayloadBodyV1, error)
 	GetPayloadBodiesByHashV1(ctx context.Context, hashes []common.Hash) ([]*typ.ExecutionPayloadBodyV1, error)
 
+	GetBlobsV1(ctx context.Context, versionedHashes []common.Hash) ([]*typ.BlobAndProofV1, error)
+
 	LatestForkchoiceSent() (fcState *api.ForkchoiceStateV1, pAttributes *typ.PayloadAttributes)
 	LatestNewPayloadSent() (payload *typ.ExecutableData)
 
@@ -84,6 +92,31 @@ type EngineClient interface {
 	Eth
 }
 
+// Transport is the protocol used to send Engine API requests to a client.
+type Transport string
+
+const (
+	HTTP      Transport = "http"
+	WebSocket Transport = "ws"
+)
+
+// EnvTransport is the environment variable which selects the Engine API transport
+// of all clients in a test run. The simulator sets it from the transport build
+// argument.
+const EnvTransport = "HIVE_ENGINE_TRANSPORT"
+
+// TransportFromEnv returns the transport selected for the test run, HTTP if unset.
+func TransportFromEnv() (Transport, error) {
+	switch t := Transport(strings.ToLower(os.Getenv(EnvTransport))); t {
+	case "":
+		return HTTP, nil
+	case HTTP, WebSocket:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown %s value %q", EnvTransport, t)
+	}
+}
+
 type EngineStarter interface {
 	StartClient(T *hivesim.T, testContext context.Context, genesis *core.Genesis, ClientParams hivesim.Params, ClientFiles hivesim.Params, bootClients ...EngineClient) (EngineClient, error)
 }
@@ -0,0 +1,26 @@
+package client
+
+import "testing"
+
+func TestTransportFromEnv(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  Transport
+		err   bool
+	}{
+		{"", HTTP, false},
+		{"http", HTTP, false},
+		{"ws", WebSocket, false},
+		{"WS", WebSocket, false},
+		{"ipc", "", true},
+	} {
+		t.Setenv(EnvTransport, tt.value)
+		got, err := TransportFromEnv()
+		if (err != nil) != tt.err {
+			t.Errorf("%q: unexpected error: %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
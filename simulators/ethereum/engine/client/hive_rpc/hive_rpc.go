@@ -1,9 +1,12 @@
 package hive_rpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net"
 	"net/http"
@@ -23,8 +26,12 @@ import (
 	"github.com/ethereum/hive/simulators/ethereum/engine/client"
 	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
 	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/metrics"
+	"github.com/ethereum/hive/simulators/ethereum/engine/schema"
+	"github.com/ethereum/hive/simulators/ethereum/engine/trace"
 	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
 
@@ -36,17 +43,20 @@ type HiveRPCEngineStarter struct {
 	EnginePort              int
 	EthPort                 int
 	JWTSecret               []byte
+	// Transport of the Engine API, selected by client.EnvTransport if empty
+	EngineTransport client.Transport
 }
 
 var _ client.EngineStarter = (*HiveRPCEngineStarter)(nil)
 
 func (s HiveRPCEngineStarter) StartClient(T *hivesim.T, testContext context.Context, genesis *core.Genesis, ClientParams hivesim.Params, ClientFiles hivesim.Params, bootClients ...client.EngineClient) (client.EngineClient, error) {
 	var (
-		clientType = s.ClientType
-		enginePort = s.EnginePort
-		ethPort    = s.EthPort
-		jwtSecret  = s.JWTSecret
-		ttd        = s.TerminalTotalDifficulty
+		clientType      = s.ClientType
+		enginePort      = s.EnginePort
+		ethPort         = s.EthPort
+		jwtSecret       = s.JWTSecret
+		ttd             = s.TerminalTotalDifficulty
+		engineTransport = s.EngineTransport
 	)
 	if clientType == "" {
 		cs, err := T.Sim.ClientTypes()
@@ -67,6 +77,12 @@ func (s HiveRPCEngineStarter) StartClient(T *hivesim.T, testContext context.Cont
 	if jwtSecret == nil {
 		jwtSecret = globals.DefaultJwtTokenSecretBytes
 	}
+	if engineTransport == "" {
+		var err error
+		if engineTransport, err = client.TransportFromEnv(); err != nil {
+			return nil, err
+		}
+	}
 	if s.ChainFile != "" {
 		ClientFiles = ClientFiles.Set("/chain.rlp", "./chains/"+s.ChainFile)
 	}
@@ -104,11 +120,19 @@ func (s HiveRPCEngineStarter) StartClient(T *hivesim.T, testContext context.Cont
 	if err != nil {
 		return nil, err
 	}
-	c := T.StartClient(clientType, genesisStart, ClientParams, hivesim.WithStaticFiles(ClientFiles))
+	startOptions := []hivesim.StartOption{genesisStart, ClientParams, hivesim.WithStaticFiles(ClientFiles)}
+	if s.JWTSecret != nil {
+		// Configure the client with the custom secret
+		secretFile := []byte("0x" + hex.EncodeToString(jwtSecret))
+		startOptions = append(startOptions, hivesim.WithDynamicFile(globals.JwtSecretFile, func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(secretFile)), nil
+		}))
+	}
+	c := T.StartClient(clientType, startOptions...)
 	if err := CheckEthEngineLive(c); err != nil {
 		return nil, fmt.Errorf("Engine/Eth ports were never open for client: %v", err)
 	}
-	ec := NewHiveRPCEngineClient(c, enginePort, ethPort, jwtSecret, ttd, &helper.LoggingRoundTrip{
+	ec := NewHiveRPCEngineClient(c, enginePort, ethPort, jwtSecret, ttd, engineTransport, &helper.LoggingRoundTrip{
 		Logger: T,
 		ID:     c.Container,
 		Inner:  http.DefaultTransport,
@@ -151,11 +175,21 @@ type AccountTransactionInfo struct {
 // Implements the EngineClient interface for a normal RPC client.
 type HiveRPCEngineClient struct {
 	*ethclient.Client
-	h              *hivesim.Client
-	c              *rpc.Client
-	cEth           *rpc.Client
-	ttd            *big.Int
-	JWTSecretBytes []byte
+	h         *hivesim.Client
+	c         *rpc.Client
+	engineURL string
+	// Transport of the Engine API client c. The Eth API is always used over HTTP.
+	engineTransport client.Transport
+	cEth            *rpc.Client
+	ttd             *big.Int
+	JWTSecretBytes  []byte
+
+	// Records the requests of the client when a trace recorder is set
+	traceTransport *trace.Transport
+	// Validates the responses of the client when a schema checker is set
+	schemaTransport *schema.Transport
+	// Measures the request latencies of the client when a metrics recorder is set
+	metricsTransport *metrics.Transport
 
 	// Engine updates info
 	latestFcUStateSent *api.ForkchoiceStateV1
@@ -173,11 +207,37 @@ type HiveRPCEngineClient struct {
 var _ client.EngineClient = (*HiveRPCEngineClient)(nil)
 
 // NewClient creates a engine client that uses the given RPC client.
-func NewHiveRPCEngineClient(h *hivesim.Client, enginePort int, ethPort int, jwtSecretBytes []byte, ttd *big.Int, transport http.RoundTripper) *HiveRPCEngineClient {
+// Requests sent over HTTP pass through the given transport, which is not used for
+// the WebSocket connection of the Engine API.
+func NewHiveRPCEngineClient(h *hivesim.Client, enginePort int, ethPort int, jwtSecretBytes []byte, ttd *big.Int, engineTransport client.Transport, transport http.RoundTripper) *HiveRPCEngineClient {
 	// Prepare HTTP Client
-	httpClient := rpc.WithHTTPClient(&http.Client{Transport: transport})
+	metricsTransport := &metrics.Transport{Inner: transport}
+	traceTransport := &trace.Transport{Client: h.Container, Inner: metricsTransport}
+	schemaTransport := &schema.Transport{Inner: traceTransport}
+	httpClient := rpc.WithHTTPClient(&http.Client{Transport: schemaTransport})
 
-	engineRpcClient, err := rpc.DialOptions(context.Background(), fmt.Sprintf("http://%s:%d/", h.IP, enginePort), httpClient)
+	// Prepare Engine Client
+	var (
+		engineURL       string
+		engineRpcClient *rpc.Client
+		err             error
+	)
+	switch engineTransport {
+	case client.WebSocket:
+		// The token is only sent with the upgrade request of the connection.
+		engineURL = fmt.Sprintf("ws://%s:%d/", h.IP, enginePort)
+		engineRpcClient, err = rpc.DialOptions(context.Background(), engineURL, rpc.WithHTTPAuth(func(header http.Header) error {
+			token, err := GetNewToken(jwtSecretBytes, time.Now())
+			if err != nil {
+				return err
+			}
+			header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			return nil
+		}))
+	default:
+		engineURL = fmt.Sprintf("http://%s:%d/", h.IP, enginePort)
+		engineRpcClient, err = rpc.DialOptions(context.Background(), engineURL, httpClient)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -189,13 +249,18 @@ func NewHiveRPCEngineClient(h *hivesim.Client, enginePort int, ethPort int, jwtS
 	}
 	eth := ethclient.NewClient(ethRpcClient)
 	return &HiveRPCEngineClient{
-		h:              h,
-		c:              engineRpcClient,
-		Client:         eth,
-		cEth:           ethRpcClient,
-		ttd:            ttd,
-		JWTSecretBytes: jwtSecretBytes,
-		accTxInfoMap:   make(map[common.Address]*AccountTransactionInfo),
+		h:                h,
+		c:                engineRpcClient,
+		engineURL:        engineURL,
+		engineTransport:  engineTransport,
+		Client:           eth,
+		cEth:             ethRpcClient,
+		ttd:              ttd,
+		JWTSecretBytes:   jwtSecretBytes,
+		traceTransport:   traceTransport,
+		schemaTransport:  schemaTransport,
+		metricsTransport: metricsTransport,
+		accTxInfoMap:     make(map[common.Address]*AccountTransactionInfo),
 	}
 }
 
@@ -203,6 +268,38 @@ func (ec *HiveRPCEngineClient) ID() string {
 	return ec.h.Container
 }
 
+// EngineTransport returns the transport of the Engine API client.
+func (ec *HiveRPCEngineClient) EngineTransport() client.Transport {
+	return ec.engineTransport
+}
+
+// SetTraceRecorder starts recording all Engine and Eth API requests of the client.
+// Recording is only supported over HTTP, since the WebSocket connection of the
+// Engine API does not pass through the recording transport.
+func (ec *HiveRPCEngineClient) SetTraceRecorder(rec *trace.Recorder) error {
+	if rec != nil && ec.engineTransport != client.HTTP {
+		return fmt.Errorf("engine API traces can't be recorded over the %s transport", ec.engineTransport)
+	}
+	ec.traceTransport.SetRecorder(rec)
+	return nil
+}
+
+// SetSchemaChecker starts validating all Engine and Eth API responses of the client.
+// Like recording, it is only supported over HTTP.
+func (ec *HiveRPCEngineClient) SetSchemaChecker(c *schema.Checker) error {
+	if c != nil && ec.engineTransport != client.HTTP {
+		return fmt.Errorf("strict schema mode is not supported over the %s transport", ec.engineTransport)
+	}
+	ec.schemaTransport.SetChecker(c)
+	return nil
+}
+
+// SetMetricsRecorder starts measuring the latencies of all Engine and Eth API
+// requests of the client.
+func (ec *HiveRPCEngineClient) SetMetricsRecorder(rec *metrics.Recorder) {
+	ec.metricsTransport.SetRecorder(rec)
+}
+
 func (ec *HiveRPCEngineClient) EnodeURL() (string, error) {
 	return ec.h.EnodeURL()
 }
@@ -275,6 +372,16 @@ func (ec *HiveRPCEngineClient) HeaderByNumber(ctx context.Context, number *big.I
 	return header, err
 }
 
+// BlockByNumberJSON returns the undecoded response of eth_getBlockByNumber.
+func (ec *HiveRPCEngineClient) BlockByNumberJSON(ctx context.Context, number *big.Int, fullTx bool) (json.RawMessage, error) {
+	var block json.RawMessage
+	err := ec.cEth.CallContext(ctx, &block, "eth_getBlockByNumber", toBlockNumArg(number), fullTx)
+	if err == nil && (len(block) == 0 || string(block) == "null") {
+		err = ethereum.NotFound
+	}
+	return block, err
+}
+
 // Helper structs to fetch the TotalDifficulty
 type TD struct {
 	TotalDifficulty *hexutil.Big `json:"totalDifficulty"`
@@ -335,6 +442,35 @@ func (ec *HiveRPCEngineClient) PrepareDefaultAuthCallToken() error {
 	return nil
 }
 
+// CallWithAuthToken calls an Engine API method with the given token, instead of a
+// token signed with the secret of the client. Over WebSocket, the call is sent on a
+// new connection whose upgrade request carries the token, and a rejected upgrade is
+// returned as rpc.HTTPError.
+func (ec *HiveRPCEngineClient) CallWithAuthToken(ctx context.Context, token string, result interface{}, method string, args ...interface{}) error {
+	if ec.engineTransport == client.WebSocket {
+		header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer %s", token)}}
+		// The RPC client doesn't return the status of a failed upgrade, so it is
+		// checked on a separate connection first.
+		conn, resp, err := websocket.DefaultDialer.DialContext(ctx, ec.engineURL, header)
+		if err != nil {
+			if resp != nil {
+				return rpc.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+			}
+			return err
+		}
+		conn.Close()
+		c, err := rpc.DialOptions(ctx, ec.engineURL, rpc.WithHeaders(header))
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		return c.CallContext(ctx, result, method, args...)
+	}
+	ec.c.SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+	defer ec.PrepareDefaultAuthCallToken()
+	return ec.c.CallContext(ctx, result, method, args...)
+}
+
 // Engine API Call Methods
 
 // Forkchoice Updated API Calls
@@ -391,6 +527,10 @@ func (ec *HiveRPCEngineClient) GetPayload(ctx context.Context, version int, payl
 		blockValue = response.BlockValue
 		blobsBundle = response.BlobsBundle
 		shouldOverrideBuilder = response.ShouldOverrideBuilder
+		if version >= 4 {
+			// Execution requests are a NewPayload parameter, keep them with the payload.
+			executableData.ExecutionRequests = response.ExecutionRequests
+		}
 	} else {
 		err = ec.c.CallContext(ctx, &executableData, rpcString, payloadId)
 	}
@@ -412,6 +552,10 @@ func (ec *HiveRPCEngineClient) GetPayloadV3(ctx context.Context, payloadId *api.
 	return ec.GetPayload(ctx, 3, payloadId)
 }
 
+func (ec *HiveRPCEngineClient) GetPayloadV4(ctx context.Context, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error) {
+	return ec.GetPayload(ctx, 4, payloadId)
+}
+
 // Get Payload Bodies API Calls
 func (ec *HiveRPCEngineClient) GetPayloadBodiesByRangeV1(ctx context.Context, start uint64, count uint64) ([]*typ.ExecutionPayloadBodyV1, error) {
 	var (
@@ -439,6 +583,20 @@ func (ec *HiveRPCEngineClient) GetPayloadBodiesByHashV1(ctx context.Context, has
 	return result, err
 }
 
+// Get Blobs API Calls
+func (ec *HiveRPCEngineClient) GetBlobsV1(ctx context.Context, versionedHashes []common.Hash) ([]*typ.BlobAndProofV1, error) {
+	var (
+		result []*typ.BlobAndProofV1
+		err    error
+	)
+	if err = ec.PrepareDefaultAuthCallToken(); err != nil {
+		return nil, err
+	}
+
+	err = ec.c.CallContext(ctx, &result, "engine_getBlobsV1", versionedHashes)
+	return result, err
+}
+
 // Get Blob Bundle API Calls
 func (ec *HiveRPCEngineClient) GetBlobsBundleV1(ctx context.Context, payloadId *api.PayloadID) (*typ.BlobsBundle, error) {
 	var (
@@ -459,7 +617,16 @@ func (ec *HiveRPCEngineClient) NewPayload(ctx context.Context, version int, payl
 		return result, err
 	}
 
-	if version >= 3 {
+	if version >= 4 {
+		var requests []hexutil.Bytes
+		if payload.ExecutionRequests != nil {
+			requests = make([]hexutil.Bytes, len(payload.ExecutionRequests))
+			for i, r := range payload.ExecutionRequests {
+				requests[i] = r
+			}
+		}
+		err = ec.c.CallContext(ctx, &result, fmt.Sprintf("engine_newPayloadV%d", version), payload, payload.VersionedHashes, payload.ParentBeaconBlockRoot, requests)
+	} else if version >= 3 {
 		err = ec.c.CallContext(ctx, &result, fmt.Sprintf("engine_newPayloadV%d", version), payload, payload.VersionedHashes, payload.ParentBeaconBlockRoot)
 	} else {
 		err = ec.c.CallContext(ctx, &result, fmt.Sprintf("engine_newPayloadV%d", version), payload)
@@ -483,6 +650,11 @@ func (ec *HiveRPCEngineClient) NewPayloadV3(ctx context.Context, payload *typ.Ex
 	return ec.NewPayload(ctx, 3, payload)
 }
 
+func (ec *HiveRPCEngineClient) NewPayloadV4(ctx context.Context, payload *typ.ExecutableData) (api.PayloadStatusV1, error) {
+	ec.latestPayloadSent = payload
+	return ec.NewPayload(ctx, 4, payload)
+}
+
 // Exchange Transition Configuration API Call Methods
 func (ec *HiveRPCEngineClient) ExchangeTransitionConfigurationV1(ctx context.Context, tConf *api.TransitionConfigurationV1) (api.TransitionConfigurationV1, error) {
 	var result api.TransitionConfigurationV1
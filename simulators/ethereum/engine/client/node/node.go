@@ -440,6 +440,8 @@ func (n *GethNode) NewPayload(ctx context.Context, version int, pl *typ.Executab
 		return n.NewPayloadV2(ctx, pl)
 	case 3:
 		return n.NewPayloadV3(ctx, pl)
+	case 4:
+		return n.NewPayloadV4(ctx, pl)
 	}
 	return beacon.PayloadStatusV1{}, fmt.Errorf("unknown version %d", version)
 }
@@ -480,6 +482,10 @@ func (n *GethNode) NewPayloadV3(ctx context.Context, pl *typ.ExecutableData) (be
 	return resp, err
 }
 
+func (n *GethNode) NewPayloadV4(ctx context.Context, pl *typ.ExecutableData) (beacon.PayloadStatusV1, error) {
+	return beacon.PayloadStatusV1{}, fmt.Errorf("prague is not supported by the embedded geth node")
+}
+
 func (n *GethNode) ForkchoiceUpdated(ctx context.Context, version int, fcs *beacon.ForkchoiceStateV1, payload *typ.PayloadAttributes) (beacon.ForkChoiceResponse, error) {
 	switch version {
 	case 1:
@@ -560,6 +566,10 @@ func (n *GethNode) GetPayloadV3(ctx context.Context, payloadId *beacon.PayloadID
 	return ed, p.BlockValue, blobsBundle, &p.Override, err
 }
 
+func (n *GethNode) GetPayloadV4(ctx context.Context, payloadId *beacon.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error) {
+	return typ.ExecutableData{}, nil, nil, nil, fmt.Errorf("prague is not supported by the embedded geth node")
+}
+
 func (n *GethNode) GetPayload(ctx context.Context, version int, payloadId *beacon.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error) {
 
 	switch version {
@@ -571,6 +581,8 @@ func (n *GethNode) GetPayload(ctx context.Context, version int, payloadId *beaco
 		return ed, value, nil, nil, err
 	case 3:
 		return n.GetPayloadV3(ctx, payloadId)
+	case 4:
+		return n.GetPayloadV4(ctx, payloadId)
 	default:
 		return typ.ExecutableData{}, nil, nil, nil, fmt.Errorf("unknown version %d", version)
 	}
@@ -584,6 +596,10 @@ func (n *GethNode) GetPayloadBodiesByHashV1(ctx context.Context, hashes []common
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (n *GethNode) GetBlobsV1(ctx context.Context, versionedHashes []common.Hash) ([]*typ.BlobAndProofV1, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (n *GethNode) GetBlobsBundleV1(ctx context.Context, payloadId *beacon.PayloadID) (*typ.BlobsBundle, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -680,6 +696,14 @@ func (n *GethNode) BalanceAt(ctx context.Context, account common.Address, blockN
 	return stateDB.GetBalance(account), nil
 }
 
+func (n *GethNode) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	stateDB, err := n.getStateDB(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return stateDB.GetCode(account), nil
+}
+
 func (n *GethNode) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
 	stateDB, err := n.getStateDB(ctx, blockNumber)
 	if err != nil {
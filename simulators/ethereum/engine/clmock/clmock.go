@@ -19,6 +19,8 @@ import (
 	"github.com/ethereum/hive/simulators/ethereum/engine/config/cancun"
 	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
 	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/schema"
+	"github.com/ethereum/hive/simulators/ethereum/engine/trace"
 	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -71,6 +73,10 @@ type CLMocker struct {
 	EngineClients []client.EngineClient
 	// Lock required so no client is offboarded during block production.
 	EngineClientsLock sync.Mutex
+	// Records the requests of all clients added to the CL Mocker, if set
+	TraceRecorder *trace.Recorder
+	// Validates the responses of all clients added to the CL Mocker, if set
+	SchemaChecker *schema.Checker
 	// Number of required slots before a block which was set as Head moves to `safe` and `finalized` respectively
 	SlotsToSafe      *big.Int
 	SlotsToFinalized *big.Int
@@ -181,6 +187,20 @@ func (cl *CLMocker) AddEngineClient(ec client.EngineClient) {
 	cl.EngineClientsLock.Lock()
 	defer cl.EngineClientsLock.Unlock()
 	cl.Logf("CLMocker: Adding engine client %v", ec.ID())
+	if cl.TraceRecorder != nil {
+		if tc, ok := ec.(interface{ SetTraceRecorder(*trace.Recorder) error }); ok {
+			if err := tc.SetTraceRecorder(cl.TraceRecorder); err != nil {
+				cl.Fatalf("CLMocker: Unable to record requests of %v: %v", ec.ID(), err)
+			}
+		}
+	}
+	if cl.SchemaChecker != nil {
+		if sc, ok := ec.(interface{ SetSchemaChecker(*schema.Checker) error }); ok {
+			if err := sc.SetSchemaChecker(cl.SchemaChecker); err != nil {
+				cl.Fatalf("CLMocker: Unable to check responses of %v: %v", ec.ID(), err)
+			}
+		}
+	}
 	cl.EngineClients = append(cl.EngineClients, ec)
 }
 
@@ -296,7 +316,7 @@ func (cl *CLMocker) SetTTDBlockClient(ec client.EngineClient) {
 
 	// Prepare initial forkchoice, to be sent to the transition payload producer
 	cl.LatestForkchoice = api.ForkchoiceStateV1{}
-	cl.LatestForkchoice.HeadBlockHash = cl.LatestHeader.Hash()
+	cl.LatestForkchoice.HeadBlockHash = cl.HeaderHash(cl.LatestHeader, nil)
 
 }
 
@@ -319,6 +339,21 @@ func (cl *CLMocker) IsBlockPoS(bn *big.Int) bool {
 	return true
 }
 
+// HeaderHash returns the block hash of a header retrieved from a client.
+// Prague headers commit to the execution requests of the block, which are not part
+// of go-ethereum's header type, so the requests of the payload the header was built
+// from must be given. They can't be looked up by number, since the header may be
+// part of a side chain.
+func (cl *CLMocker) HeaderHash(h *types.Header, requests [][]byte) common.Hash {
+	if !cl.IsPrague(h.Time) {
+		return h.Hash()
+	}
+	if requests == nil {
+		requests = [][]byte{}
+	}
+	return typ.HeaderHash(h, requests)
+}
+
 // Return the per-block timestamp value increment
 func (cl *CLMocker) GetTimestampIncrement() uint64 {
 	return cl.BlockTimestampIncrement.Uint64()
@@ -498,8 +533,8 @@ func (cl *CLMocker) GetNextPayload() {
 	if cl.LatestPayloadBuilt.Random != cl.LatestPayloadAttributes.Random {
 		cl.Fatalf("CLMocker: Incorrect PrevRandao on payload built: %v != %v", cl.LatestPayloadBuilt.Random, cl.LatestPayloadAttributes.Random)
 	}
-	if cl.LatestPayloadBuilt.ParentHash != cl.LatestHeader.Hash() {
-		cl.Fatalf("CLMocker: Incorrect ParentHash on payload built: %v != %v", cl.LatestPayloadBuilt.ParentHash, cl.LatestHeader.Hash())
+	if parentHash := cl.HeaderHash(cl.LatestHeader, cl.LatestExecutedPayload.ExecutionRequests); cl.LatestPayloadBuilt.ParentHash != parentHash {
+		cl.Fatalf("CLMocker: Incorrect ParentHash on payload built: %v != %v", cl.LatestPayloadBuilt.ParentHash, parentHash)
 	}
 	if cl.LatestPayloadBuilt.Number != cl.LatestHeader.Number.Uint64()+1 {
 		cl.Fatalf("CLMocker: Incorrect Number on payload built: %v != %v", cl.LatestPayloadBuilt.Number, cl.LatestHeader.Number.Uint64()+1)
@@ -517,6 +552,10 @@ func (cl *CLMocker) GetNextPayload() {
 		}
 		cl.LatestPayloadBuilt.ParentBeaconBlockRoot = cl.LatestPayloadAttributes.BeaconRoot
 	}
+
+	if cl.IsPrague(cl.LatestPayloadBuilt.Timestamp) && cl.LatestPayloadBuilt.ExecutionRequests == nil {
+		cl.Fatalf("CLMocker: No execution requests on prague")
+	}
 	cl.LatestPayloadBuilt.PayloadAttributes = cl.LatestPayloadAttributes
 }
 
@@ -696,8 +735,8 @@ func (cl *CLMocker) ProduceSingleBlock(callbacks BlockProcessCallbacks) {
 			cl.Logf("CLMocker: Client %v did not accept the new payload: %v", ec.ID(), err)
 			continue
 		}
-		if newHeader.Hash() != cl.LatestPayloadBuilt.BlockHash {
-			cl.Logf("CLMocker: Client %v produced a new header with incorrect hash: %v != %v", ec.ID(), newHeader.Hash(), cl.LatestPayloadBuilt.BlockHash)
+		if newHash := cl.HeaderHash(newHeader, cl.LatestPayloadBuilt.ExecutionRequests); newHash != cl.LatestPayloadBuilt.BlockHash {
+			cl.Logf("CLMocker: Client %v produced a new header with incorrect hash: %v != %v", ec.ID(), newHash, cl.LatestPayloadBuilt.BlockHash)
 			continue
 		}
 		// Check that the new finalized header has the correct properties
@@ -0,0 +1,87 @@
+// The engine-perf-compare command compares the reports of two runs of the
+// engine-perf suite and reports the metrics which got worse.
+//
+//	engine-perf-compare -threshold 0.1 old-results.json new-results.json
+//
+// The arguments can be hive suite result files, test logs, or reports in JSON
+// format. All result files of a run are read when the hive results directory is
+// passed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/metrics"
+)
+
+func main() {
+	var (
+		threshold = flag.Float64("threshold", 0.1, "relative change above which a metric is a regression")
+		all       = flag.Bool("all", false, "print all changes, not only regressions")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <old> <new>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old, err := readReports(flag.Arg(0))
+	if err != nil {
+		fatalf("can't read %s: %v", flag.Arg(0), err)
+	}
+	new, err := readReports(flag.Arg(1))
+	if err != nil {
+		fatalf("can't read %s: %v", flag.Arg(1), err)
+	}
+	changes := metrics.Compare(old, new, *threshold)
+	if len(changes) == 0 {
+		fatalf("no tests found in both runs")
+	}
+	var regressions int
+	for _, c := range changes {
+		if c.Regression {
+			regressions++
+			fmt.Println("REGRESSION", c)
+		} else if *all {
+			fmt.Println("          ", c)
+		}
+	}
+	fmt.Printf("%d metrics compared, %d regressions\n", len(changes), regressions)
+	if regressions > 0 {
+		os.Exit(1)
+	}
+}
+
+// readReports reads the reports of a file, or of all result files in a hive
+// results directory.
+func readReports(path string) ([]*metrics.Report, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+	}
+	var reports []*metrics.Report
+	for _, file := range files {
+		r, err := metrics.ReadReports(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		reports = append(reports, r...)
+	}
+	return reports, nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -0,0 +1,73 @@
+// The engine-replay command sends the requests of an engine simulator trace to a
+// client and reports the first response that differs from the recorded one.
+//
+//	engine-replay -engine http://127.0.0.1:8551 -eth http://127.0.0.1:8545 results.json
+//
+// The trace is read from a hive suite result file, a test log, or a file of trace
+// entries in JSON-lines format. The client must be started with the same genesis as
+// the recorded test.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/trace"
+)
+
+func main() {
+	var (
+		engineURL = flag.String("engine", "http://127.0.0.1:8551", "Engine API endpoint of the client")
+		ethURL    = flag.String("eth", "http://127.0.0.1:8545", "Eth API endpoint of the client")
+		jwtSecret = flag.String("jwt", hex.EncodeToString(globals.DefaultJwtTokenSecretBytes), "JWT secret (hex)")
+		client    = flag.String("client", "", "container ID of the recorded client (default: first client in trace)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <trace>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	secret, err := hex.DecodeString(strings.TrimPrefix(*jwtSecret, "0x"))
+	if err != nil {
+		fatalf("invalid -jwt: %v", err)
+	}
+	entries, err := trace.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatalf("can't read trace: %v", err)
+	}
+	clients := trace.Clients(entries)
+	if len(clients) == 0 {
+		fatalf("trace is empty")
+	}
+	if *client == "" {
+		*client = clients[0]
+	}
+	entries = trace.FilterClient(entries, *client)
+	fmt.Printf("replaying %d requests of client %s\n", len(entries), *client)
+
+	r := &trace.Replayer{EngineURL: *engineURL, EthURL: *ethURL, JWTSecret: secret}
+	div, err := r.Replay(context.Background(), entries)
+	if err != nil {
+		fatalf("replay failed: %v", err)
+	}
+	if div != nil {
+		fmt.Println("divergence at", div)
+		os.Exit(1)
+	}
+	fmt.Println("all responses match the trace")
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -14,6 +14,7 @@ const (
 	Paris    Fork = "Paris"
 	Shanghai Fork = "Shanghai"
 	Cancun   Fork = "Cancun"
+	Prague   Fork = "Prague"
 )
 
 func (f Fork) PreviousFork() Fork {
@@ -24,6 +25,8 @@ func (f Fork) PreviousFork() Fork {
 		return Paris
 	case Cancun:
 		return Shanghai
+	case Prague:
+		return Cancun
 	default:
 		return NA
 	}
@@ -34,6 +37,7 @@ type ForkConfig struct {
 	ParisNumber       *big.Int
 	ShanghaiTimestamp *big.Int
 	CancunTimestamp   *big.Int
+	PragueTimestamp   *big.Int
 }
 
 func (f *ForkConfig) IsShanghai(blockTimestamp uint64) bool {
@@ -44,6 +48,10 @@ func (f *ForkConfig) IsCancun(blockTimestamp uint64) bool {
 	return f.CancunTimestamp != nil && new(big.Int).SetUint64(blockTimestamp).Cmp(f.CancunTimestamp) >= 0
 }
 
+func (f *ForkConfig) IsPrague(blockTimestamp uint64) bool {
+	return f.PragueTimestamp != nil && new(big.Int).SetUint64(blockTimestamp).Cmp(f.PragueTimestamp) >= 0
+}
+
 func (f *ForkConfig) ForkchoiceUpdatedVersion(headTimestamp uint64, payloadAttributesTimestamp *uint64) int {
 	// If the payload attributes timestamp is nil, use the head timestamp
 	// to calculate the FcU version.
@@ -61,7 +69,9 @@ func (f *ForkConfig) ForkchoiceUpdatedVersion(headTimestamp uint64, payloadAttri
 }
 
 func (f *ForkConfig) NewPayloadVersion(timestamp uint64) int {
-	if f.IsCancun(timestamp) {
+	if f.IsPrague(timestamp) {
+		return 4
+	} else if f.IsCancun(timestamp) {
 		return 3
 	} else if f.IsShanghai(timestamp) {
 		return 2
@@ -70,7 +80,9 @@ func (f *ForkConfig) NewPayloadVersion(timestamp uint64) int {
 }
 
 func (f *ForkConfig) GetPayloadVersion(timestamp uint64) int {
-	if f.IsCancun(timestamp) {
+	if f.IsPrague(timestamp) {
+		return 4
+	} else if f.IsCancun(timestamp) {
 		return 3
 	} else if f.IsShanghai(timestamp) {
 		return 2
@@ -6,6 +6,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/hive/simulators/ethereum/engine/config/cancun"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config/prague"
 )
 
 func (f *ForkConfig) ConfigGenesis(genesis *core.Genesis) error {
@@ -27,6 +28,11 @@ func (f *ForkConfig) ConfigGenesis(genesis *core.Genesis) error {
 			return fmt.Errorf("failed to configure cancun fork: %v", err)
 		}
 	}
+	if f.PragueTimestamp != nil {
+		if err := prague.ConfigGenesis(genesis, f.PragueTimestamp.Uint64()); err != nil {
+			return fmt.Errorf("failed to configure prague fork: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,26 @@
+package prague
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// EIP 7685
+	DEPOSIT_REQUEST_TYPE       = byte(0x00)
+	WITHDRAWAL_REQUEST_TYPE    = byte(0x01)
+	CONSOLIDATION_REQUEST_TYPE = byte(0x02)
+
+	// EIP 7002
+	WITHDRAWAL_REQUEST_PREDEPLOY_ADDRESS = common.HexToAddress("0x00000961Ef480Eb55e80D19ad83579A64c007002")
+	WITHDRAWAL_REQUEST_INPUT_SIZE        = 56 // validator pubkey (48) + amount (8)
+	MAX_WITHDRAWAL_REQUESTS_PER_BLOCK    = 16
+
+	// EIP 7251
+	CONSOLIDATION_REQUEST_PREDEPLOY_ADDRESS = common.HexToAddress("0x0000BBdDc7CE488642fb579F8B00f3a590007251")
+	CONSOLIDATION_REQUEST_INPUT_SIZE        = 96 // source pubkey (48) + target pubkey (48)
+	MAX_CONSOLIDATION_REQUESTS_PER_BLOCK    = 2
+
+	// EIP 2935
+	HISTORY_STORAGE_ADDRESS = common.HexToAddress("0x0000F90827F1C53a10cb7A02335B175320002935")
+	HISTORY_SERVE_WINDOW    = uint64(8191)
+)
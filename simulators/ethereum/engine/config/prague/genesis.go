@@ -0,0 +1,45 @@
+package prague
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var (
+	// Runtime code of the EIP-2935 history storage contract.
+	historyStorageCode = common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe14604657602036036042575f35600143038111604257611fff81430311604257611fff9006545f5260205ff35b5f5ffd5b5f35611fff60014303065500")
+
+	// Runtime code of the EIP-7002 withdrawal request contract.
+	withdrawalRequestCode = common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe1460cb5760115f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff146101f457600182026001905f5b5f82111560685781019083028483029004916001019190604d565b909390049250505036603814608857366101f457346101f4575f5260205ff35b34106101f457600154600101600155600354806003026004013381556001015f35815560010160203590553360601b5f5260385f601437604c5fa0600101600355005b6003546002548082038060101160df575060105b5f5b8181146101835782810160030260040181604c02815460601b8152601401816001015481526020019060020154807fffffffffffffffffffffffffffffffff00000000000000000000000000000000168252906010019060401c908160381c81600701538160301c81600601538160281c81600501538160201c81600401538160181c81600301538160101c81600201538160081c81600101535360010160e1565b910180921461019557906002556101a0565b90505f6002555f6003555b5f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff14156101cd57505f5b6001546002828201116101e25750505f6101e8565b01600290035b5f555f600155604c025ff35b5f5ffd")
+
+	// Runtime code of the EIP-7251 consolidation request contract.
+	consolidationRequestCode = common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe1460d35760115f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff1461019a57600182026001905f5b5f82111560685781019083028483029004916001019190604d565b9093900492505050366060146088573661019a573461019a575f5260205ff35b341061019a57600154600101600155600354806004026004013381556001015f358155600101602035815560010160403590553360601b5f5260605f60143760745fa0600101600355005b6003546002548082038060021160e7575060025b5f5b8181146101295782810160040260040181607402815460601b815260140181600101548152602001816002015481526020019060030154905260010160e9565b910180921461013b5790600255610146565b90505f6002555f6003555b5f54807fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff141561017357505f5b6001546001828201116101885750505f61018e565b01600190035b5f555f6001556074025ff35b5f5ffd")
+)
+
+// ConfigGenesis configures the genesis block for the Prague fork.
+func ConfigGenesis(genesis *core.Genesis, forkTimestamp uint64) error {
+	if genesis.Config.CancunTime == nil {
+		return fmt.Errorf("prague fork requires cancun fork")
+	}
+	if *genesis.Config.CancunTime > forkTimestamp {
+		return fmt.Errorf("prague fork must be after cancun fork")
+	}
+	genesis.Config.PragueTime = &forkTimestamp
+
+	// Add the system contracts required by the fork.
+	for addr, code := range map[common.Address][]byte{
+		HISTORY_STORAGE_ADDRESS:                 historyStorageCode,
+		WITHDRAWAL_REQUEST_PREDEPLOY_ADDRESS:    withdrawalRequestCode,
+		CONSOLIDATION_REQUEST_PREDEPLOY_ADDRESS: consolidationRequestCode,
+	} {
+		genesis.Alloc[addr] = core.GenesisAccount{
+			Balance: common.Big0,
+			Nonce:   1,
+			Code:    code,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package prague
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var systemAddress = common.HexToAddress("0xfffffffffffffffffffffffffffffffffffffffe")
+
+func newRuntimeConfig(t *testing.T, code map[common.Address][]byte) *runtime.Config {
+	db, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, c := range code {
+		db.SetCode(addr, c)
+	}
+	return &runtime.Config{
+		State:       db,
+		ChainConfig: params.AllDevChainProtocolChanges,
+		BlockNumber: big.NewInt(10),
+		GasLimit:    30_000_000,
+	}
+}
+
+func TestHistoryStorageContract(t *testing.T) {
+	cfg := newRuntimeConfig(t, map[common.Address][]byte{HISTORY_STORAGE_ADDRESS: historyStorageCode})
+	parentHash := common.HexToHash("0x01020304")
+
+	// The system call stores the parent hash.
+	cfg.Origin = systemAddress
+	if _, _, err := runtime.Call(HISTORY_STORAGE_ADDRESS, parentHash[:], cfg); err != nil {
+		t.Fatal("system call failed:", err)
+	}
+
+	// Query in the next block.
+	cfg.Origin = common.Address{1}
+	cfg.BlockNumber = big.NewInt(11)
+	query := common.BigToHash(big.NewInt(9))
+	ret, _, err := runtime.Call(HISTORY_STORAGE_ADDRESS, query[:], cfg)
+	if err != nil {
+		t.Fatal("query failed:", err)
+	}
+	if !bytes.Equal(ret, parentHash[:]) {
+		t.Fatalf("wrong hash returned: %x", ret)
+	}
+	// Querying the current block must fail.
+	query = common.BigToHash(big.NewInt(11))
+	if _, _, err := runtime.Call(HISTORY_STORAGE_ADDRESS, query[:], cfg); err == nil {
+		t.Fatal("query for current block did not fail")
+	}
+}
+
+func TestRequestContracts(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      common.Address
+		code      []byte
+		inputSize int
+		maxCount  int
+	}{
+		{"withdrawal", WITHDRAWAL_REQUEST_PREDEPLOY_ADDRESS, withdrawalRequestCode, WITHDRAWAL_REQUEST_INPUT_SIZE, MAX_WITHDRAWAL_REQUESTS_PER_BLOCK},
+		{"consolidation", CONSOLIDATION_REQUEST_PREDEPLOY_ADDRESS, consolidationRequestCode, CONSOLIDATION_REQUEST_INPUT_SIZE, MAX_CONSOLIDATION_REQUESTS_PER_BLOCK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newRuntimeConfig(t, map[common.Address][]byte{test.addr: test.code})
+			sender := common.HexToAddress("0x1234")
+			cfg.State.SetBalance(sender, big.NewInt(params.Ether))
+
+			// Check the fee when the queue is empty.
+			cfg.Origin = sender
+			ret, _, err := runtime.Call(test.addr, nil, cfg)
+			if err != nil {
+				t.Fatal("fee query failed:", err)
+			}
+			if new(big.Int).SetBytes(ret).Cmp(common.Big1) != 0 {
+				t.Fatalf("wrong fee: %x", ret)
+			}
+
+			// Add one more request than can be dequeued in a block.
+			input := make([]byte, test.inputSize)
+			for i := range input {
+				input[i] = byte(i)
+			}
+			cfg.Value = common.Big1
+			for i := 0; i < test.maxCount+1; i++ {
+				if _, _, err := runtime.Call(test.addr, input, cfg); err != nil {
+					t.Fatal("adding request failed:", err)
+				}
+			}
+			// Adding a request without fee must fail.
+			cfg.Value = nil
+			if _, _, err := runtime.Call(test.addr, input, cfg); err == nil {
+				t.Fatal("request without fee accepted")
+			}
+
+			// Dequeue using system calls.
+			cfg.Origin = systemAddress
+			requestSize := common.AddressLength + test.inputSize
+			for _, count := range []int{test.maxCount, 1, 0} {
+				ret, _, err := runtime.Call(test.addr, nil, cfg)
+				if err != nil {
+					t.Fatal("system call failed:", err)
+				}
+				if len(ret) != count*requestSize {
+					t.Fatalf("wrong number of requests: got %d bytes, want %d requests", len(ret), count)
+				}
+				for i := 0; i < count; i++ {
+					req := ret[i*requestSize : (i+1)*requestSize]
+					if common.BytesToAddress(req[:common.AddressLength]) != sender {
+						t.Fatalf("wrong source address in request %d: %x", i, req)
+					}
+				}
+			}
+		})
+	}
+}
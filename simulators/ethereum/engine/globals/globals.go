@@ -56,6 +56,8 @@ var (
 	// JWT Authentication Related
 	DefaultJwtTokenSecretBytes = []byte("secretsecretsecretsecretsecretse") // secretsecretsecretsecretsecretse
 	MaxTimeDriftSeconds        = int64(60)
+	// File from which the clients read a custom secret
+	JwtSecretFile = "/jwtsecret"
 
 	// Accounts used for testing
 	TestAccountCount = uint64(1000)
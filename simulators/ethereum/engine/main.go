@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,8 +15,13 @@ import (
 	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
 	suite_auth "github.com/ethereum/hive/simulators/ethereum/engine/suites/auth"
 	suite_cancun "github.com/ethereum/hive/simulators/ethereum/engine/suites/cancun"
+	suite_differential "github.com/ethereum/hive/simulators/ethereum/engine/suites/differential"
 	suite_engine "github.com/ethereum/hive/simulators/ethereum/engine/suites/engine"
 	suite_excap "github.com/ethereum/hive/simulators/ethereum/engine/suites/exchange_capabilities"
+	suite_fuzz "github.com/ethereum/hive/simulators/ethereum/engine/suites/fuzz"
+	suite_perf "github.com/ethereum/hive/simulators/ethereum/engine/suites/perf"
+	suite_prague "github.com/ethereum/hive/simulators/ethereum/engine/suites/prague"
+	suite_scenario "github.com/ethereum/hive/simulators/ethereum/engine/suites/scenario"
 	suite_withdrawals "github.com/ethereum/hive/simulators/ethereum/engine/suites/withdrawals"
 	"github.com/ethereum/hive/simulators/ethereum/engine/test"
 )
@@ -52,6 +58,31 @@ var (
 		Description: `
 	Test Engine API on Cancun.`[1:],
 	}
+	prague = hivesim.Suite{
+		Name: "engine-prague",
+		Description: `
+	Test Engine API on Prague.`[1:],
+	}
+	scenarios = hivesim.Suite{
+		Name: "engine-scenarios",
+		Description: `
+	Test Engine API using the declarative scenarios in the scenarios directory.`[1:],
+	}
+	fuzz = hivesim.Suite{
+		Name: "engine-fuzz",
+		Description: `
+	Test Engine API with generated sequences of mutated payloads and forkchoice updates.`[1:],
+	}
+	differential = hivesim.Suite{
+		Name: "engine-differential",
+		Description: `
+	Test Engine API by executing the same payloads on all clients and comparing the results.`[1:],
+	}
+	perf = hivesim.Suite{
+		Name: "engine-perf",
+		Description: `
+	Measure Engine API latencies and block processing throughput under configurable transaction loads.`[1:],
+	}
 )
 
 func main() {
@@ -85,6 +116,44 @@ func main() {
 		Run:         makeRunner(suite_cancun.Tests, "full"),
 		AlwaysRun:   true,
 	})
+	prague.Add(hivesim.TestSpec{
+		Name:        "engine-prague test loader",
+		Description: "",
+		Run:         makeRunner(suite_prague.Tests, "full"),
+		AlwaysRun:   true,
+	})
+	scenarioTests, err := suite_scenario.LoadDir("./scenarios")
+	if err != nil {
+		panic(fmt.Sprintf("unable to load scenarios: %v", err))
+	}
+	scenarios.Add(hivesim.TestSpec{
+		Name:        "engine-scenarios test loader",
+		Description: "",
+		Run:         makeRunner(scenarioTests, "full"),
+		AlwaysRun:   true,
+	})
+	fuzzCases, err := suite_fuzz.LoadDir("./fuzz-cases")
+	if err != nil {
+		panic(fmt.Sprintf("unable to load fuzz cases: %v", err))
+	}
+	fuzz.Add(hivesim.TestSpec{
+		Name:        "engine-fuzz test loader",
+		Description: "",
+		Run:         makeRunner(append(suite_fuzz.Tests, suite_fuzz.ReplaySpecs(fuzzCases)...), "full"),
+		AlwaysRun:   true,
+	})
+	differential.Add(hivesim.TestSpec{
+		Name:        "engine-differential test loader",
+		Description: "",
+		Run:         makeRunner(suite_differential.Tests, "full"),
+		AlwaysRun:   true,
+	})
+	perf.Add(hivesim.TestSpec{
+		Name:        "engine-perf test loader",
+		Description: "",
+		Run:         makeRunner(suite_perf.Tests, "full"),
+		AlwaysRun:   true,
+	})
 	simulator := hivesim.New()
 
 	// Mark suites for execution
@@ -94,6 +163,23 @@ func main() {
 	// hivesim.MustRunSuite(simulator, syncSuite)
 	hivesim.MustRunSuite(simulator, withdrawals)
 	hivesim.MustRunSuite(simulator, cancun)
+	hivesim.MustRunSuite(simulator, prague)
+	hivesim.MustRunSuite(simulator, scenarios)
+
+	// Mark opt-in suites for execution
+	runOptInSuite(simulator, fuzz)
+	runOptInSuite(simulator, differential)
+	runOptInSuite(simulator, perf)
+}
+
+// runOptInSuite runs a suite which is not part of the default run. The suite only
+// runs when its name appears in the suite part of the test pattern.
+func runOptInSuite(sim *hivesim.Simulation, suite hivesim.Suite) {
+	suitePattern, _ := sim.TestPattern()
+	if !strings.Contains(strings.ToLower(suitePattern), suite.Name) {
+		return
+	}
+	hivesim.MustRunSuite(sim, suite)
 }
 
 func makeRunner(tests []test.Spec, nodeType string) func(t *hivesim.T) {
@@ -167,6 +253,9 @@ func makeRunner(tests []test.Spec, nodeType string) func(t *hivesim.T) {
 				if forkConfig.CancunTimestamp != nil {
 					newParams = newParams.Set("HIVE_CANCUN_TIMESTAMP", fmt.Sprintf("%d", forkConfig.CancunTimestamp))
 				}
+				if forkConfig.PragueTimestamp != nil {
+					newParams = newParams.Set("HIVE_PRAGUE_TIMESTAMP", fmt.Sprintf("%d", forkConfig.PragueTimestamp))
+				}
 			}
 
 			if nodeType != "" {
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is the difference of a metric between two runs of a test.
+type Change struct {
+	Key    string
+	Metric string
+	Old    float64
+	New    float64
+	// Whether the metric got worse by more than the threshold
+	Regression bool
+}
+
+// Relative returns the relative change of the metric.
+func (c Change) Relative() float64 {
+	if c.Old == 0 {
+		return 0
+	}
+	return (c.New - c.Old) / c.Old
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %.2f -> %.2f (%+.1f%%)", c.Key, c.Metric, c.Old, c.New, c.Relative()*100)
+}
+
+// Compare compares the reports of tests present in both runs. Latencies are
+// compared by their percentiles, where higher values are worse, and throughput
+// by the newPayload throughput, where lower values are worse. A change is a
+// regression if the metric got worse by more than the relative threshold.
+func Compare(old, new []*Report, threshold float64) []Change {
+	oldReports := make(map[string]*Report)
+	for _, r := range old {
+		oldReports[r.Key()] = r
+	}
+	var changes []Change
+	for _, n := range new {
+		o, ok := oldReports[n.Key()]
+		if !ok {
+			continue
+		}
+		add := func(metric string, oldValue, newValue float64, higherIsWorse bool) {
+			c := Change{Key: n.Key(), Metric: metric, Old: oldValue, New: newValue}
+			if higherIsWorse {
+				c.Regression = c.Relative() > threshold
+			} else {
+				c.Regression = -c.Relative() > threshold
+			}
+			changes = append(changes, c)
+		}
+		add("mgasPerSecond", o.MGasPerSecond, n.MGasPerSecond, false)
+		add("txPerSecond", o.TxPerSecond, n.TxPerSecond, false)
+		for _, ns := range n.Latencies {
+			prev, ok := o.Latency(ns.Method)
+			if !ok {
+				continue
+			}
+			add(ns.Method+" p50Ms", prev.P50, ns.P50, true)
+			add(ns.Method+" p95Ms", prev.P95, ns.P95, true)
+			add(ns.Method+" p99Ms", prev.P99, ns.P99, true)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
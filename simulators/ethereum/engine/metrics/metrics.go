@@ -0,0 +1,173 @@
+// Package metrics measures the latencies of the Engine and Eth API requests of a
+// client, and encodes performance reports which can be compared across runs.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder collects the latencies of requests by method.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+}
+
+// NewRecorder creates an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{latencies: make(map[string][]time.Duration)}
+}
+
+// Record adds the latency of a request.
+func (r *Recorder) Record(method string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[method] = append(r.latencies[method], d)
+}
+
+// Reset discards all recorded latencies.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = make(map[string][]time.Duration)
+}
+
+// Total returns the sum of the latencies of a method.
+func (r *Recorder) Total(method string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total time.Duration
+	for _, d := range r.latencies[method] {
+		total += d
+	}
+	return total
+}
+
+// Summaries returns the latency distribution of every recorded method, sorted
+// by method name.
+func (r *Recorder) Summaries() []Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summaries := make([]Summary, 0, len(r.latencies))
+	for method, latencies := range r.latencies {
+		summaries = append(summaries, Summarize(method, latencies))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Method < summaries[j].Method
+	})
+	return summaries
+}
+
+// Summary is the latency distribution of a method, in milliseconds.
+type Summary struct {
+	Method string  `json:"method"`
+	Count  int     `json:"count"`
+	Mean   float64 `json:"meanMs"`
+	P50    float64 `json:"p50Ms"`
+	P95    float64 `json:"p95Ms"`
+	P99    float64 `json:"p99Ms"`
+	Max    float64 `json:"maxMs"`
+}
+
+// Summarize calculates the distribution of a list of latencies. Percentiles use
+// the nearest-rank method.
+func Summarize(method string, latencies []time.Duration) Summary {
+	s := Summary{Method: method, Count: len(latencies)}
+	if len(latencies) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.Mean = milliseconds(total / time.Duration(len(sorted)))
+	s.P50 = milliseconds(percentile(sorted, 50))
+	s.P95 = milliseconds(percentile(sorted, 95))
+	s.P99 = milliseconds(percentile(sorted, 99))
+	s.Max = milliseconds(sorted[len(sorted)-1])
+	return s
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Transport is a http.RoundTripper which records the latency of every request
+// once a recorder has been set. The latency includes reading the response body.
+// Batches are recorded as "batch".
+type Transport struct {
+	Inner http.RoundTripper
+
+	mu  sync.Mutex
+	rec *Recorder
+}
+
+// SetRecorder sets the recorder of the transport. Recording stops when rec is nil.
+func (t *Transport) SetRecorder(rec *Recorder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rec = rec
+}
+
+func (t *Transport) recorder() *Recorder {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rec
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := t.recorder()
+	if rec == nil || req.Body == nil {
+		return t.Inner.RoundTrip(req)
+	}
+
+	reqBytes, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	reqCopy := *req
+	reqCopy.Body = io.NopCloser(bytes.NewReader(reqBytes))
+
+	start := time.Now()
+	resp, err := t.Inner.RoundTrip(&reqCopy)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	rec.Record(methodOf(reqBytes), time.Since(start))
+
+	respCopy := *resp
+	respCopy.Body = io.NopCloser(bytes.NewReader(respBytes))
+	return &respCopy, nil
+}
+
+func methodOf(body []byte) string {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return "batch"
+	}
+	return msg.Method
+}
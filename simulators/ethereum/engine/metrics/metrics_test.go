@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	s := Summarize("engine_newPayloadV3", latencies)
+	want := Summary{Method: "engine_newPayloadV3", Count: 100, Mean: 50.5, P50: 50, P95: 95, P99: 99, Max: 100}
+	if s != want {
+		t.Fatalf("wrong summary: %+v", s)
+	}
+	if latencies[0] != 100*time.Millisecond {
+		t.Fatal("latencies modified")
+	}
+	if s := Summarize("x", []time.Duration{time.Millisecond}); s.P50 != 1 || s.P99 != 1 {
+		t.Fatalf("wrong summary of single latency: %+v", s)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	tr := &Transport{Inner: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(string(body)))}, nil
+	})}
+	send := func(body string) string {
+		req, _ := http.NewRequest("POST", "http://localhost", strings.NewReader(body))
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`)
+	rec := NewRecorder()
+	tr.SetRecorder(rec)
+	if got := send(`{"jsonrpc":"2.0","id":2,"method":"engine_getPayloadV3"}`); !strings.Contains(got, "engine_getPayloadV3") {
+		t.Fatalf("wrong response body: %s", got)
+	}
+	send(`[{"jsonrpc":"2.0","id":3,"method":"eth_sendRawTransaction"}]`)
+
+	summaries := rec.Summaries()
+	if len(summaries) != 2 || summaries[0].Method != "batch" || summaries[1].Method != "engine_getPayloadV3" || summaries[1].Count != 1 {
+		t.Fatalf("wrong summaries: %+v", summaries)
+	}
+}
+
+func TestParseReports(t *testing.T) {
+	report := &Report{Test: "Transfers", Client: "go-ethereum", Fork: "Cancun", Blocks: 10, Latencies: []Summary{{Method: "engine_newPayloadV3", Count: 10, P50: 5}}}
+	line, err := report.LogLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := json.Marshal(report)
+	suite, _ := json.Marshal(map[string]interface{}{
+		"testCases": map[string]interface{}{
+			"1": map[string]interface{}{"summaryResult": map[string]interface{}{"details": "Start test\n" + line + "\nEnd test\n"}},
+		},
+	})
+	for name, data := range map[string]string{
+		"report": string(saved),
+		"suite":  string(suite),
+		"log":    "INFO: something\n" + line + "\n",
+	} {
+		reports, err := ParseReports([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(reports) != 1 || reports[0].Key() != "Cancun/Transfers (go-ethereum)" || reports[0].Latencies[0].P50 != 5 {
+			t.Fatalf("%s: wrong reports: %+v", name, reports)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := []*Report{{Test: "Transfers", Client: "geth", MGasPerSecond: 100, TxPerSecond: 1000, Latencies: []Summary{{Method: "engine_newPayloadV3", P50: 10, P95: 20, P99: 30}}}}
+	new := []*Report{
+		{Test: "Transfers", Client: "geth", MGasPerSecond: 80, TxPerSecond: 1050, Latencies: []Summary{{Method: "engine_newPayloadV3", P50: 10.5, P95: 25, P99: 15}}},
+		{Test: "Other", Client: "geth", MGasPerSecond: 1},
+	}
+	var regressions []string
+	changes := Compare(old, new, 0.1)
+	for _, c := range changes {
+		if c.Regression {
+			regressions = append(regressions, c.Metric)
+		}
+	}
+	if len(changes) != 5 {
+		t.Fatalf("wrong number of changes: %v", changes)
+	}
+	if strings.Join(regressions, ",") != "mgasPerSecond,engine_newPayloadV3 p95Ms" {
+		t.Fatalf("wrong regressions: %v", regressions)
+	}
+}
+
+func TestReadReports(t *testing.T) {
+	// Hive moves the test logs of a result file to the details log of the suite.
+	reports, err := ReadReports("testdata/results.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Key() != "Cancun/Transfers (go-ethereum)" || reports[0].Latencies[0].P50 != 5 {
+		t.Fatalf("wrong reports: %+v", reports)
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/results"
+)
+
+// LogPrefix marks the line of a test log which contains the report of the test.
+const LogPrefix = "ENGINE_PERF_METRICS "
+
+// Report contains the results of a performance test.
+type Report struct {
+	Test   string `json:"test"`
+	Client string `json:"client"`
+	Fork   string `json:"fork"`
+
+	// Measured blocks and their contents
+	Blocks       int    `json:"blocks"`
+	Transactions int    `json:"transactions"`
+	GasUsed      uint64 `json:"gasUsed"`
+
+	// Throughput of engine_newPayload, calculated from its total latency
+	MGasPerSecond float64 `json:"mgasPerSecond"`
+	TxPerSecond   float64 `json:"txPerSecond"`
+	// Blocks produced per second of wall time, including the transaction
+	// submission and the CL Mocker delays
+	BlocksPerSecond float64 `json:"blocksPerSecond"`
+
+	Latencies []Summary `json:"latencies"`
+}
+
+// Key identifies the test of a report across runs.
+func (r *Report) Key() string {
+	return fmt.Sprintf("%s/%s (%s)", r.Fork, r.Test, r.Client)
+}
+
+// Latency returns the latency summary of a method.
+func (r *Report) Latency(method string) (Summary, bool) {
+	for _, s := range r.Latencies {
+		if s.Method == method {
+			return s, true
+		}
+	}
+	return Summary{}, false
+}
+
+// LogLine encodes the report as a single test log line.
+func (r *Report) LogLine() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return LogPrefix + string(data), nil
+}
+
+// ParseReports extracts the reports from a file, which can be a single report in
+// JSON format, a hive suite result file, or a test log containing report lines.
+// Result files have to be read with ReadReports, unless they store the test logs
+// inline.
+func ParseReports(data []byte) ([]*Report, error) {
+	return parseReports(data, "")
+}
+
+// ReadReports reads the reports of a file. The test logs of a hive suite result
+// file are read from the details log of the suite.
+func ReadReports(file string) ([]*Report, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseReports(data, filepath.Dir(file))
+}
+
+func parseReports(data []byte, dir string) ([]*Report, error) {
+	var report Report
+	if json.Unmarshal(data, &report) == nil && report.Test != "" {
+		return []*Report{&report}, nil
+	}
+	logs, ok, err := results.TestLogs(data, dir)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		data = []byte(strings.Join(logs, "\n"))
+	}
+
+	var reports []*Report
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.Index(line, LogPrefix)
+		if i < 0 {
+			continue
+		}
+		r := new(Report)
+		if err := json.Unmarshal([]byte(line[i+len(LogPrefix):]), r); err != nil {
+			return nil, fmt.Errorf("invalid report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, scanner.Err()
+}
@@ -0,0 +1,84 @@
+// Package results reads the test logs stored in hive suite result files.
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNoDir is returned when the logs of a suite are stored in its test details
+// log, but the directory of the result file is unknown.
+var ErrNoDir = errors.New("test logs are stored in the details log of the suite, the result file must be read from disk")
+
+type suite struct {
+	TestCases      map[string]testCase `json:"testCases"`
+	TestDetailsLog string              `json:"testDetailsLog"`
+}
+
+type testCase struct {
+	SummaryResult struct {
+		Details    string `json:"details"`
+		LogOffsets *struct {
+			Begin int64 `json:"begin"`
+			End   int64 `json:"end"`
+		} `json:"log"`
+	} `json:"summaryResult"`
+}
+
+// TestLogs returns the logs of the test cases in a hive suite result file, and
+// whether the data is a result file at all. Hive moves the logs of finished tests
+// to the test details log of the suite, which is resolved relative to dir, the
+// directory containing the result file.
+func TestLogs(data []byte, dir string) ([]string, bool, error) {
+	var s suite
+	if json.Unmarshal(data, &s) != nil || s.TestCases == nil {
+		return nil, false, nil
+	}
+	cases := make([]testCase, 0, len(s.TestCases))
+	for _, tc := range s.TestCases {
+		cases = append(cases, tc)
+	}
+	// Return the logs in the order they were written.
+	sort.SliceStable(cases, func(i, j int) bool {
+		return cases[i].begin() < cases[j].begin()
+	})
+
+	var (
+		logs    = make([]string, 0, len(cases))
+		details *os.File
+	)
+	for _, tc := range cases {
+		offsets := tc.SummaryResult.LogOffsets
+		if offsets == nil {
+			logs = append(logs, tc.SummaryResult.Details)
+			continue
+		}
+		if details == nil {
+			if dir == "" || s.TestDetailsLog == "" {
+				return nil, true, ErrNoDir
+			}
+			f, err := os.Open(filepath.Join(dir, filepath.FromSlash(s.TestDetailsLog)))
+			if err != nil {
+				return nil, true, err
+			}
+			defer f.Close()
+			details = f
+		}
+		log := make([]byte, offsets.End-offsets.Begin)
+		if n, err := details.ReadAt(log, offsets.Begin); n < len(log) {
+			return nil, true, err
+		}
+		logs = append(logs, string(log))
+	}
+	return logs, true, nil
+}
+
+func (tc testCase) begin() int64 {
+	if tc.SummaryResult.LogOffsets == nil {
+		return 0
+	}
+	return tc.SummaryResult.LogOffsets.Begin
+}
@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvStrict is the environment variable which enables strict mode. When set to a
+// non-empty value, all responses of the clients are validated against the
+// specification and violations fail the test. The simulator sets it from the
+// strict_schema build argument.
+const EnvStrict = "HIVE_ENGINE_STRICT_SCHEMA"
+
+// Checker validates responses and collects the violations.
+type Checker struct {
+	spec *Spec
+
+	mu         sync.Mutex
+	violations []Violation
+	checked    map[string]int
+}
+
+// NewChecker creates a checker for the given specification.
+func NewChecker(spec *Spec) *Checker {
+	return &Checker{spec: spec, checked: make(map[string]int)}
+}
+
+// Check validates the response to a call of the given method.
+func (c *Checker) Check(method string, response []byte) []Violation {
+	v := c.spec.ValidateResponse(method, response)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked[method]++
+	c.violations = append(c.violations, v...)
+	return v
+}
+
+// CheckExchange validates the responses of a JSON-RPC exchange, which can be a
+// single call or a batch.
+func (c *Checker) CheckExchange(request, response []byte) []Violation {
+	var (
+		reqs  []json.RawMessage
+		resps []json.RawMessage
+	)
+	if json.Unmarshal(request, &reqs) != nil {
+		reqs = []json.RawMessage{request}
+		resps = []json.RawMessage{response}
+	} else if err := json.Unmarshal(response, &resps); err != nil {
+		return c.Check(methodOf(reqs[0]), response)
+	}
+
+	methods := make(map[string]string, len(reqs))
+	for _, r := range reqs {
+		methods[idOf(r)] = methodOf(r)
+	}
+	var violations []Violation
+	for _, r := range resps {
+		violations = append(violations, c.Check(methods[idOf(r)], r)...)
+	}
+	return violations
+}
+
+// Violations returns all violations found so far.
+func (c *Checker) Violations() []Violation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Violation(nil), c.violations...)
+}
+
+// Summary returns a report of the number of checked responses and violations per
+// method.
+func (c *Checker) Summary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failed := make(map[string]int)
+	for _, v := range c.violations {
+		failed[v.Method]++
+	}
+	methods := make([]string, 0, len(c.checked))
+	for m := range c.checked {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schema violations: %d\n", len(c.violations))
+	for _, m := range methods {
+		fmt.Fprintf(&b, "  %-40s responses: %4d  violations: %d\n", m, c.checked[m], failed[m])
+	}
+	return b.String()
+}
+
+func methodOf(msg []byte) string {
+	var m struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(msg, &m)
+	return m.Method
+}
+
+func idOf(msg []byte) string {
+	var m struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(msg, &m)
+	return string(m.ID)
+}
+
+// Transport is a http.RoundTripper which validates all responses once a checker
+// has been set.
+type Transport struct {
+	Inner http.RoundTripper
+
+	mu      sync.Mutex
+	checker *Checker
+}
+
+// SetChecker sets the checker of the transport. Validation stops when c is nil.
+func (t *Transport) SetChecker(c *Checker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.checker = c
+}
+
+func (t *Transport) getChecker() *Checker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.checker
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	checker := t.getChecker()
+	if checker == nil || req.Body == nil {
+		return t.Inner.RoundTrip(req)
+	}
+
+	reqBytes, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	reqCopy := *req
+	reqCopy.Body = io.NopCloser(bytes.NewReader(reqBytes))
+
+	resp, err := t.Inner.RoundTrip(&reqCopy)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	respCopy := *resp
+	respCopy.Body = io.NopCloser(bytes.NewReader(respBytes))
+
+	// Only responses with a JSON body are checked, HTTP errors such as failed
+	// authentication are handled by the RPC client.
+	if resp.StatusCode == http.StatusOK {
+		checker.CheckExchange(reqBytes, respBytes)
+	}
+	return &respCopy, nil
+}
@@ -0,0 +1,505 @@
+// Package schema validates JSON-RPC responses against the execution-apis OpenRPC
+// specification of the Engine and Eth APIs.
+//
+// openrpc.json is generated from the YAML sources in the execution-apis repository,
+// see README.md.
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed openrpc.json
+var openrpcJSON []byte
+
+// Violation is a response which doesn't conform to the specification.
+type Violation struct {
+	Method string
+	// Path is the JSON path of the offending value, e.g. $.result.payloadStatus.status
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Method, v.Path, v.Message)
+}
+
+// Spec holds the result schemas of all methods.
+type Spec struct {
+	results map[string]interface{}
+	schemas map[string]interface{}
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+
+	// Strict reports properties which are not defined by the schema of an object.
+	Strict bool
+}
+
+var (
+	defaultSpec     *Spec
+	defaultSpecOnce sync.Once
+)
+
+// Default returns the vendored specification, in strict mode.
+func Default() *Spec {
+	defaultSpecOnce.Do(func() {
+		s, err := Parse(openrpcJSON)
+		if err != nil {
+			panic(fmt.Sprintf("invalid vendored openrpc.json: %v", err))
+		}
+		s.Strict = true
+		defaultSpec = s
+	})
+	return defaultSpec
+}
+
+// Parse reads an OpenRPC document.
+func Parse(doc []byte) (*Spec, error) {
+	var d struct {
+		Methods []struct {
+			Name   string `json:"name"`
+			Result struct {
+				Schema interface{} `json:"schema"`
+			} `json:"result"`
+		} `json:"methods"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	s := &Spec{
+		results:  make(map[string]interface{}, len(d.Methods)),
+		schemas:  d.Components.Schemas,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	for _, m := range d.Methods {
+		s.results[m.Name] = m.Result.Schema
+	}
+	return s, nil
+}
+
+// HasMethod reports whether the specification defines the method.
+func (s *Spec) HasMethod(method string) bool {
+	_, ok := s.results[method]
+	return ok
+}
+
+// ValidateResponse checks a JSON-RPC response to a call of the given method.
+// Responses of unknown methods are only checked for the JSON-RPC envelope.
+func (s *Spec) ValidateResponse(method string, response []byte) []Violation {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(response, &msg); err != nil {
+		return []Violation{{Method: method, Path: "$", Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	c := &checker{spec: s, method: method}
+	if msg["jsonrpc"] != "2.0" {
+		c.fail("$.jsonrpc", "expected \"2.0\", got %v", msg["jsonrpc"])
+	}
+	if _, ok := msg["id"]; !ok {
+		c.fail("$", "missing id")
+	}
+	result, hasResult := msg["result"]
+	errObj, hasError := msg["error"]
+	switch {
+	case hasResult && hasError:
+		c.fail("$", "response contains both result and error")
+	case !hasResult && !hasError:
+		c.fail("$", "response contains neither result nor error")
+	case hasError:
+		c.checkError(errObj)
+	default:
+		if schema, ok := s.results[method]; ok {
+			c.validate(result, schema, "$.result", true)
+		}
+	}
+	return dedup(c.violations)
+}
+
+// dedup removes repeated violations, which are reported when a value is checked by
+// a schema and the schema it refines.
+func dedup(violations []Violation) []Violation {
+	var (
+		seen   = make(map[Violation]bool)
+		result []Violation
+	)
+	for _, v := range violations {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+type checker struct {
+	spec       *Spec
+	method     string
+	violations []Violation
+}
+
+func (c *checker) fail(path, format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{Method: c.method, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) checkError(v interface{}) {
+	e, ok := v.(map[string]interface{})
+	if !ok {
+		c.fail("$.error", "expected object, got %s", typeName(v))
+		return
+	}
+	if code, ok := e["code"].(float64); !ok || code != float64(int64(code)) {
+		c.fail("$.error.code", "expected integer, got %v", e["code"])
+	}
+	if _, ok := e["message"].(string); !ok {
+		c.fail("$.error.message", "expected string, got %v", e["message"])
+	}
+}
+
+// sub returns a checker collecting violations separately, for evaluating
+// alternatives.
+func (c *checker) sub() *checker {
+	return &checker{spec: c.spec, method: c.method}
+}
+
+// validate checks value v against a schema. The entry flag is set when the schema
+// is the outermost one applied to the value, which is where undefined properties
+// are reported in strict mode.
+func (c *checker) validate(v interface{}, schema interface{}, path string, entry bool) {
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			c.fail(path, "value not allowed")
+		}
+		return
+	case map[string]interface{}:
+		c.validateObject(v, schema, path, entry)
+	}
+}
+
+func (c *checker) validateObject(v interface{}, schema map[string]interface{}, path string, entry bool) {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := c.spec.resolve(ref)
+		if err != nil {
+			c.fail(path, "%v", err)
+			return
+		}
+		c.validate(v, target, path, false)
+	}
+	if t, ok := schema["type"]; ok && !matchesType(v, t) {
+		c.fail(path, "expected %v, got %s", t, typeName(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, v) {
+		c.fail(path, "value %v not in %v", v, enum)
+	}
+	if cv, ok := schema["const"]; ok && !reflect.DeepEqual(cv, v) {
+		c.fail(path, "expected %v, got %v", cv, v)
+	}
+	if p, ok := schema["pattern"].(string); ok {
+		if str, ok := v.(string); ok {
+			re, err := c.spec.pattern(p)
+			if err != nil {
+				c.fail(path, "invalid pattern in schema: %v", err)
+			} else if !re.MatchString(str) {
+				c.fail(path, "value %q does not match %s", str, p)
+			}
+		}
+	}
+	if n, ok := v.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			c.fail(path, "value %v less than minimum %v", n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			c.fail(path, "value %v greater than maximum %v", n, max)
+		}
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range all {
+			c.validate(v, s, path, false)
+		}
+	}
+	// oneOf is evaluated like anyOf, since many alternatives in the specification
+	// (e.g. transaction types) are not mutually exclusive.
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts, ok := schema[key].([]interface{}); ok {
+			c.validateAlternatives(v, alts, path)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		sub := c.sub()
+		sub.validate(v, not, path, false)
+		if len(sub.violations) == 0 {
+			c.fail(path, "value matches excluded schema")
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c.validateProperties(v, schema, path, entry)
+	case []interface{}:
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				c.validate(item, items, fmt.Sprintf("%s[%d]", path, i), true)
+			}
+		}
+	}
+}
+
+// validateAlternatives checks that v matches at least one of the schemas. When none
+// matches, the violations of the closest alternative are reported.
+func (c *checker) validateAlternatives(v interface{}, alts []interface{}, path string) {
+	var best []Violation
+	for i, alt := range alts {
+		sub := c.sub()
+		sub.validate(v, alt, path, false)
+		if len(sub.violations) == 0 {
+			return
+		}
+		if i == 0 || len(sub.violations) < len(best) {
+			best = sub.violations
+		}
+	}
+	c.fail(path, "value does not match any of %d alternatives", len(alts))
+	c.violations = append(c.violations, best...)
+}
+
+func (c *checker) validateProperties(obj map[string]interface{}, schema map[string]interface{}, path string, entry bool) {
+	if req, ok := schema["required"].([]interface{}); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				c.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	for name, value := range obj {
+		// The specification doesn't mark optional properties as nullable, but
+		// the Engine API uses null for absent values, e.g. latestValidHash.
+		if value == nil && !isRequired(schema, name) {
+			continue
+		}
+		if ps, ok := props[name]; ok {
+			c.validate(value, ps, path+"."+name, true)
+		}
+	}
+	if pp, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		for pattern, ps := range pp {
+			re, err := c.spec.pattern(pattern)
+			if err != nil {
+				continue
+			}
+			for name, value := range obj {
+				if re.MatchString(name) {
+					c.validate(value, ps, path+"."+name, true)
+				}
+			}
+		}
+	}
+	if ap, ok := schema["additionalProperties"]; ok {
+		for name, value := range obj {
+			if _, ok := props[name]; ok {
+				continue
+			}
+			if ap == false {
+				c.fail(path, "property %q not allowed", name)
+			} else {
+				c.validate(value, ap, path+"."+name, true)
+			}
+		}
+	}
+
+	if entry && c.spec.Strict {
+		known, open := c.spec.knownProperties(schema, obj, make(map[string]bool))
+		if open || len(known) == 0 {
+			return
+		}
+		var extra []string
+		for name := range obj {
+			if !known[name] {
+				extra = append(extra, name)
+			}
+		}
+		sort.Strings(extra)
+		for _, name := range extra {
+			c.fail(path, "property %q is not defined by the specification", name)
+		}
+	}
+}
+
+// knownProperties collects the properties defined for an object by a schema and
+// all schemas it references. open is true when the schema allows arbitrary
+// properties.
+func (s *Spec) knownProperties(schema interface{}, obj map[string]interface{}, visited map[string]bool) (known map[string]bool, open bool) {
+	known = make(map[string]bool)
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return known, schema == true
+	}
+	if ap, ok := m["additionalProperties"]; ok && ap != false {
+		open = true
+	}
+	if _, ok := m["patternProperties"]; ok {
+		open = true
+	}
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			known[name] = true
+		}
+	}
+	merge := func(sub interface{}) {
+		k, o := s.knownProperties(sub, obj, visited)
+		for name := range k {
+			known[name] = true
+		}
+		open = open || o
+	}
+	if ref, ok := m["$ref"].(string); ok && !visited[ref] {
+		visited[ref] = true
+		if target, err := s.resolve(ref); err == nil {
+			merge(target)
+		}
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		if alts, ok := m[key].([]interface{}); ok {
+			for _, alt := range alts {
+				merge(alt)
+			}
+		}
+	}
+	return known, open
+}
+
+// resolve returns the schema referenced by a local JSON pointer.
+func (s *Spec) resolve(ref string) (interface{}, error) {
+	const prefix = "#/components/schemas/"
+	if !strings.HasPrefix(ref, prefix) {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	parts := strings.Split(ref[len(prefix):], "/")
+	var cur interface{} = s.schemas
+	for _, p := range parts {
+		p = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			next, ok := node[p]
+			if !ok {
+				return nil, fmt.Errorf("unknown schema reference %q", ref)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("unknown schema reference %q", ref)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("unknown schema reference %q", ref)
+		}
+	}
+	return cur, nil
+}
+
+func (s *Spec) pattern(p string) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if re, ok := s.patterns[p]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[p] = re
+	return re, nil
+}
+
+func isRequired(schema map[string]interface{}, name string) bool {
+	req, _ := schema["required"].([]interface{})
+	for _, r := range req {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(v interface{}, t interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(v, t)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(v, s) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(v interface{}, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && new(big.Float).SetFloat64(n).IsInt()
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, x := range list {
+		if reflect.DeepEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
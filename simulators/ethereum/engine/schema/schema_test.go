@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestVendoredSpec checks that all references and patterns of the vendored
+// specification can be used by the validator.
+func TestVendoredSpec(t *testing.T) {
+	s := Default()
+	for _, m := range []string{"engine_newPayloadV3", "engine_forkchoiceUpdatedV3", "engine_getPayloadV3", "eth_getBlockByNumber"} {
+		if !s.HasMethod(m) {
+			t.Errorf("method %s missing from specification", m)
+		}
+	}
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := node["$ref"].(string); ok {
+				if _, err := s.resolve(ref); err != nil {
+					t.Error(err)
+				}
+			}
+			if p, ok := node["pattern"].(string); ok {
+				if _, err := regexp.Compile(p); err != nil {
+					t.Errorf("pattern %s: %v", p, err)
+				}
+			}
+			for _, v := range node {
+				walk(v)
+			}
+		case []interface{}:
+			for _, v := range node {
+				walk(v)
+			}
+		}
+	}
+	walk(s.schemas)
+	walk(s.results)
+}
+
+func TestValidateResponse(t *testing.T) {
+	const (
+		hash = "0x3559e851470f6e7bbed1db474980683e8c315bfce99b2a6ef47c057c04de7858"
+	)
+	tests := []struct {
+		method   string
+		response string
+		paths    []string
+	}{
+		{
+			method:   "engine_newPayloadV3",
+			response: `{"jsonrpc":"2.0","id":1,"result":{"status":"VALID","latestValidHash":"` + hash + `","validationError":null}}`,
+		},
+		{
+			method:   "engine_newPayloadV3",
+			response: `{"jsonrpc":"2.0","id":1,"error":{"code":-38005,"message":"Unsupported fork"}}`,
+		},
+		{
+			// INVALID_BLOCK_HASH is not allowed since V2.
+			method:   "engine_newPayloadV3",
+			response: `{"jsonrpc":"2.0","id":1,"result":{"status":"INVALID_BLOCK_HASH","latestValidHash":null,"validationError":null}}`,
+			paths:    []string{"$.result.status"},
+		},
+		{
+			// Uppercase hex and missing required property.
+			method:   "engine_newPayloadV3",
+			response: `{"jsonrpc":"2.0","id":1,"result":{"latestValidHash":"` + strings.ToUpper(hash) + `"}}`,
+			paths:    []string{"$.result", "$.result.latestValidHash"},
+		},
+		{
+			// Property not defined by the specification.
+			method:   "engine_forkchoiceUpdatedV3",
+			response: `{"jsonrpc":"2.0","id":1,"result":{"payloadStatus":{"status":"VALID","latestValidHash":"` + hash + `","validationError":null,"extra":1},"payloadId":null}}`,
+			paths:    []string{"$.result.payloadStatus"},
+		},
+		{
+			// Leading zeros in quantities.
+			method:   "eth_blockNumber",
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x01"}`,
+			paths:    []string{"$.result"},
+		},
+		{
+			method:   "eth_blockNumber",
+			response: `{"jsonrpc":"1.0","result":"0x1"}`,
+			paths:    []string{"$.jsonrpc", "$"},
+		},
+	}
+	for i, test := range tests {
+		violations := Default().ValidateResponse(test.method, []byte(test.response))
+		paths := make(map[string]bool)
+		for _, v := range violations {
+			paths[v.Path] = true
+		}
+		if len(test.paths) == 0 && len(violations) > 0 {
+			t.Errorf("test %d: unexpected violations: %v", i, violations)
+		}
+		for _, p := range test.paths {
+			if !paths[p] {
+				t.Errorf("test %d: missing violation at %s, got %v", i, p, violations)
+			}
+		}
+	}
+}
+
+func TestCheckerBatch(t *testing.T) {
+	c := NewChecker(Default())
+	req, _ := json.Marshal([]map[string]interface{}{
+		{"jsonrpc": "2.0", "id": 1, "method": "eth_blockNumber"},
+		{"jsonrpc": "2.0", "id": 2, "method": "eth_chainId"},
+	})
+	resp := []byte(`[{"jsonrpc":"2.0","id":2,"result":"0x1"},{"jsonrpc":"2.0","id":1,"result":"1"}]`)
+	violations := c.CheckExchange(req, resp)
+	if len(violations) != 1 || violations[0].Method != "eth_blockNumber" {
+		t.Fatalf("wrong violations: %v", violations)
+	}
+	if summary := c.Summary(); !strings.Contains(summary, "eth_chainId") {
+		t.Fatalf("summary does not list checked method:\n%s", summary)
+	}
+}
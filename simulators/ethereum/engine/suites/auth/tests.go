@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/hive/simulators/ethereum/engine/config"
 	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
 	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // JWT Authentication Tests
@@ -75,6 +76,86 @@ var Tests = []test.Spec{
 		AuthOk:                true,
 		RetryAttempts:         5,
 	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Positive time drift, at limit, correct secret",
+		},
+		// The drift decreases until the client checks the token, so it is within
+		// the limit.
+		Token: hs256Token(func(now time.Time) jwt.MapClaims {
+			return jwt.MapClaims{"iat": now.Unix() + globals.MaxTimeDriftSeconds}
+		}),
+		AuthOk:        true,
+		RetryAttempts: 5,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Issued one day ago, correct secret",
+		},
+		Token: hs256Token(func(now time.Time) jwt.MapClaims {
+			return jwt.MapClaims{"iat": now.Add(-24 * time.Hour).Unix()}
+		}),
+		AuthOk: false,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Missing iat claim, correct secret",
+		},
+		Token: hs256Token(func(now time.Time) jwt.MapClaims {
+			return jwt.MapClaims{}
+		}),
+		AuthOk: false,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Optional id and clv claims, correct secret",
+		},
+		Token: hs256Token(func(now time.Time) jwt.MapClaims {
+			return jwt.MapClaims{"iat": now.Unix(), "id": "hive-cl", "clv": "Hive/v1.0.0"}
+		}),
+		AuthOk: true,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Unknown extra claim, correct secret",
+		},
+		Token: hs256Token(func(now time.Time) jwt.MapClaims {
+			return jwt.MapClaims{"iat": now.Unix(), "hive": true}
+		}),
+		AuthOk: true,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: None algorithm",
+		},
+		Token:  noneAlgToken,
+		AuthOk: false,
+	},
+	TokenTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Invalid signature",
+		},
+		Token:  invalidSignatureToken,
+		AuthOk: false,
+	},
+	TokenReuseTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Token reuse within time drift limit",
+		},
+		Delay: 2 * time.Second,
+	},
+	TokenReuseTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name:           "JWT Authentication: Token reuse after time drift limit",
+			TimeoutSeconds: 120,
+		},
+		Delay: time.Duration(globals.MaxTimeDriftSeconds+5) * time.Second,
+	},
+	ClientSecretTestSpec{
+		BaseSpec: test.BaseSpec{
+			Name: "JWT Authentication: Client with random secret",
+		},
+	},
 }
 
 type AuthTestSpec struct {
@@ -0,0 +1,187 @@
+package suite_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Token creates an authentication token from the secret of the client.
+type Token func(secret []byte, now time.Time) (string, error)
+
+// hs256Token signs the claims with the secret using HS256.
+func hs256Token(claims func(now time.Time) jwt.MapClaims) Token {
+	return func(secret []byte, now time.Time) (string, error) {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims(now)).SignedString(secret)
+	}
+}
+
+// iatClaims contains only the required issued-at claim.
+func iatClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{"iat": now.Unix()}
+}
+
+// noneAlgToken creates an unsigned token using the "none" algorithm.
+func noneAlgToken(_ []byte, now time.Time) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodNone, iatClaims(now)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+}
+
+// invalidSignatureToken creates a token whose signature is modified.
+func invalidSignatureToken(secret []byte, now time.Time) (string, error) {
+	token, err := hs256Token(iatClaims)(secret, now)
+	if err != nil {
+		return "", err
+	}
+	// Modify the first character of the signature, since the last one also
+	// contains padding bits.
+	i := strings.LastIndex(token, ".") + 1
+	c := byte('A')
+	if token[i] == c {
+		c = 'B'
+	}
+	return token[:i] + string(c) + token[i+1:], nil
+}
+
+// authCheck sends a request authenticated with the token, and reports an error if
+// the authentication result is not as expected.
+func authCheck(ctx context.Context, ec *hive_rpc.HiveRPCEngineClient, token string, authOk bool) error {
+	ctx, cancel := context.WithTimeout(ctx, globals.RPCTimeout)
+	defer cancel()
+	var result []string
+	err := ec.CallWithAuthToken(ctx, token, &result, "engine_exchangeCapabilities", []string{})
+	switch {
+	case authOk && err != nil:
+		return fmt.Errorf("authentication was supposed to pass but failed: %v", err)
+	case !authOk && err == nil:
+		return errors.New("authentication was supposed to fail but passed")
+	case !authOk && !authRejected(err):
+		return fmt.Errorf("authentication was supposed to fail with status 401 or 403, got: %v", err)
+	}
+	return nil
+}
+
+// authRejected reports whether the client rejected the authentication of a request.
+func authRejected(err error) bool {
+	var httpErr rpc.HTTPError
+	return errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden)
+}
+
+// TokenTestSpec sends a request authenticated with a custom token.
+type TokenTestSpec struct {
+	test.BaseSpec
+	Token  Token
+	AuthOk bool
+	// Time drift test cases are reattempted in order to mitigate false negatives
+	RetryAttempts int64
+}
+
+func (s TokenTestSpec) WithMainFork(fork config.Fork) test.Spec {
+	specCopy := s
+	specCopy.MainFork = fork
+	return specCopy
+}
+
+func (s TokenTestSpec) Execute(t *test.Env) {
+	retryAttemptsLeft := s.RetryAttempts
+	for {
+		token, err := s.Token(globals.DefaultJwtTokenSecretBytes, time.Now())
+		if err != nil {
+			t.Fatalf("FAIL (%s): Unable to create the auth token: %v", t.TestName, err)
+		}
+		err = authCheck(t.TestContext, t.HiveEngine, token, s.AuthOk)
+		if err == nil {
+			return
+		}
+		if retryAttemptsLeft == 0 {
+			t.Fatalf("FAIL (%s): %v", t.TestName, err)
+		}
+		retryAttemptsLeft--
+		time.Sleep(time.Second)
+	}
+}
+
+// TokenReuseTestSpec sends two requests with the same token. The second request
+// is sent after the given delay, and is expected to fail once the issue time of the
+// token exceeds the allowed time drift.
+type TokenReuseTestSpec struct {
+	test.BaseSpec
+	Delay time.Duration
+}
+
+func (s TokenReuseTestSpec) WithMainFork(fork config.Fork) test.Spec {
+	specCopy := s
+	specCopy.MainFork = fork
+	return specCopy
+}
+
+func (s TokenReuseTestSpec) Execute(t *test.Env) {
+	token, err := hs256Token(iatClaims)(globals.DefaultJwtTokenSecretBytes, time.Now())
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to create the auth token: %v", t.TestName, err)
+	}
+	if err := authCheck(t.TestContext, t.HiveEngine, token, true); err != nil {
+		t.Fatalf("FAIL (%s): First request: %v", t.TestName, err)
+	}
+	time.Sleep(s.Delay)
+	authOk := s.Delay < time.Duration(globals.MaxTimeDriftSeconds)*time.Second
+	if err := authCheck(t.TestContext, t.HiveEngine, token, authOk); err != nil {
+		t.Fatalf("FAIL (%s): Reused token after %v: %v", t.TestName, s.Delay, err)
+	}
+}
+
+// ClientSecretTestSpec starts a client configured with a random secret, and checks
+// that it only accepts tokens signed with that secret.
+type ClientSecretTestSpec struct {
+	test.BaseSpec
+}
+
+func (s ClientSecretTestSpec) WithMainFork(fork config.Fork) test.Spec {
+	specCopy := s
+	specCopy.MainFork = fork
+	return specCopy
+}
+
+func (s ClientSecretTestSpec) Execute(t *test.Env) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("FAIL (%s): Unable to generate secret: %v", t.TestName, err)
+	}
+	c, err := hive_rpc.HiveRPCEngineStarter{JWTSecret: secret}.StartClient(t.T, t.TestContext, t.Genesis, t.ClientParams, t.ClientFiles)
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to start client with custom secret: %v", t.TestName, err)
+	}
+	defer c.Close()
+	ec := c.(*hive_rpc.HiveRPCEngineClient)
+
+	now := time.Now()
+	for _, check := range []struct {
+		name   string
+		client *hive_rpc.HiveRPCEngineClient
+		secret []byte
+		authOk bool
+	}{
+		{"custom secret on custom client", ec, secret, true},
+		{"default secret on custom client", ec, globals.DefaultJwtTokenSecretBytes, false},
+		{"custom secret on default client", t.HiveEngine, secret, false},
+		{"default secret on default client", t.HiveEngine, globals.DefaultJwtTokenSecretBytes, true},
+	} {
+		token, err := hs256Token(iatClaims)(check.secret, now)
+		if err != nil {
+			t.Fatalf("FAIL (%s): Unable to create the auth token: %v", t.TestName, err)
+		}
+		if err := authCheck(t.TestContext, check.client, token, check.authOk); err != nil {
+			t.Fatalf("FAIL (%s): %s: %v", t.TestName, check.name, err)
+		}
+	}
+}
@@ -30,11 +30,5 @@ func (cs *CancunBaseSpec) Execute(t *test.Env) {
 		t.CLMock.PayloadProductionClientDelay = time.Duration(cs.GetPayloadDelay) * time.Second
 	}
 
-	for stepId, step := range cs.TestSequence {
-		t.Logf("INFO: Executing step %d: %s", stepId+1, step.Description())
-		if err := step.Execute(blobTestCtx); err != nil {
-			t.Fatalf("FAIL: Error executing step %d: %v", stepId+1, err)
-		}
-	}
-
+	cs.TestSequence.Run(t, blobTestCtx)
 }
@@ -29,14 +29,10 @@ type CancunTestContext struct {
 	*TestBlobTxPool
 }
 
-// Interface to represent a single step in a test vector
-type TestStep interface {
-	// Executes the step
-	Execute(testCtx *CancunTestContext) error
-	Description() string
-}
+// A single step in a test vector
+type TestStep = test.Step[*CancunTestContext]
 
-type TestSequence []TestStep
+type TestSequence = test.Sequence[*CancunTestContext]
 
 // A step that runs two or more steps in parallel
 type ParallelSteps struct {
@@ -753,6 +749,328 @@ func (step SendModifiedLatestPayload) Description() string {
 	return desc
 }
 
+// A step that sends a transaction with the nonce of the last blob transaction of an
+// account, and expects the client to reject the replacement
+type SendRejectedReplacementTransaction struct {
+	// Blobs of the replacement, a transaction without blobs is sent if zero
+	BlobCount uint64
+	// Max Data Gas Cost of the replacement
+	BlobTransactionMaxBlobGasCost *big.Int
+	// Gas Fee Cap of the replacement
+	BlobTransactionGasFeeCap *big.Int
+	// Gas Tip Cap of the replacement
+	BlobTransactionGasTipCap *big.Int
+	// Account index of the replaced transaction
+	AccountIndex uint64
+	// Client index to send the replacement to
+	ClientIndex uint64
+}
+
+func (step SendRejectedReplacementTransaction) Execute(t *CancunTestContext) error {
+	return sendRejectedTransaction(t, step, 0)
+}
+
+func (step SendRejectedReplacementTransaction) Description() string {
+	return fmt.Sprintf("SendRejectedReplacementTransaction: %d blobs, account %d, client %d", step.BlobCount, step.AccountIndex, step.ClientIndex)
+}
+
+// A step that sends a blob transaction at the next nonce of an account, and
+// expects the client to reject it
+type SendRejectedBlobTransaction struct {
+	// Blobs of the transaction
+	BlobCount uint64
+	// Max Data Gas Cost of the transaction
+	BlobTransactionMaxBlobGasCost *big.Int
+	// Gas Fee Cap of the transaction
+	BlobTransactionGasFeeCap *big.Int
+	// Gas Tip Cap of the transaction
+	BlobTransactionGasTipCap *big.Int
+	// Account index of the sender
+	AccountIndex uint64
+	// Client index to send the transaction to
+	ClientIndex uint64
+}
+
+func (step SendRejectedBlobTransaction) Execute(t *CancunTestContext) error {
+	return sendRejectedTransaction(t, SendRejectedReplacementTransaction(step), 1)
+}
+
+func (step SendRejectedBlobTransaction) Description() string {
+	return fmt.Sprintf("SendRejectedBlobTransaction: %d blobs, account %d, client %d", step.BlobCount, step.AccountIndex, step.ClientIndex)
+}
+
+// sendRejectedTransaction sends a transaction at the given offset from the last
+// nonce of the account, and checks that the client rejects it.
+func sendRejectedTransaction(t *CancunTestContext, step SendRejectedReplacementTransaction, nonceOffset uint64) error {
+	if step.ClientIndex >= uint64(len(t.Engines)) {
+		return fmt.Errorf("invalid client index %d", step.ClientIndex)
+	}
+	var (
+		engine = t.Engines[step.ClientIndex]
+		sender = globals.TestAccounts[step.AccountIndex]
+		addr   = common.BigToAddress(cancun.DATAHASH_START_ADDRESS)
+	)
+	header, err := engine.HeaderByNumber(t.TestContext, nil)
+	if err != nil {
+		return errors.Wrap(err, "error getting header")
+	}
+	nonce, err := t.GetLastNonce(t.TestContext, engine, sender, header)
+	if err != nil {
+		return errors.Wrap(err, "error getting last account nonce")
+	}
+	nonce += nonceOffset
+	var txCreator helper.TransactionCreator
+	if step.BlobCount > 0 {
+		txCreator = &helper.BlobTransactionCreator{
+			To:         &addr,
+			GasLimit:   100000,
+			GasTip:     step.BlobTransactionGasTipCap,
+			GasFee:     step.BlobTransactionGasFeeCap,
+			BlobGasFee: step.BlobTransactionMaxBlobGasCost,
+			BlobCount:  step.BlobCount,
+			BlobID:     t.CurrentBlobID,
+		}
+	} else {
+		txCreator = &helper.BaseTransactionCreator{
+			Recipient:  &addr,
+			GasLimit:   100000,
+			GasTip:     step.BlobTransactionGasTipCap,
+			GasFee:     step.BlobTransactionGasFeeCap,
+			TxType:     helper.DynamicFeeTxOnly,
+			ForkConfig: t.ForkConfig,
+		}
+	}
+	tx, err := txCreator.MakeTransaction(sender, nonce, header.Time)
+	if err != nil {
+		return errors.Wrap(err, "error crafting transaction")
+	}
+
+	// The transaction is sent only once, since any retry could be accepted
+	ctx, cancel := context.WithTimeout(t.TestContext, globals.RPCTimeout)
+	defer cancel()
+	err = engine.SendTransaction(ctx, tx)
+	if err == nil {
+		return fmt.Errorf("transaction %s with nonce %d was accepted", tx.Hash(), nonce)
+	}
+	t.Logf("INFO: Transaction %s with nonce %d rejected: %v", tx.Hash(), nonce, err)
+
+	// Blob IDs are not reused, so the blobs of later transactions are different
+	t.TestBlobTxPool.Mutex.Lock()
+	t.CurrentBlobID += helper.BlobID(step.BlobCount)
+	t.TestBlobTxPool.Mutex.Unlock()
+	return nil
+}
+
+// A step that requests blobs from the blob pool of the client using engine_getBlobsV1
+type GetBlobs struct {
+	// Blobs whose versioned hashes are requested
+	BlobIDs helper.BlobIDs
+	// Number of random versioned hashes appended to the request, which the client
+	// must not know
+	UnknownHashCount uint64
+	// Blobs which the client must return, null is expected for all other hashes
+	ExpectedBlobs helper.BlobIDs
+	// Expected error code of the request
+	ExpectedError *int
+	// Client index to request the blobs from
+	ClientIndex uint64
+}
+
+func (step GetBlobs) Execute(t *CancunTestContext) error {
+	if step.ClientIndex >= uint64(len(t.TestEngines)) {
+		return fmt.Errorf("invalid client index %d", step.ClientIndex)
+	}
+	versionedHashes := make([]common.Hash, 0, len(step.BlobIDs)+int(step.UnknownHashCount))
+	for _, blobID := range step.BlobIDs {
+		versionedHash, err := blobID.GetVersionedHash(typ.BlobCommitmentVersionKZG)
+		if err != nil {
+			return err
+		}
+		versionedHashes = append(versionedHashes, versionedHash)
+	}
+	for i := uint64(0); i < step.UnknownHashCount; i++ {
+		var versionedHash common.Hash
+		t.Rand.Read(versionedHash[:])
+		versionedHash[0] = typ.BlobCommitmentVersionKZG
+		versionedHashes = append(versionedHashes, versionedHash)
+	}
+
+	r := t.TestEngines[step.ClientIndex].TestEngineGetBlobsV1(versionedHashes)
+	if step.ExpectedError != nil {
+		r.ExpectErrorCode(*step.ExpectedError)
+		return nil
+	}
+	r.ExpectBlobsCount(uint64(len(versionedHashes)))
+	return VerifyBlobsAndProofs(step.BlobIDs, step.ExpectedBlobs, r.BlobsAndProofs)
+}
+
+func (step GetBlobs) Description() string {
+	return fmt.Sprintf("GetBlobs: %d blobs, %d unknown hashes, %d blobs expected", len(step.BlobIDs), step.UnknownHashCount, len(step.ExpectedBlobs))
+}
+
+// VerifyBlobsAndProofs checks the response to a engine_getBlobsV1 request for the
+// given blobs: expected blobs must be returned with their correct proofs, and
+// all other entries must be null.
+func VerifyBlobsAndProofs(requested helper.BlobIDs, expected helper.BlobIDs, blobsAndProofs []*typ.BlobAndProofV1) error {
+	isExpected := make(map[helper.BlobID]bool)
+	for _, blobID := range expected {
+		isExpected[blobID] = true
+	}
+	for i, blobAndProof := range blobsAndProofs {
+		if i >= len(requested) {
+			// Unknown hashes
+			if blobAndProof != nil {
+				return fmt.Errorf("blob returned for unknown versioned hash at index %d", i)
+			}
+			continue
+		}
+		blobID := requested[i]
+		if !isExpected[blobID] {
+			if blobAndProof != nil {
+				return fmt.Errorf("blob %d returned, expected null", blobID)
+			}
+			continue
+		}
+		if blobAndProof == nil {
+			return fmt.Errorf("blob %d not returned", blobID)
+		}
+		if ok, err := blobID.VerifyBlob(&blobAndProof.Blob); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("blob %d returned with incorrect content", blobID)
+		}
+		_, blobData, err := helper.BlobDataGenerator(blobID, 1)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(blobAndProof.Proof[:], blobData.Proofs[0][:]) {
+			return fmt.Errorf("blob %d returned with incorrect proof: want %s, got %s", blobID, blobData.Proofs[0], blobAndProof.Proof)
+		}
+	}
+	return nil
+}
+
+// A step that includes blob transactions in a payload, re-orgs the payload out in
+// favor of an alternative empty payload, and re-orgs back to it. The blobs must be
+// available in the blob pool of the client while the transactions are re-org'd out.
+type BlobTransactionsReOrg struct {
+	// Number of blob transactions included in the re-org'd payload
+	TransactionCount uint64
+	// Blobs per transaction
+	BlobsPerTransaction uint64
+}
+
+// Time given to the client to update the blob pool after a new head is set
+const blobPoolUpdateTimeout = 10 * time.Second
+
+// waitForBlobs polls the blob pool of the client until the given blobs are
+// available.
+func waitForBlobs(t *CancunTestContext, blobIDs helper.BlobIDs) error {
+	var (
+		versionedHashes = make([]common.Hash, len(blobIDs))
+		err             error
+	)
+	for i, blobID := range blobIDs {
+		if versionedHashes[i], err = blobID.GetVersionedHash(typ.BlobCommitmentVersionKZG); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(blobPoolUpdateTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(t.TestContext, globals.RPCTimeout)
+		blobsAndProofs, err := t.Engine.GetBlobsV1(ctx, versionedHashes)
+		cancel()
+		if err == nil {
+			if len(blobsAndProofs) != len(versionedHashes) {
+				return fmt.Errorf("expected %d entries, got %d", len(versionedHashes), len(blobsAndProofs))
+			}
+			err = VerifyBlobsAndProofs(blobIDs, blobIDs, blobsAndProofs)
+			if err == nil {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-t.TestContext.Done():
+			return t.TestContext.Err()
+		}
+	}
+}
+
+func (step BlobTransactionsReOrg) Execute(t *CancunTestContext) error {
+	var (
+		sendStep = SendBlobTransactions{
+			TransactionCount:              step.TransactionCount,
+			BlobsPerTransaction:           step.BlobsPerTransaction,
+			BlobTransactionMaxBlobGasCost: big.NewInt(1),
+		}
+		blobIDs    = helper.GetBlobList(t.CurrentBlobID, step.TransactionCount*sendStep.GetBlobsPerTransaction())
+		altPayload *typ.ExecutableData
+	)
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnPayloadAttributesGenerated: func() {
+			// Build the alternative payload before the transactions are sent, so it
+			// does not include them.
+			payloadAttributes := t.CLMock.LatestPayloadAttributes
+			t.Rand.Read(payloadAttributes.Random[:])
+			r := t.TestEngine.TestEngineForkchoiceUpdated(&t.CLMock.LatestForkchoice, &payloadAttributes, t.CLMock.LatestHeader.Time)
+			r.ExpectNoError()
+			if r.Response.PayloadID == nil {
+				t.Fatalf("FAIL: No payload ID returned for the alternative payload")
+			}
+			g := t.TestEngine.TestEngineGetPayload(r.Response.PayloadID, &payloadAttributes)
+			g.ExpectNoError()
+			if len(g.Payload.Transactions) != 0 {
+				t.Fatalf("FAIL: Alternative payload contains %d transactions", len(g.Payload.Transactions))
+			}
+			altPayload = &g.Payload
+
+			if err := sendStep.Execute(t); err != nil {
+				t.Fatalf("FAIL: Error sending blob transactions: %v", err)
+			}
+		},
+		OnGetPayload: func() {
+			_, blobDataInPayload, err := GetBlobDataInPayload(t.TestBlobTxPool, &t.CLMock.LatestPayloadBuilt)
+			if err != nil {
+				t.Fatalf("FAIL: Error retrieving blob data from payload: %v", err)
+			}
+			if len(blobDataInPayload) != len(blobIDs) {
+				t.Fatalf("FAIL: Expected %d blobs in the payload, got %d", len(blobIDs), len(blobDataInPayload))
+			}
+		},
+		OnForkchoiceBroadcast: func() {
+			// Clients may keep the blobs of included transactions, so their
+			// availability is only checked once the transactions are re-org'd out.
+
+			// Re-org to the alternative payload, which moves the transactions back
+			// to the pool.
+			n := t.TestEngine.TestEngineNewPayload(altPayload)
+			n.ExpectStatus(test.Valid)
+			f := t.TestEngine.TestEngineForkchoiceUpdated(&api.ForkchoiceStateV1{
+				HeadBlockHash:      altPayload.BlockHash,
+				SafeBlockHash:      t.CLMock.LatestForkchoice.SafeBlockHash,
+				FinalizedBlockHash: t.CLMock.LatestForkchoice.FinalizedBlockHash,
+			}, nil, altPayload.Timestamp)
+			f.ExpectPayloadStatus(test.Valid)
+			if err := waitForBlobs(t, blobIDs); err != nil {
+				t.Fatalf("FAIL: Blobs were not available after the transactions were re-org'd out: %v", err)
+			}
+
+			// Re-org back to the canonical payload
+			version := t.ForkConfig.ForkchoiceUpdatedVersion(t.CLMock.LatestPayloadBuilt.Timestamp, nil)
+			t.CLMock.BroadcastForkchoiceUpdated(&t.CLMock.LatestForkchoice, nil, version)
+		},
+	})
+	return nil
+}
+
+func (step BlobTransactionsReOrg) Description() string {
+	return fmt.Sprintf("BlobTransactionsReOrg: %d transactions, %d blobs each", step.TransactionCount, step.BlobsPerTransaction)
+}
+
 // A step that attempts to peer to the client using devp2p, and checks the forkid of the client
 type DevP2PClientPeering struct {
 	// Client index to peer to
@@ -1760,6 +1760,299 @@ var Tests = []test.Spec{
 		},
 	},
 
+	// Blob pool tests
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Replace Blob Transaction, Insufficient Blob Gas Fee Bump",
+			About: `
+			Send a blob transaction, and try to replace it with a blob transaction
+			with higher gas fee and tip but the same max fee per blob gas.
+			Verify that the replacement is rejected, and that the original
+			transaction is included in the next payload.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{ // Blob ID 0
+				TransactionCount:              1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e2),
+				BlobTransactionGasFeeCap:      big.NewInt(1e9),
+				BlobTransactionGasTipCap:      big.NewInt(1e9),
+			},
+			SendRejectedReplacementTransaction{ // Blob ID 1
+				BlobCount:                     1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e2),
+				BlobTransactionGasFeeCap:      big.NewInt(1e10),
+				BlobTransactionGasTipCap:      big.NewInt(1e10),
+			},
+			GetBlobs{
+				BlobIDs:       helper.GetBlobList(0, 2),
+				ExpectedBlobs: []helper.BlobID{0},
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 1,
+				ExpectedBlobs:             []helper.BlobID{0},
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Replace Blob Transaction, Insufficient Execution Fee Bump",
+			About: `
+			Send a blob transaction, and try to replace it with a blob transaction
+			with higher max fee per blob gas but the same gas fee and tip.
+			Verify that the replacement is rejected, and that the original
+			transaction is included in the next payload.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{ // Blob ID 0
+				TransactionCount:              1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e2),
+				BlobTransactionGasFeeCap:      big.NewInt(1e9),
+				BlobTransactionGasTipCap:      big.NewInt(1e9),
+			},
+			SendRejectedReplacementTransaction{ // Blob ID 1
+				BlobCount:                     1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e3),
+				BlobTransactionGasFeeCap:      big.NewInt(1e9),
+				BlobTransactionGasTipCap:      big.NewInt(1e9),
+			},
+			GetBlobs{
+				BlobIDs:       helper.GetBlobList(0, 2),
+				ExpectedBlobs: []helper.BlobID{0},
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 1,
+				ExpectedBlobs:             []helper.BlobID{0},
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Replace Blob Transaction, Non-Blob Replacement",
+			About: `
+			Send a blob transaction, and try to replace it with a dynamic fee
+			transaction with higher gas fee and tip.
+			Verify that the replacement is rejected, and that the original
+			transaction is included in the next payload.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{ // Blob ID 0
+				TransactionCount:              1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e2),
+				BlobTransactionGasFeeCap:      big.NewInt(1e9),
+				BlobTransactionGasTipCap:      big.NewInt(1e9),
+			},
+			SendRejectedReplacementTransaction{
+				BlobTransactionGasFeeCap: big.NewInt(1e10),
+				BlobTransactionGasTipCap: big.NewInt(1e10),
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 1,
+				ExpectedBlobs:             []helper.BlobID{0},
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Blob Transaction Pool, Account Transactions Exceeding Block Capacity",
+			About: `
+			Send 16 single-blob transactions using account A, which is the
+			per-account limit of pending blob transactions of some clients.
+			Verify that all transactions are accepted and that they are included
+			in the following payloads in nonce order, with
+			cancun.MAX_BLOBS_PER_BLOCK blobs per payload.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{
+				TransactionCount:              16,
+				BlobsPerTransaction:           1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(100),
+			},
+			GetBlobs{
+				BlobIDs:       helper.GetBlobList(0, 16),
+				ExpectedBlobs: helper.GetBlobList(0, 16),
+			},
+			NewPayloads{
+				PayloadCount:              2,
+				ExpectedIncludedBlobCount: cancun.MAX_BLOBS_PER_BLOCK,
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 16 - 2*cancun.MAX_BLOBS_PER_BLOCK,
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Blob Transaction Pool, Account Pending Transactions Limit",
+			About: `
+			Send 16 single-blob transactions using account A, which is the
+			per-account limit of pending blob transactions of go-ethereum and
+			other clients, then send one more blob transaction at the next nonce.
+			Verify that the transaction exceeding the limit is rejected, and that
+			only the blobs of the first 16 transactions are in the blob pool and
+			included in the following payloads.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{ // Blob IDs 0-15
+				TransactionCount:              16,
+				BlobsPerTransaction:           1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(100),
+			},
+			SendRejectedBlobTransaction{ // Blob ID 16
+				BlobCount:                     1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(100),
+			},
+			GetBlobs{
+				BlobIDs:       helper.GetBlobList(0, 17),
+				ExpectedBlobs: helper.GetBlobList(0, 16),
+			},
+			NewPayloads{
+				PayloadCount:              2,
+				ExpectedIncludedBlobCount: cancun.MAX_BLOBS_PER_BLOCK,
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 16 - 2*cancun.MAX_BLOBS_PER_BLOCK,
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "Blob Transaction Re-Org",
+			About: `
+			Send blob transactions and include them in a payload. Then re-org to
+			an alternative empty payload and back.
+			Verify using engine_getBlobsV1 that the blobs are available in the
+			blob pool while the transactions are re-org'd out.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			// Get past the genesis
+			NewPayloads{
+				PayloadCount: 1,
+			},
+			BlobTransactionsReOrg{
+				TransactionCount:    2,
+				BlobsPerTransaction: 2,
+			},
+			// The chain continues on top of the re-org'd payload
+			NewPayloads{
+				ExpectedIncludedBlobCount: 0,
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "GetBlobsV1, Pooled Blobs",
+			About: `
+			Send blob transactions with multiple blobs each, and request their blobs
+			using engine_getBlobsV1, along with unknown versioned hashes.
+			Verify that the blobs and proofs are returned in the requested order,
+			and that null is returned for the unknown versioned hashes.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{
+				TransactionCount:              2,
+				BlobsPerTransaction:           2,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1),
+			},
+			GetBlobs{
+				BlobIDs:          helper.GetBlobListByIndex(3, 0),
+				UnknownHashCount: 2,
+				ExpectedBlobs:    helper.GetBlobList(0, 4),
+			},
+			NewPayloads{
+				ExpectedIncludedBlobCount: 4,
+				ExpectedBlobs:             helper.GetBlobList(0, 4),
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "GetBlobsV1, Replaced Blob Transaction",
+			About: `
+			Send a blob transaction and replace it with a blob transaction
+			carrying a different blob.
+			Verify that engine_getBlobsV1 only returns the blob of the
+			replacement.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			SendBlobTransactions{ // Blob ID 0
+				TransactionCount:              1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1),
+				BlobTransactionGasFeeCap:      big.NewInt(1e9),
+				BlobTransactionGasTipCap:      big.NewInt(1e9),
+			},
+			SendBlobTransactions{ // Blob ID 1
+				TransactionCount:              1,
+				BlobTransactionMaxBlobGasCost: big.NewInt(1e2),
+				BlobTransactionGasFeeCap:      big.NewInt(1e10),
+				BlobTransactionGasTipCap:      big.NewInt(1e10),
+				ReplaceTransactions:           true,
+			},
+			GetBlobs{
+				BlobIDs:       helper.GetBlobList(0, 2),
+				ExpectedBlobs: []helper.BlobID{1},
+			},
+		},
+	},
+
+	&CancunBaseSpec{
+
+		BaseSpec: test.BaseSpec{
+			Name: "GetBlobsV1, Too Large Request",
+			About: `
+			Request 129 versioned hashes using engine_getBlobsV1, one more
+			than the allowed maximum of the request.
+			Verify that the client returns the "Too large request" error.
+			`,
+			MainFork: config.Cancun,
+		},
+
+		TestSequence: TestSequence{
+			GetBlobs{
+				UnknownHashCount: 129,
+				ExpectedError:    globals.TOO_LARGE_REQUEST,
+			},
+		},
+	},
+
 	// DevP2P tests
 	&CancunBaseSpec{
 		BaseSpec: test.BaseSpec{
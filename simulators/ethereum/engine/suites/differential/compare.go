@@ -0,0 +1,106 @@
+package suite_differential
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Fields of eth_getBlockByNumber which are not compared. The total difficulty was
+// removed from the spec after the merge and is returned by some clients only.
+var ignoredBlockFields = map[string]bool{
+	"totalDifficulty": true,
+}
+
+// Divergence is a value which differs between the reference client and another
+// client.
+type Divergence struct {
+	// Path of the value, e.g. "stateRoot" or "transactions[1].gasPrice"
+	Path string
+	// Values returned by the reference and the compared client, "<missing>" if
+	// the value is absent.
+	Expected, Got string
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", d.Path, d.Expected, d.Got)
+}
+
+// compareBlocks compares the eth_getBlockByNumber responses of two clients field
+// by field. Divergences are sorted by path.
+func compareBlocks(expected, got json.RawMessage) ([]Divergence, error) {
+	var e, g interface{}
+	if err := decodeJSON(expected, &e); err != nil {
+		return nil, fmt.Errorf("invalid reference block: %w", err)
+	}
+	if err := decodeJSON(got, &g); err != nil {
+		return nil, fmt.Errorf("invalid block: %w", err)
+	}
+	var divergences []Divergence
+	compareValues("", e, g, &divergences)
+	sort.Slice(divergences, func(i, j int) bool {
+		return divergences[i].Path < divergences[j].Path
+	})
+	return divergences, nil
+}
+
+func decodeJSON(data json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func compareValues(path string, expected, got interface{}, divergences *[]Divergence) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, ev := range e {
+			if path == "" && ignoredBlockFields[key] {
+				continue
+			}
+			gv, ok := g[key]
+			if !ok {
+				*divergences = append(*divergences, Divergence{joinPath(path, key), encodeValue(ev), "<missing>"})
+				continue
+			}
+			compareValues(joinPath(path, key), ev, gv, divergences)
+		}
+		for key, gv := range g {
+			if _, ok := e[key]; !ok && !(path == "" && ignoredBlockFields[key]) {
+				*divergences = append(*divergences, Divergence{joinPath(path, key), "<missing>", encodeValue(gv)})
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(e) {
+			break
+		}
+		for i := range e {
+			compareValues(fmt.Sprintf("%s[%d]", path, i), e[i], g[i], divergences)
+		}
+		return
+	}
+	if ev, gv := encodeValue(expected), encodeValue(got); ev != gv {
+		*divergences = append(*divergences, Divergence{path, ev, gv})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func encodeValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
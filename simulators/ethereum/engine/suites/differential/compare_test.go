@@ -0,0 +1,52 @@
+package suite_differential
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareBlocks(t *testing.T) {
+	reference := `{
+		"number": "0xa",
+		"stateRoot": "0x01",
+		"totalDifficulty": "0x0",
+		"transactions": [{"hash": "0x11", "v": "0x0"}, {"hash": "0x12"}],
+		"withdrawals": []
+	}`
+	tests := []struct {
+		block string
+		want  []Divergence
+	}{
+		{
+			// Key order, whitespace and the total difficulty are ignored
+			block: `{"withdrawals":[],"transactions":[{"v":"0x0","hash":"0x11"},{"hash":"0x12"}],"stateRoot":"0x01","number":"0xa"}`,
+		},
+		{
+			block: `{"number":"0xa","stateRoot":"0x02","transactions":[{"hash":"0x11","v":"0x1"},{"hash":"0x12"}],"withdrawals":[]}`,
+			want: []Divergence{
+				{Path: "stateRoot", Expected: `"0x01"`, Got: `"0x02"`},
+				{Path: "transactions[0].v", Expected: `"0x0"`, Got: `"0x1"`},
+			},
+		},
+		{
+			block: `{"number":"0xa","stateRoot":"0x01","transactions":[{"hash":"0x11","v":"0x0"}],"withdrawals":null,"size":"0x1"}`,
+			want: []Divergence{
+				{Path: "size", Expected: "<missing>", Got: `"0x1"`},
+				{Path: "transactions", Expected: `[{"hash":"0x11","v":"0x0"},{"hash":"0x12"}]`, Got: `[{"hash":"0x11","v":"0x0"}]`},
+				{Path: "withdrawals", Expected: "[]", Got: "null"},
+			},
+		},
+	}
+	for i, test := range tests {
+		got, err := compareBlocks([]byte(reference), []byte(test.block))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: wrong divergences\n got: %v\nwant: %v", i, got, test.want)
+		}
+	}
+	if _, err := compareBlocks([]byte(reference), []byte("{")); err == nil {
+		t.Error("no error for invalid block")
+	}
+}
@@ -0,0 +1,214 @@
+package suite_differential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+)
+
+const (
+	// Number of blocks produced in each test
+	blockCount = 20
+	// Number of transactions sent before each block
+	txsPerBlock = 4
+)
+
+// Tests contains a differential test for each fork. The test is run once per
+// client type, which acts as reference client against all other eth1 clients.
+var Tests []test.Spec
+
+func init() {
+	for _, fork := range []config.Fork{config.Shanghai, config.Cancun, config.Prague} {
+		Tests = append(Tests, test.BaseSpec{
+			Name:           "Differential Payload Execution",
+			About:          "Executes the same sequence of payloads on all eth1 clients, and reports every client whose payload status or eth_getBlockByNumber output differs from the reference client.",
+			MainFork:       fork,
+			TimeoutSeconds: 600,
+			Run:            runDifferential,
+		})
+	}
+}
+
+// rawBlockClient is implemented by clients which return the undecoded output of
+// eth_getBlockByNumber.
+type rawBlockClient interface {
+	BlockByNumberJSON(ctx context.Context, number *big.Int, fullTx bool) (json.RawMessage, error)
+}
+
+type diffClient struct {
+	client.EngineClient
+	Type string
+	// Block number of the first divergence, if any
+	DivergedAt *uint64
+}
+
+func (c *diffClient) String() string {
+	return fmt.Sprintf("%s (%s)", c.Type, c.ID())
+}
+
+func runDifferential(t *test.Env) {
+	reference := &diffClient{EngineClient: t.Engine, Type: t.Client.Type}
+	if _, ok := t.Engine.(rawBlockClient); !ok {
+		t.Fatalf("FAIL (%s): Reference client does not return raw blocks", t.TestName)
+	}
+
+	// Start all other eth1 clients. Only the reference client is added to the CL
+	// Mocker, so it builds all payloads, and a diverging client can't stop block
+	// production. The other clients receive the payloads and forkchoice updates
+	// from the test.
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to get client types: %v", t.TestName, err)
+	}
+	var clients []*diffClient
+	for _, clientType := range clientTypes {
+		if clientType.Name == reference.Type || !clientType.HasRole("eth1") {
+			continue
+		}
+		ec, err := hive_rpc.HiveRPCEngineStarter{ClientType: clientType.Name}.StartClient(t.T, t.TestContext, t.Genesis, t.ClientParams, t.ClientFiles)
+		if err != nil {
+			t.Fatalf("FAIL (%s): Unable to start client %s: %v", t.TestName, clientType.Name, err)
+		}
+		defer ec.Close()
+		clients = append(clients, &diffClient{EngineClient: ec, Type: clientType.Name})
+	}
+	if len(clients) == 0 {
+		t.Logf("INFO (%s): No other eth1 clients available, nothing to compare", t.TestName)
+		return
+	}
+	t.CLMock.WaitForTTD()
+
+	for i := 0; i < blockCount; i++ {
+		// Failed requests of the other clients, reported after the block
+		failures := make(map[*diffClient]string)
+		t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+			OnPayloadProducerSelected: func() {
+				_, err := t.SendNextTransactions(t.TestContext, t.CLMock.NextBlockProducer, &helper.BaseTransactionCreator{
+					Recipient:  &globals.PrevRandaoContractAddr,
+					Amount:     big.NewInt(1),
+					GasLimit:   75000,
+					BlobCount:  big.NewInt(1),
+					TxType:     helper.UnspecifiedTransactionType,
+					ForkConfig: t.ForkConfig,
+				}, txsPerBlock)
+				if err != nil {
+					t.Fatalf("FAIL (%s): Unable to send transactions: %v", t.TestName, err)
+				}
+			},
+			OnNewPayloadBroadcast: func() {
+				payload := &t.CLMock.LatestPayloadBuilt
+				version := t.ForkConfig.NewPayloadVersion(payload.Timestamp)
+				for _, c := range clients {
+					if c.DivergedAt != nil {
+						continue
+					}
+					ctx, cancel := context.WithTimeout(t.TestContext, globals.RPCTimeout)
+					if _, err := c.NewPayload(ctx, version, payload); err != nil {
+						failures[c] = fmt.Sprintf("engine_newPayloadV%d failed: %v", version, err)
+					}
+					cancel()
+				}
+			},
+			OnForkchoiceBroadcast: func() {
+				version := t.ForkConfig.ForkchoiceUpdatedVersion(t.CLMock.LatestExecutedPayload.Timestamp, nil)
+				for _, c := range clients {
+					if c.DivergedAt != nil || failures[c] != "" {
+						continue
+					}
+					ctx, cancel := context.WithTimeout(t.TestContext, globals.RPCTimeout)
+					if _, err := c.ForkchoiceUpdated(ctx, version, &t.CLMock.LatestForkchoice, nil); err != nil {
+						failures[c] = fmt.Sprintf("engine_forkchoiceUpdatedV%d failed: %v", version, err)
+					}
+					cancel()
+				}
+			},
+		})
+
+		number := t.CLMock.LatestHeader.Number
+		if r := reference.LatestNewPayloadResponse(); r == nil || r.Status != api.VALID {
+			t.Fatalf("FAIL (%s): Reference client did not validate block %d: %v", t.TestName, number, r)
+		}
+		expectedBlock, err := reference.EngineClient.(rawBlockClient).BlockByNumberJSON(t.TestContext, number, true)
+		if err != nil {
+			t.Fatalf("FAIL (%s): Unable to get block %d from reference client: %v", t.TestName, number, err)
+		}
+		for _, c := range clients {
+			if c.DivergedAt != nil {
+				continue
+			}
+			reason := failures[c]
+			if reason == "" {
+				reason = compareClient(t, reference, c, number, expectedBlock)
+			}
+			if reason != "" {
+				n := number.Uint64()
+				c.DivergedAt = &n
+				t.Errorf("FAIL (%s): %s diverged from %s at block %d: %s", t.TestName, c, reference, n, reason)
+			}
+		}
+	}
+
+	// Summary of all clients
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Differential summary after %d blocks, reference %s:", blockCount, reference)
+	for _, c := range clients {
+		if c.DivergedAt == nil {
+			fmt.Fprintf(&summary, "\n  %s: no divergence", c)
+		} else {
+			fmt.Fprintf(&summary, "\n  %s: diverged at block %d", c, *c.DivergedAt)
+		}
+	}
+	t.Logf("INFO (%s): %s", t.TestName, summary.String())
+}
+
+// compareClient compares the latest payload status and the block of a client
+// with the reference client. It returns the reason of a divergence, or an empty
+// string.
+func compareClient(t *test.Env, reference, c *diffClient, number *big.Int, expectedBlock json.RawMessage) string {
+	expected, got := reference.LatestNewPayloadResponse(), c.LatestNewPayloadResponse()
+	if got == nil {
+		return "no payload status"
+	}
+	if got.Status != expected.Status || !equalHashes(got, expected) {
+		return fmt.Sprintf("payload status %s (latest valid hash %v), expected %s (latest valid hash %v)", got.Status, got.LatestValidHash, expected.Status, expected.LatestValidHash)
+	}
+
+	rc, ok := c.EngineClient.(rawBlockClient)
+	if !ok {
+		return "client does not return raw blocks"
+	}
+	block, err := rc.BlockByNumberJSON(t.TestContext, number, true)
+	if err != nil {
+		return fmt.Sprintf("unable to get block: %v", err)
+	}
+	divergences, err := compareBlocks(expectedBlock, block)
+	if err != nil {
+		return err.Error()
+	}
+	if len(divergences) > 0 {
+		lines := make([]string, len(divergences))
+		for i, d := range divergences {
+			lines[i] = d.String()
+		}
+		return fmt.Sprintf("eth_getBlockByNumber output differs:\n  %s", strings.Join(lines, "\n  "))
+	}
+	return ""
+}
+
+func equalHashes(a, b *api.PayloadStatusV1) bool {
+	if a.LatestValidHash == nil || b.LatestValidHash == nil {
+		return a.LatestValidHash == b.LatestValidHash
+	}
+	return *a.LatestValidHash == *b.LatestValidHash
+}
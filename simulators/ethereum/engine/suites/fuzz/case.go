@@ -0,0 +1,386 @@
+// # Engine API fuzzing
+//
+// Fuzz cases are sequences of actions generated from a seed: mutated payloads sent
+// through engine_newPayload, forkchoice updates pointing to valid, invalid and
+// unknown blocks, and valid blocks produced by the CL Mocker. Instead of expecting
+// specific results, the responses of the client are checked against invariants of
+// the Engine API specification. Failing cases are minimized and logged as case
+// files, which can be replayed by adding them to the fuzz-cases directory.
+package suite_fuzz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Case is a sequence of actions executed against a client.
+type Case struct {
+	Name string      `yaml:"name"`
+	Fork config.Fork `yaml:"fork"`
+	// Seed used to generate the case, informational only
+	Seed    int64    `yaml:"seed"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Action is a single step of a case. Exactly one of the fields is set.
+type Action struct {
+	// Produces valid blocks using the CL Mocker
+	ProduceBlocks uint64 `yaml:"produceBlocks,omitempty"`
+	// Mutates the next payload built by the client and sends it before the valid
+	// payload is broadcast
+	MutatePayload []Mutation `yaml:"mutatePayload,omitempty"`
+	// Sends a sequence of forkchoice updates, after which the forkchoice of the CL
+	// Mocker is restored
+	Forkchoice []ForkchoiceCall `yaml:"forkchoice,omitempty"`
+}
+
+func (a Action) String() string {
+	switch {
+	case a.ProduceBlocks > 0:
+		return fmt.Sprintf("produce %d block(s)", a.ProduceBlocks)
+	case len(a.MutatePayload) > 0:
+		s := make([]string, len(a.MutatePayload))
+		for i, m := range a.MutatePayload {
+			s[i] = m.String()
+		}
+		return "mutate payload: " + strings.Join(s, ", ")
+	default:
+		s := make([]string, len(a.Forkchoice))
+		for i, c := range a.Forkchoice {
+			s[i] = c.String()
+		}
+		return "forkchoice: " + strings.Join(s, ", ")
+	}
+}
+
+func (a Action) validate() error {
+	set := 0
+	if a.ProduceBlocks > 0 {
+		set++
+	}
+	if len(a.MutatePayload) > 0 {
+		set++
+	}
+	if len(a.Forkchoice) > 0 {
+		set++
+	}
+	if set != 1 {
+		return fmt.Errorf("action must contain exactly one of produceBlocks, mutatePayload and forkchoice")
+	}
+	for _, m := range a.MutatePayload {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+	for _, c := range a.Forkchoice {
+		for _, r := range []BlockRef{c.Head, c.Safe, c.Finalized} {
+			if err := r.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Targets of payload mutations
+type MutationTarget string
+
+const (
+	TargetHeader          MutationTarget = "header"
+	TargetTransactions    MutationTarget = "transactions"
+	TargetWithdrawals     MutationTarget = "withdrawals"
+	TargetVersionedHashes MutationTarget = "versionedHashes"
+)
+
+// Operations supported by each target. Header operations are applied to the field
+// given in the mutation.
+var mutationOps = map[MutationTarget][]string{
+	TargetHeader:          {"flip", "add", "set"},
+	TargetTransactions:    {"drop", "duplicate", "swap", "corrupt", "append"},
+	TargetWithdrawals:     {"drop", "duplicate", "swap", "amount", "remove"},
+	TargetVersionedHashes: {"drop", "duplicate", "swap", "corrupt", "append"},
+}
+
+// Header fields and the operation which applies to them
+var headerFields = map[string]string{
+	"parentHash":    "flip",
+	"feeRecipient":  "flip",
+	"stateRoot":     "flip",
+	"receiptsRoot":  "flip",
+	"prevRandao":    "flip",
+	"blockHash":     "flip",
+	"number":        "add",
+	"gasLimit":      "add",
+	"gasUsed":       "add",
+	"timestamp":     "add",
+	"baseFeePerGas": "add",
+	"blobGasUsed":   "add",
+	"excessBlobGas": "add",
+	"extraData":     "set",
+}
+
+// Mutation is a modification of a payload. Indexes are taken modulo the length of
+// the modified list, so mutations can be applied to any payload.
+type Mutation struct {
+	Target MutationTarget `yaml:"target"`
+	Op     string         `yaml:"op"`
+	Field  string         `yaml:"field,omitempty"`
+	Index  int            `yaml:"index,omitempty"`
+	// Second index for swaps, byte position for flips and corruptions
+	Other int           `yaml:"other,omitempty"`
+	Delta int64         `yaml:"delta,omitempty"`
+	Data  hexutil.Bytes `yaml:"data,omitempty"`
+}
+
+func (m Mutation) String() string {
+	switch m.Op {
+	case "add":
+		return fmt.Sprintf("%s %+d", m.Field, m.Delta)
+	case "flip":
+		return fmt.Sprintf("flip %s byte %d", m.Field, m.Other)
+	case "set":
+		return fmt.Sprintf("set %s=%s", m.Field, m.Data)
+	case "amount":
+		return fmt.Sprintf("%s[%d].amount %+d", m.Target, m.Index, m.Delta)
+	case "remove":
+		return fmt.Sprintf("remove %s", m.Target)
+	}
+	return fmt.Sprintf("%s %s[%d]", m.Op, m.Target, m.Index)
+}
+
+func (m Mutation) validate() error {
+	ops, ok := mutationOps[m.Target]
+	if !ok {
+		return fmt.Errorf("unknown mutation target %q", m.Target)
+	}
+	known := false
+	for _, op := range ops {
+		known = known || op == m.Op
+	}
+	if !known {
+		return fmt.Errorf("unknown %s mutation %q", m.Target, m.Op)
+	}
+	if m.Target == TargetHeader {
+		if op, ok := headerFields[m.Field]; !ok {
+			return fmt.Errorf("unknown header field %q", m.Field)
+		} else if op != m.Op {
+			return fmt.Errorf("header field %s does not support %q", m.Field, m.Op)
+		}
+	}
+	return nil
+}
+
+// Kinds of block references in forkchoice updates
+const (
+	RefHead      = "head"      // latest block of the CL Mocker
+	RefAncestor  = "ancestor"  // canonical block Depth blocks below the head
+	RefSafe      = "safe"      // safe block of the CL Mocker
+	RefFinalized = "finalized" // finalized block of the CL Mocker
+	RefInvalid   = "invalid"   // latest payload sent which must not be valid
+	RefUnknown   = "unknown"   // the hash given in the reference
+	RefZero      = "zero"      // the zero hash
+)
+
+// BlockRef refers to a block by its relation to the chain of the CL Mocker.
+type BlockRef struct {
+	Kind  string       `yaml:"kind"`
+	Depth uint64       `yaml:"depth,omitempty"`
+	Hash  *common.Hash `yaml:"hash,omitempty"`
+}
+
+func (r BlockRef) String() string {
+	switch r.Kind {
+	case RefAncestor:
+		return fmt.Sprintf("head-%d", r.Depth)
+	case RefUnknown:
+		return r.Hash.String()[:10]
+	}
+	return r.Kind
+}
+
+func (r BlockRef) validate() error {
+	switch r.Kind {
+	case RefHead, RefAncestor, RefSafe, RefFinalized, RefInvalid, RefZero:
+	case RefUnknown:
+		if r.Hash == nil {
+			return fmt.Errorf("unknown block reference without hash")
+		}
+	default:
+		return fmt.Errorf("unknown block reference %q", r.Kind)
+	}
+	return nil
+}
+
+// ForkchoiceCall is a forkchoice update without payload attributes.
+type ForkchoiceCall struct {
+	Head      BlockRef `yaml:"head"`
+	Safe      BlockRef `yaml:"safe"`
+	Finalized BlockRef `yaml:"finalized"`
+}
+
+func (c ForkchoiceCall) String() string {
+	return fmt.Sprintf("(%s, %s, %s)", c.Head, c.Safe, c.Finalized)
+}
+
+// Generate creates a case of the given length from a seed.
+func Generate(seed int64, fork config.Fork, length int) *Case {
+	rng := rand.New(rand.NewSource(seed))
+	c := &Case{
+		Name: fmt.Sprintf("Fuzz seed %d", seed),
+		Fork: fork,
+		Seed: seed,
+		// Start with a few blocks so ancestors can be referenced
+		Actions: []Action{{ProduceBlocks: 2}},
+	}
+	for len(c.Actions) < length {
+		switch n := rng.Intn(10); {
+		case n < 6:
+			muts := make([]Mutation, 1+rng.Intn(2))
+			for i := range muts {
+				muts[i] = randomMutation(rng)
+			}
+			c.Actions = append(c.Actions, Action{MutatePayload: muts})
+		case n < 9:
+			calls := make([]ForkchoiceCall, 1+rng.Intn(3))
+			for i := range calls {
+				calls[i] = ForkchoiceCall{
+					Head:      randomRef(rng),
+					Safe:      randomRef(rng),
+					Finalized: randomRef(rng),
+				}
+			}
+			c.Actions = append(c.Actions, Action{Forkchoice: calls})
+		default:
+			c.Actions = append(c.Actions, Action{ProduceBlocks: uint64(1 + rng.Intn(2))})
+		}
+	}
+	return c
+}
+
+func randomMutation(rng *rand.Rand) Mutation {
+	targets := []MutationTarget{TargetHeader, TargetTransactions, TargetWithdrawals, TargetVersionedHashes}
+	m := Mutation{
+		Target: targets[rng.Intn(len(targets))],
+		Index:  rng.Intn(16),
+		Other:  rng.Intn(64),
+	}
+	if m.Target == TargetHeader {
+		fields := make([]string, 0, len(headerFields))
+		for f := range headerFields {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		m.Field = fields[rng.Intn(len(fields))]
+		m.Op = headerFields[m.Field]
+	} else {
+		ops := mutationOps[m.Target]
+		m.Op = ops[rng.Intn(len(ops))]
+	}
+	switch m.Op {
+	case "add", "amount":
+		deltas := []int64{-1, 1, -1000, 1 << 20, -(1 << 40), 1 << 40}
+		m.Delta = deltas[rng.Intn(len(deltas))]
+	case "set", "append":
+		size := 1 + rng.Intn(48)
+		if m.Target == TargetVersionedHashes {
+			size = 32
+		}
+		m.Data = make([]byte, size)
+		rng.Read(m.Data)
+	}
+	return m
+}
+
+func randomRef(rng *rand.Rand) BlockRef {
+	kinds := []string{RefHead, RefHead, RefAncestor, RefSafe, RefFinalized, RefInvalid, RefUnknown, RefZero}
+	r := BlockRef{Kind: kinds[rng.Intn(len(kinds))]}
+	switch r.Kind {
+	case RefAncestor:
+		r.Depth = uint64(1 + rng.Intn(3))
+	case RefUnknown:
+		var h common.Hash
+		rng.Read(h[:])
+		r.Hash = &h
+	}
+	return r
+}
+
+// Parse reads a case from YAML or JSON and validates it.
+func Parse(r io.Reader) (*Case, error) {
+	var c Case
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Name == "" {
+		return nil, fmt.Errorf("case has no name")
+	}
+	if c.Fork != config.Shanghai && c.Fork != config.Cancun {
+		return nil, fmt.Errorf("unsupported fork %q", c.Fork)
+	}
+	if len(c.Actions) == 0 {
+		return nil, fmt.Errorf("case has no actions")
+	}
+	for i, a := range c.Actions {
+		if err := a.validate(); err != nil {
+			return nil, fmt.Errorf("action %d: %v", i+1, err)
+		}
+	}
+	return &c, nil
+}
+
+// Marshal encodes the case as YAML.
+func (c *Case) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// LoadDir loads all case files (.yaml, .yml and .json) in a directory. A missing
+// directory is not an error.
+func LoadDir(dir string) ([]*Case, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var cases []*Case
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if e.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c, err := Parse(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
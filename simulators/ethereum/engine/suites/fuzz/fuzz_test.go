@@ -0,0 +1,140 @@
+package suite_fuzz
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+func TestGenerateRoundTrip(t *testing.T) {
+	c := Generate(42, config.Cancun, caseLength)
+	if !reflect.DeepEqual(c, Generate(42, config.Cancun, caseLength)) {
+		t.Fatal("generation is not deterministic")
+	}
+	if len(c.Actions) != caseLength {
+		t.Fatalf("wrong number of actions: %d", len(c.Actions))
+	}
+	data, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(c, parsed) {
+		t.Fatalf("case changed after encoding:\n%s", data)
+	}
+}
+
+func testTransaction(nonce uint64) []byte {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.LegacyTx{
+		Nonce:    nonce,
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+	})
+	data, _ := tx.MarshalBinary()
+	return data
+}
+
+func testPayload() *typ.ExecutableData {
+	blobGas := uint64(131072)
+	hashes := []common.Hash{{0x01, 0x01}, {0x01, 0x02}}
+	return &typ.ExecutableData{
+		ParentHash:    common.Hash{1},
+		StateRoot:     common.Hash{2},
+		LogsBloom:     make([]byte, 256),
+		Number:        10,
+		GasLimit:      30_000_000,
+		GasUsed:       100_000,
+		Timestamp:     100,
+		BaseFeePerGas: big.NewInt(7),
+		Transactions:  [][]byte{testTransaction(0), testTransaction(1)},
+		Withdrawals: []*types.Withdrawal{
+			{Index: 1, Amount: 100},
+			{Index: 2, Amount: 0},
+		},
+		BlobGasUsed:     &blobGas,
+		ExcessBlobGas:   new(uint64),
+		VersionedHashes: &hashes,
+	}
+}
+
+func TestApplyMutations(t *testing.T) {
+	tests := []struct {
+		mutation         Mutation
+		changed, invalid bool
+	}{
+		{Mutation{Target: TargetHeader, Op: "flip", Field: "stateRoot"}, true, true},
+		{Mutation{Target: TargetHeader, Op: "flip", Field: "prevRandao"}, true, false},
+		{Mutation{Target: TargetHeader, Op: "add", Field: "gasLimit", Delta: 1}, true, false},
+		{Mutation{Target: TargetHeader, Op: "add", Field: "gasLimit", Delta: 1 << 20}, true, true},
+		{Mutation{Target: TargetHeader, Op: "add", Field: "timestamp", Delta: -1}, true, true},
+		{Mutation{Target: TargetHeader, Op: "add", Field: "excessBlobGas", Delta: -1}, false, false},
+		{Mutation{Target: TargetHeader, Op: "set", Field: "extraData", Data: make([]byte, 33)}, true, true},
+		{Mutation{Target: TargetTransactions, Op: "drop"}, true, true},
+		{Mutation{Target: TargetTransactions, Op: "swap"}, true, false},
+		{Mutation{Target: TargetTransactions, Op: "corrupt", Other: 5}, true, false},
+		{Mutation{Target: TargetTransactions, Op: "append", Data: []byte{1}}, true, true},
+		{Mutation{Target: TargetWithdrawals, Op: "drop", Index: 1}, true, false},
+		{Mutation{Target: TargetWithdrawals, Op: "amount", Index: 1, Delta: -1}, false, false},
+		{Mutation{Target: TargetWithdrawals, Op: "remove"}, true, true},
+		{Mutation{Target: TargetVersionedHashes, Op: "swap"}, true, true},
+		{Mutation{Target: TargetVersionedHashes, Op: "append", Data: []byte{1}}, true, true},
+	}
+	for _, test := range tests {
+		base := testPayload()
+		r, err := applyMutations(base, []Mutation{test.mutation})
+		if err != nil {
+			t.Fatalf("%v: %v", test.mutation, err)
+		}
+		if r.changed != test.changed || r.invalid != test.invalid {
+			t.Errorf("%v: got changed=%t invalid=%t, want %t %t", test.mutation, r.changed, r.invalid, test.changed, test.invalid)
+		}
+		if r.changed && r.payload.BlockHash == (common.Hash{}) {
+			t.Errorf("%v: block hash not calculated", test.mutation)
+		}
+		if !reflect.DeepEqual(base, testPayload()) {
+			t.Errorf("%v: base payload modified", test.mutation)
+		}
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	// The failure reproduces whenever actions 3 and 7 of the original case are
+	// executed, and is detected at the later one.
+	c := Generate(1, config.Shanghai, 10)
+	for i := range c.Actions {
+		c.Actions[i] = Action{ProduceBlocks: uint64(i + 1)}
+	}
+	trial := func(candidate *Case) *Failure {
+		var found3, found7 bool
+		for i, a := range candidate.Actions {
+			found3 = found3 || a.ProduceBlocks == 3
+			found7 = found7 || a.ProduceBlocks == 7
+			if found3 && found7 {
+				return &Failure{Action: i, Invariant: InvariantInvalidHead}
+			}
+		}
+		return nil
+	}
+	failure := &Failure{Action: 6, Invariant: InvariantInvalidHead}
+	minimized, f := Minimize(c, failure, trial, 20)
+	if len(minimized.Actions) != 2 || minimized.Actions[0].ProduceBlocks != 3 || minimized.Actions[1].ProduceBlocks != 7 {
+		t.Fatalf("wrong minimized case: %v", minimized.Actions)
+	}
+	if f.Action != 1 {
+		t.Fatalf("wrong failing action: %d", f.Action)
+	}
+	if len(c.Actions) != 10 {
+		t.Fatal("original case modified")
+	}
+}
@@ -0,0 +1,46 @@
+package suite_fuzz
+
+// Minimize reduces a failing case to a shorter case which still violates the same
+// invariant. Every attempt is executed by trial, which runs a case against a fresh
+// client. At most maxTrials attempts are made.
+//
+// Actions after the failing one are dropped first, then chunks of decreasing size
+// are removed as long as the failure reproduces.
+func Minimize(c *Case, failure *Failure, trial func(*Case) *Failure, maxTrials int) (*Case, *Failure) {
+	best := c.withActions(c.Actions[:failure.Action+1])
+	bestFailure := failure
+	trials := 0
+
+	for chunk := len(best.Actions) / 2; chunk >= 1 && trials < maxTrials; {
+		removed := false
+		for start := 0; start < len(best.Actions) && trials < maxTrials; start += chunk {
+			end := start + chunk
+			if end > len(best.Actions) {
+				end = len(best.Actions)
+			}
+			if end-start == len(best.Actions) {
+				continue
+			}
+			actions := append(append([]Action{}, best.Actions[:start]...), best.Actions[end:]...)
+			candidate := best.withActions(actions)
+			trials++
+			if f := trial(candidate); f != nil && f.Invariant == failure.Invariant {
+				best, bestFailure = candidate.withActions(actions[:f.Action+1]), f
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			chunk /= 2
+		} else if chunk > len(best.Actions)/2 {
+			chunk = len(best.Actions) / 2
+		}
+	}
+	return best, bestFailure
+}
+
+func (c *Case) withActions(actions []Action) *Case {
+	cpy := *c
+	cpy.Actions = append([]Action{}, actions...)
+	return &cpy
+}
@@ -0,0 +1,315 @@
+package suite_fuzz
+
+import (
+	"math/big"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+// mutationResult is a payload modified by a list of mutations.
+type mutationResult struct {
+	payload *typ.ExecutableData
+	// Whether any of the mutations changed the payload
+	changed bool
+	// Whether the payload is certainly invalid, i.e. it must never be VALID nor
+	// become the head of the client
+	invalid bool
+}
+
+// fixedVersionedHashes replaces the versioned hashes of the payload.
+type fixedVersionedHashes []common.Hash
+
+func (h fixedVersionedHashes) GetVersionedHashes(*rand.Rand, *[]common.Hash) (*[]common.Hash, error) {
+	hashes := []common.Hash(h)
+	return &hashes, nil
+}
+
+// applyMutations modifies a copy of the base payload. The block hash is
+// recalculated unless it is mutated itself.
+func applyMutations(base *typ.ExecutableData, mutations []Mutation) (*mutationResult, error) {
+	var (
+		r           = &mutationResult{}
+		custom      = &helper.CustomPayloadData{}
+		txs         = copyBytesList(base.Transactions)
+		withdrawals = copyWithdrawals(base.Withdrawals)
+		hashes      []common.Hash
+		flipHash    = -1
+	)
+	if base.VersionedHashes != nil {
+		hashes = append([]common.Hash{}, *base.VersionedHashes...)
+	}
+	changed := func(invalid bool) {
+		r.changed = true
+		r.invalid = r.invalid || invalid
+	}
+
+	for _, m := range mutations {
+		switch m.Target {
+		case TargetHeader:
+			applyHeaderMutation(base, custom, m, changed, &flipHash)
+
+		case TargetTransactions:
+			var ok, invalid bool
+			txs, ok, invalid = mutateList(txs, m, func(tx []byte) []byte {
+				return corruptTransaction(tx, m.Other)
+			}, func() []byte {
+				return unsignedTransaction(m)
+			})
+			if ok {
+				// Changing a transaction can result in an equivalent block, e.g. when
+				// the gas limit is modified. Swapping transactions of different senders
+				// can also result in the same state.
+				changed(invalid && m.Op != "corrupt")
+				custom.Transactions = &txs
+			}
+
+		case TargetWithdrawals:
+			if base.Withdrawals == nil {
+				continue
+			}
+			switch m.Op {
+			case "remove":
+				custom.RemoveWithdrawals = true
+				changed(true)
+				continue
+			case "amount":
+				if len(withdrawals) == 0 {
+					continue
+				}
+				w := withdrawals[m.Index%len(withdrawals)]
+				amount := addDelta(w.Amount, m.Delta)
+				if amount == w.Amount {
+					continue
+				}
+				w.Amount = amount
+				changed(true)
+			default:
+				list, ok, _ := mutateList(withdrawals, m, nil, nil)
+				if !ok {
+					continue
+				}
+				// Dropping or duplicating an empty withdrawal doesn't change the state.
+				invalid := m.Op != "swap" && withdrawals[m.Index%len(withdrawals)].Amount > 0
+				withdrawals = list
+				changed(invalid)
+			}
+			custom.Withdrawals = types.Withdrawals(withdrawals)
+			if len(withdrawals) == 0 {
+				custom.Withdrawals = types.Withdrawals{}
+			}
+
+		case TargetVersionedHashes:
+			if base.VersionedHashes == nil {
+				continue
+			}
+			list, ok, _ := mutateList(hashes, m, func(h common.Hash) common.Hash {
+				h[m.Other%common.HashLength] ^= 0xff
+				return h
+			}, func() common.Hash { return common.BytesToHash(m.Data) })
+			if !ok || (m.Op == "swap" && list[m.Index%len(list)] == hashes[m.Index%len(hashes)]) {
+				continue
+			}
+			hashes = list
+			custom.VersionedHashesCustomizer = fixedVersionedHashes(hashes)
+			changed(true)
+		}
+	}
+	if !r.changed {
+		return r, nil
+	}
+
+	payload, err := custom.CustomizePayload(nil, base)
+	if err != nil {
+		return nil, err
+	}
+	if flipHash >= 0 {
+		payload.BlockHash[flipHash%common.HashLength] ^= 0xff
+	}
+	payload.ExecutionRequests = base.ExecutionRequests
+	r.payload = payload
+	return r, nil
+}
+
+func applyHeaderMutation(base *typ.ExecutableData, custom *helper.CustomPayloadData, m Mutation, changed func(bool), flipHash *int) {
+	flip := func(h common.Hash) *common.Hash {
+		h[m.Other%common.HashLength] ^= 0xff
+		return &h
+	}
+	switch m.Field {
+	case "parentHash":
+		custom.ParentHash = flip(base.ParentHash)
+		changed(true)
+	case "stateRoot":
+		custom.StateRoot = flip(base.StateRoot)
+		changed(true)
+	case "receiptsRoot":
+		custom.ReceiptsRoot = flip(base.ReceiptsRoot)
+		changed(true)
+	case "prevRandao":
+		custom.PrevRandao = flip(base.Random)
+		changed(false)
+	case "feeRecipient":
+		addr := base.FeeRecipient
+		addr[m.Other%common.AddressLength] ^= 0xff
+		custom.FeeRecipient = &addr
+		changed(false)
+	case "blockHash":
+		*flipHash = m.Other
+		changed(true)
+	case "number":
+		if v := addDelta(base.Number, m.Delta); v != base.Number {
+			custom.Number = &v
+			changed(true)
+		}
+	case "gasLimit":
+		v := addDelta(base.GasLimit, m.Delta)
+		if v == base.GasLimit {
+			return
+		}
+		custom.GasLimit = &v
+		// The gas limit can change by 1/1024 of the parent gas limit, which is
+		// within 1/1024 of the gas limit of the base payload.
+		diff := new(big.Int).Sub(new(big.Int).SetUint64(v), new(big.Int).SetUint64(base.GasLimit))
+		changed(diff.CmpAbs(big.NewInt(int64(base.GasLimit/512))) >= 0 || v < base.GasUsed)
+	case "gasUsed":
+		if v := addDelta(base.GasUsed, m.Delta); v != base.GasUsed {
+			custom.GasUsed = &v
+			changed(true)
+		}
+	case "timestamp":
+		if v := addDelta(base.Timestamp, m.Delta); v != base.Timestamp {
+			custom.Timestamp = &v
+			// Later timestamps are valid unless they cross a fork boundary.
+			changed(v < base.Timestamp)
+		}
+	case "baseFeePerGas":
+		if base.BaseFeePerGas == nil {
+			return
+		}
+		v := new(big.Int).Add(base.BaseFeePerGas, big.NewInt(m.Delta))
+		if v.Sign() < 0 {
+			v.SetUint64(0)
+		}
+		if v.Cmp(base.BaseFeePerGas) != 0 {
+			custom.BaseFeePerGas = v
+			changed(true)
+		}
+	case "blobGasUsed":
+		if base.BlobGasUsed == nil {
+			return
+		}
+		if v := addDelta(*base.BlobGasUsed, m.Delta); v != *base.BlobGasUsed {
+			custom.BlobGasUsed = &v
+			changed(true)
+		}
+	case "excessBlobGas":
+		if base.ExcessBlobGas == nil {
+			return
+		}
+		if v := addDelta(*base.ExcessBlobGas, m.Delta); v != *base.ExcessBlobGas {
+			custom.ExcessBlobGas = &v
+			changed(true)
+		}
+	case "extraData":
+		data := []byte(m.Data)
+		custom.ExtraData = &data
+		changed(len(data) > 32)
+	}
+}
+
+// mutateList applies a list operation to a copy of the list. It reports whether
+// the list was changed, and whether the change is certainly invalid for lists in
+// which every element affects the state.
+func mutateList[T any](list []T, m Mutation, corrupt func(T) T, newElem func() T) ([]T, bool, bool) {
+	if m.Op == "append" {
+		return append(append([]T{}, list...), newElem()), true, true
+	}
+	if len(list) == 0 {
+		return list, false, false
+	}
+	i := m.Index % len(list)
+	result := append([]T{}, list...)
+	switch m.Op {
+	case "drop":
+		return append(result[:i], result[i+1:]...), true, true
+	case "duplicate":
+		dup := append(append([]T{}, list[:i+1]...), list[i:]...)
+		return dup, true, true
+	case "swap":
+		if len(list) < 2 {
+			return list, false, false
+		}
+		j := m.Other % len(list)
+		if j == i {
+			j = (i + 1) % len(list)
+		}
+		result[i], result[j] = result[j], result[i]
+		return result, true, false
+	case "corrupt":
+		if corrupt == nil {
+			return list, false, false
+		}
+		result[i] = corrupt(result[i])
+		return result, true, true
+	}
+	return list, false, false
+}
+
+// corruptTransaction flips a byte of an encoded transaction, starting at the given
+// position. Positions at which the result can't be decoded are skipped, since the
+// transactions root of the payload is calculated from the decoded transactions.
+func corruptTransaction(tx []byte, pos int) []byte {
+	for i := 0; i < len(tx); i++ {
+		result := append([]byte{}, tx...)
+		result[(pos+i)%len(tx)] ^= 0xff
+		if new(types.Transaction).UnmarshalBinary(result) == nil {
+			return result
+		}
+	}
+	return tx
+}
+
+// unsignedTransaction creates a transaction without signature, which can't be
+// included in a valid block.
+func unsignedTransaction(m Mutation) []byte {
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    uint64(m.Other),
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+		Data:     m.Data,
+	})
+	data, _ := tx.MarshalBinary()
+	return data
+}
+
+func addDelta(v uint64, delta int64) uint64 {
+	if delta < 0 {
+		if uint64(-delta) > v {
+			return 0
+		}
+		return v - uint64(-delta)
+	}
+	return v + uint64(delta)
+}
+
+func copyBytesList(list [][]byte) [][]byte {
+	result := make([][]byte, len(list))
+	copy(result, list)
+	return result
+}
+
+func copyWithdrawals(list []*types.Withdrawal) []*types.Withdrawal {
+	if list == nil {
+		return nil
+	}
+	result := make([]*types.Withdrawal, len(list))
+	for i, w := range list {
+		cpy := *w
+		result[i] = &cpy
+	}
+	return result
+}
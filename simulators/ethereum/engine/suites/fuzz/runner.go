@@ -0,0 +1,317 @@
+package suite_fuzz
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+)
+
+// Invariants checked by the runner
+const (
+	// The client returned an error which is not allowed by the specification, or
+	// failed to respond
+	InvariantRPCError = "rpc-error"
+	// The payload status does not match the latest valid hash, or is not allowed
+	// for the method
+	InvariantStatus = "status"
+	// An invalid payload or forkchoice was accepted as VALID
+	InvariantInvalidAccepted = "invalid-accepted"
+	// A valid forkchoice update of the CL Mocker chain was rejected
+	InvariantValidRejected = "valid-rejected"
+	// The head of the client is a block which must not be valid
+	InvariantInvalidHead = "invalid-head"
+	// The CL Mocker aborted block production
+	InvariantAborted = "aborted"
+)
+
+// Failure is a violated invariant.
+type Failure struct {
+	// Index of the action during which the invariant was violated
+	Action    int
+	Invariant string
+	Message   string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("action %d: %s: %s", f.Action+1, f.Invariant, f.Message)
+}
+
+// runner executes a case using a CL Mocker which serves a single client.
+type runner struct {
+	cl       *clmock.CLMocker
+	ec       client.EngineClient
+	txSender *helper.TransactionSender
+	ctx      context.Context
+
+	// Hashes of payloads which must not be valid, latest last
+	invalid []common.Hash
+	// Execution requests of the invalid payloads, for computing header hashes
+	requests map[common.Hash][][]byte
+	// Index of the action being executed
+	action  int
+	failure *Failure
+}
+
+func newRunner(cl *clmock.CLMocker, ec client.EngineClient, txSender *helper.TransactionSender) *runner {
+	return &runner{cl: cl, ec: ec, txSender: txSender, ctx: cl.TestContext, requests: make(map[common.Hash][][]byte)}
+}
+
+func (r *runner) fail(invariant, format string, args ...interface{}) {
+	if r.failure == nil {
+		r.failure = &Failure{Action: r.action, Invariant: invariant, Message: fmt.Sprintf(format, args...)}
+	}
+}
+
+// run executes the actions of the case in order, until the first violation.
+func (r *runner) run(c *Case) *Failure {
+	for i, a := range c.Actions {
+		r.action = i
+		r.cl.Logf("INFO: Fuzz action %d: %s", i+1, a)
+		switch {
+		case a.ProduceBlocks > 0:
+			r.cl.ProduceBlocks(int(a.ProduceBlocks), clmock.BlockProcessCallbacks{
+				OnPayloadProducerSelected: r.sendTransactions,
+			})
+		case len(a.MutatePayload) > 0:
+			r.cl.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+				OnPayloadProducerSelected: r.sendTransactions,
+				OnGetPayload: func() {
+					r.sendMutatedPayload(a.MutatePayload)
+				},
+			})
+		default:
+			r.sendForkchoiceUpdates(a.Forkchoice)
+		}
+		if r.failure == nil {
+			r.checkHead()
+		}
+		if r.failure != nil {
+			return r.failure
+		}
+	}
+	return nil
+}
+
+// sendTransactions sends transactions of all types supported by the fork, so the
+// payload mutations have lists to work on.
+func (r *runner) sendTransactions() {
+	_, err := r.txSender.SendNextTransactions(r.ctx, r.cl.NextBlockProducer, &helper.BaseTransactionCreator{
+		Recipient:  &globals.PrevRandaoContractAddr,
+		Amount:     big.NewInt(1),
+		GasLimit:   75000,
+		BlobCount:  big.NewInt(1),
+		TxType:     helper.UnspecifiedTransactionType,
+		ForkConfig: r.cl.ForkConfig,
+	}, 3)
+	if err != nil {
+		r.fail(InvariantRPCError, "unable to send transactions: %v", err)
+	}
+}
+
+func (r *runner) sendMutatedPayload(mutations []Mutation) {
+	base := r.cl.LatestPayloadBuilt
+	result, err := applyMutations(&base, mutations)
+	if err != nil {
+		r.fail(InvariantAborted, "unable to mutate payload: %v", err)
+		return
+	}
+	if !result.changed {
+		r.cl.Logf("INFO: Mutations do not apply to the payload, skipping")
+		return
+	}
+	payload := result.payload
+	r.requests[payload.BlockHash] = payload.ExecutionRequests
+	if result.invalid {
+		r.invalid = append(r.invalid, payload.BlockHash)
+	}
+
+	version := r.cl.NewPayloadVersion(payload.Timestamp)
+	status, err := r.ec.NewPayload(r.ctx, version, payload)
+	if err != nil {
+		// Mutations can remove fields required by the method version, or move the
+		// payload into another fork.
+		r.checkError(err, "engine_newPayloadV%d", version, -32602, -38005)
+		return
+	}
+	r.cl.Logf("INFO: Mutated payload %v: %s", payload.BlockHash, status.Status)
+	r.checkStatus(status, version, payload.BlockHash)
+	switch {
+	case status.Status == api.INVALID:
+		r.invalid = append(r.invalid, payload.BlockHash)
+	case result.invalid && status.Status == api.VALID:
+		r.fail(InvariantInvalidAccepted, "payload with %v returned VALID", mutations)
+	}
+}
+
+// checkStatus checks the relation between status and latest valid hash.
+func (r *runner) checkStatus(status api.PayloadStatusV1, version int, blockHash common.Hash) {
+	lvh := status.LatestValidHash
+	switch status.Status {
+	case api.VALID:
+		if lvh == nil || *lvh != blockHash {
+			r.fail(InvariantStatus, "VALID with latestValidHash %v, expected %v", lvh, blockHash)
+		}
+	case api.INVALID:
+		if lvh != nil && *lvh != (common.Hash{}) {
+			if *lvh == blockHash {
+				r.fail(InvariantStatus, "INVALID with latestValidHash of the invalid block")
+			} else if r.isInvalid(*lvh) {
+				r.fail(InvariantStatus, "INVALID with latestValidHash %v of an invalid block", lvh)
+			}
+		}
+	case api.SYNCING, api.ACCEPTED:
+		if lvh != nil {
+			r.fail(InvariantStatus, "%s with latestValidHash %v", status.Status, lvh)
+		}
+	case "INVALID_BLOCK_HASH":
+		if version > 1 {
+			r.fail(InvariantStatus, "INVALID_BLOCK_HASH is not allowed in version %d", version)
+		}
+	default:
+		r.fail(InvariantStatus, "unknown status %q", status.Status)
+	}
+}
+
+// checkError verifies that an error is a JSON-RPC error with one of the allowed codes.
+func (r *runner) checkError(err error, method string, version int, allowed ...int) {
+	if rpcErr, ok := err.(rpc.Error); ok {
+		for _, code := range allowed {
+			if rpcErr.ErrorCode() == code {
+				return
+			}
+		}
+		r.fail(InvariantRPCError, "%s: unexpected error code %d: %v", fmt.Sprintf(method, version), rpcErr.ErrorCode(), err)
+		return
+	}
+	r.fail(InvariantRPCError, "%s: %v", fmt.Sprintf(method, version), err)
+}
+
+func (r *runner) isInvalid(hash common.Hash) bool {
+	for _, h := range r.invalid {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the hash of a block reference. Whether the reference is part of
+// the canonical chain is returned as well.
+func (r *runner) resolve(ref BlockRef) (common.Hash, bool) {
+	fc := r.cl.LatestForkchoice
+	switch ref.Kind {
+	case RefHead:
+		return fc.HeadBlockHash, true
+	case RefAncestor:
+		number := r.cl.LatestExecutedPayload.Number
+		if ref.Depth <= number {
+			if p, ok := r.cl.ExecutedPayloadHistory[number-ref.Depth]; ok {
+				return p.BlockHash, true
+			}
+		}
+		return r.cl.GenesisBlock().Hash(), true
+	case RefSafe:
+		return fc.SafeBlockHash, true
+	case RefFinalized:
+		return fc.FinalizedBlockHash, true
+	case RefInvalid:
+		if len(r.invalid) > 0 {
+			return r.invalid[len(r.invalid)-1], false
+		}
+		return common.Hash{0xff}, false
+	case RefUnknown:
+		return *ref.Hash, false
+	}
+	return common.Hash{}, false
+}
+
+func (r *runner) sendForkchoiceUpdates(calls []ForkchoiceCall) {
+	version := r.cl.ForkchoiceUpdatedVersion(r.cl.LatestExecutedPayload.Timestamp, nil)
+	for _, call := range calls {
+		head, headCanonical := r.resolve(call.Head)
+		safe, _ := r.resolve(call.Safe)
+		finalized, _ := r.resolve(call.Finalized)
+		fcState := &api.ForkchoiceStateV1{HeadBlockHash: head, SafeBlockHash: safe, FinalizedBlockHash: finalized}
+
+		resp, err := r.ec.ForkchoiceUpdated(r.ctx, version, fcState, nil)
+		if err != nil {
+			// Invalid safe and finalized blocks are rejected with an error.
+			r.checkError(err, "engine_forkchoiceUpdatedV%d", version, -38002, -32602)
+			if r.failure != nil {
+				return
+			}
+			continue
+		}
+		r.cl.Logf("INFO: Forkchoice %s: %s", call, resp.PayloadStatus.Status)
+		r.checkStatus(resp.PayloadStatus, version, head)
+		if resp.PayloadID != nil {
+			r.fail(InvariantStatus, "payload ID returned without payload attributes")
+		}
+		switch {
+		case resp.PayloadStatus.Status == api.VALID && r.isInvalid(head):
+			r.fail(InvariantInvalidAccepted, "forkchoice %s with invalid head returned VALID", call)
+		case resp.PayloadStatus.Status == api.VALID && call.Head.Kind == RefUnknown:
+			r.fail(InvariantInvalidAccepted, "forkchoice %s with unknown head returned VALID", call)
+		case resp.PayloadStatus.Status == api.INVALID && headCanonical:
+			r.fail(InvariantValidRejected, "forkchoice %s with canonical head returned INVALID", call)
+		}
+		if r.failure != nil {
+			return
+		}
+		r.checkHead()
+		if r.failure != nil {
+			return
+		}
+	}
+
+	// Restore the forkchoice of the CL Mocker, which must always be valid.
+	resp, err := r.ec.ForkchoiceUpdated(r.ctx, version, &r.cl.LatestForkchoice, nil)
+	if err != nil {
+		r.fail(InvariantValidRejected, "unable to restore forkchoice: %v", err)
+	} else if resp.PayloadStatus.Status != api.VALID {
+		r.fail(InvariantValidRejected, "restoring forkchoice returned %s", resp.PayloadStatus.Status)
+	}
+}
+
+// checkHead verifies that the client is responsive and its head is not a block
+// which must be invalid.
+func (r *runner) checkHead() {
+	header, err := r.ec.HeaderByNumber(r.ctx, nil)
+	if err != nil {
+		r.fail(InvariantRPCError, "unable to get head: %v", err)
+		return
+	}
+	for _, hash := range r.invalid {
+		if r.cl.HeaderHash(header, r.requests[hash]) == hash {
+			r.fail(InvariantInvalidHead, "head moved to invalid block %v", hash)
+			return
+		}
+	}
+}
+
+// runIsolated runs f in a separate goroutine, so that aborting the test in the CL
+// Mocker does not end the fuzz test.
+func runIsolated(f func() *Failure, action func() int) (failure *Failure) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		aborted := true
+		defer func() {
+			if aborted {
+				failure = &Failure{Action: action(), Invariant: InvariantAborted, Message: "block production was aborted"}
+			}
+		}()
+		failure = f()
+		aborted = false
+	}()
+	<-done
+	return failure
+}
@@ -0,0 +1,129 @@
+package suite_fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+)
+
+// EnvSeed is the environment variable which sets the base seed of the generated
+// cases. The simulator sets it from the fuzz_seed build argument, and the seed is
+// derived from the test random seed if it is empty.
+const EnvSeed = "HIVE_ENGINE_FUZZ_SEED"
+
+const (
+	// Number of generated cases per fork
+	casesPerFork = 4
+	// Number of actions of each generated case
+	caseLength = 16
+	// Maximum number of clients started to minimize a failing case
+	maxMinimizeTrials = 12
+)
+
+// Tests contains the generated fuzz cases.
+var Tests []test.Spec
+
+func init() {
+	for _, fork := range []config.Fork{config.Shanghai, config.Cancun} {
+		for i := 0; i < casesPerFork; i++ {
+			fork, i := fork, i
+			Tests = append(Tests, test.BaseSpec{
+				Name:           fmt.Sprintf("Fuzz Case %d", i+1),
+				About:          "Executes a generated sequence of mutated payloads and forkchoice updates, and checks the responses against the Engine API invariants.",
+				MainFork:       fork,
+				TimeoutSeconds: 900,
+				Run: func(t *test.Env) {
+					seed := baseSeed(t) + int64(i)
+					t.Logf("INFO: Fuzz seed %d", seed)
+					runCase(t, Generate(seed, fork, caseLength))
+				},
+			})
+		}
+	}
+}
+
+// ReplaySpecs returns test specs which execute saved cases.
+func ReplaySpecs(cases []*Case) []test.Spec {
+	specs := make([]test.Spec, len(cases))
+	for i, c := range cases {
+		c := c
+		specs[i] = test.BaseSpec{
+			Name:           c.Name,
+			About:          "Replays a saved fuzz case.",
+			MainFork:       c.Fork,
+			TimeoutSeconds: 900,
+			Run: func(t *test.Env) {
+				runCase(t, c)
+			},
+		}
+	}
+	return specs
+}
+
+func baseSeed(t *test.Env) int64 {
+	if val := os.Getenv(EnvSeed); val != "" {
+		seed, err := strconv.ParseInt(val, 10, 64)
+		if err == nil {
+			return seed
+		}
+		t.Logf("Warning: invalid %s value %q", EnvSeed, val)
+	}
+	return t.Rand.Int63()
+}
+
+func runCase(t *test.Env, c *Case) {
+	t.CLMock.WaitForTTD()
+
+	r := newRunner(t.CLMock, t.Engine, t.TransactionSender)
+	failure := runIsolated(func() *Failure { return r.run(c) }, func() int { return r.action })
+	if failure == nil {
+		return
+	}
+	t.Errorf("FAIL (%s): %v", t.TestName, failure)
+
+	// Minimize the case by running shorter versions against new clients.
+	minimized, minFailure := Minimize(c, failure, func(candidate *Case) *Failure {
+		return runTrial(t, candidate)
+	}, maxMinimizeTrials)
+	minimized.Name = fmt.Sprintf("%s (minimized)", c.Name)
+	t.Logf("INFO (%s): Minimized case from %d to %d actions, failure: %v", t.TestName, len(c.Actions), len(minimized.Actions), minFailure)
+
+	data, err := minimized.Marshal()
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to encode case: %v", t.TestName, err)
+	}
+	t.Logf("INFO (%s): Minimized case:\n%s", t.TestName, data)
+}
+
+// runTrial executes a case against a new client with its own CL Mocker.
+func runTrial(t *test.Env, c *Case) *Failure {
+	ec, err := hive_rpc.HiveRPCEngineStarter{}.StartClient(t.T, t.TestContext, t.Genesis, t.ClientParams, t.ClientFiles)
+	if err != nil {
+		t.Logf("INFO (%s): Unable to start client for minimization: %v", t.TestName, err)
+		return nil
+	}
+	defer ec.Close()
+
+	cl := clmock.NewCLMocker(t.T, t.Genesis, t.ForkConfig, rand.New(rand.NewSource(c.Seed)))
+	cl.SlotsToSafe = t.CLMock.SlotsToSafe
+	cl.SlotsToFinalized = t.CLMock.SlotsToFinalized
+	cl.BlockTimestampIncrement = t.CLMock.BlockTimestampIncrement
+	cl.PayloadProductionClientDelay = t.CLMock.PayloadProductionClientDelay
+	cl.TestContext = t.TestContext
+	cl.TimeoutContext = t.TimeoutContext
+	cl.AddEngineClient(ec)
+
+	r := newRunner(cl, ec, helper.NewTransactionSender(globals.TestAccounts, false))
+	return runIsolated(func() *Failure {
+		cl.WaitForTTD()
+		return r.run(c)
+	}, func() int { return r.action })
+}
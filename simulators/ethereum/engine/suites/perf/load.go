@@ -0,0 +1,127 @@
+package suite_perf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+// Genesis contract which stores 1 in the storage slot given by the first word of
+// the calldata.
+var storageContractAddr = common.HexToAddress("0000000000000000000000000000000000000317")
+
+// Recipient of value transfers and blob transactions, which must not be a
+// precompile since the transactions have no gas for the call.
+var recipientAddr = common.HexToAddress("00000000000000000000000000000000000000ff")
+
+// Load is the number of transactions of each kind sent before every block.
+type Load struct {
+	// Value transfers
+	Transfers uint64
+	// Calls which write a new storage slot
+	StorageWrites uint64
+	// Contract creations using the gas limit of ContractCreationGas
+	ContractCreations   uint64
+	ContractCreationGas uint64
+	// Blob transactions with one blob each, only sent since Cancun
+	BlobTransactions uint64
+}
+
+func (l Load) String() string {
+	var parts []string
+	add := func(count uint64, kind string) {
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, kind))
+		}
+	}
+	add(l.Transfers, "transfers")
+	add(l.StorageWrites, "storage writes")
+	add(l.ContractCreations, "contract creations")
+	add(l.BlobTransactions, "blob txs")
+	if len(parts) == 0 {
+		return "no transactions"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Gas returns the approximate gas used by the transactions of a block.
+func (l Load) Gas() uint64 {
+	return l.Transfers*21000 + l.StorageWrites*storageWriteGasUsed + l.ContractCreations*l.ContractCreationGas + l.BlobTransactions*21000
+}
+
+// Gas limit and approximate gas used by a storage write: the intrinsic gas, the
+// calldata and setting a new slot.
+const (
+	storageWriteGas     = 50000
+	storageWriteGasUsed = 21000 + 32*16 + 22100
+)
+
+type txBatch struct {
+	count   uint64
+	creator helper.TransactionCreator
+}
+
+// send sends the transactions of a block to the client.
+func (l Load) send(ctx context.Context, sender *helper.TransactionSender, ec client.EngineClient, forkConfig *config.ForkConfig, timestamp uint64) error {
+	batches := []txBatch{
+		{l.Transfers, &helper.BaseTransactionCreator{
+			Recipient:  &recipientAddr,
+			Amount:     big.NewInt(1),
+			GasLimit:   21000,
+			TxType:     helper.DynamicFeeTxOnly,
+			ForkConfig: forkConfig,
+		}},
+		{l.StorageWrites, &storageWriteTransactionCreator{helper.BaseTransactionCreator{
+			Recipient:  &storageContractAddr,
+			GasLimit:   storageWriteGas,
+			TxType:     helper.DynamicFeeTxOnly,
+			ForkConfig: forkConfig,
+		}}},
+		{l.ContractCreations, &helper.BigContractTransactionCreator{BaseTransactionCreator: helper.BaseTransactionCreator{
+			GasLimit:   l.ContractCreationGas,
+			TxType:     helper.DynamicFeeTxOnly,
+			ForkConfig: forkConfig,
+		}}},
+	}
+	if forkConfig.IsCancun(timestamp) {
+		batches = append(batches, txBatch{l.BlobTransactions, &helper.BaseTransactionCreator{
+			Recipient:  &recipientAddr,
+			GasLimit:   21000,
+			BlobCount:  big.NewInt(1),
+			TxType:     helper.BlobTxOnly,
+			ForkConfig: forkConfig,
+		}})
+	}
+	for _, b := range batches {
+		if b.count == 0 {
+			continue
+		}
+		if _, err := sender.SendNextTransactionsBatch(ctx, ec, b.creator, b.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates calls to the storage contract which write a slot derived from the sender
+// and nonce, so every transaction creates a new slot.
+type storageWriteTransactionCreator struct {
+	helper.BaseTransactionCreator
+}
+
+func (tc *storageWriteTransactionCreator) MakeTransaction(sender helper.SenderAccount, nonce uint64, blockTimestamp uint64) (typ.Transaction, error) {
+	// The payload is set on a copy, so the creator can be shared by senders.
+	base := tc.BaseTransactionCreator
+	addr := sender.GetAddress()
+	base.Payload = crypto.Keccak256(addr[:], binary.BigEndian.AppendUint64(nil, nonce))
+	return base.MakeTransaction(sender, nonce, blockTimestamp)
+}
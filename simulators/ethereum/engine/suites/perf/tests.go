@@ -0,0 +1,168 @@
+package suite_perf
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/metrics"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+)
+
+// EnvBlocks is the environment variable which sets the number of measured blocks
+// of each test. The simulator sets it from the perf_blocks build argument.
+const EnvBlocks = "HIVE_ENGINE_PERF_BLOCKS"
+
+const (
+	defaultBlocks = 50
+	warmupBlocks  = 5
+	// Gas limit of the genesis block, which the clients keep by default
+	genesisGasLimit = 30_000_000
+)
+
+// PerfTest produces blocks with a fixed transaction load and measures the latencies
+// of the Engine API calls of the client.
+type PerfTest struct {
+	test.BaseSpec
+	Load Load
+}
+
+func (s PerfTest) WithMainFork(fork config.Fork) test.Spec {
+	specCopy := s
+	specCopy.MainFork = fork
+	return specCopy
+}
+
+func (s PerfTest) GetAbout() string {
+	return fmt.Sprintf("Produces blocks containing %s, and measures the latencies of engine_newPayload, engine_forkchoiceUpdated and engine_getPayload and the block processing throughput.", s.Load)
+}
+
+func (s PerfTest) GetGenesis() *core.Genesis {
+	genesis := s.BaseSpec.GetGenesis()
+	genesis.GasLimit = genesisGasLimit
+	return genesis
+}
+
+// Tests contains the performance tests of every load on each fork.
+var Tests []test.Spec
+
+func init() {
+	loads := []struct {
+		name string
+		load Load
+	}{
+		{"Empty Blocks", Load{}},
+		{"Value Transfers", Load{Transfers: 400}},
+		{"Storage Writes", Load{StorageWrites: 300}},
+		{"Contract Creations", Load{ContractCreations: 10, ContractCreationGas: 1_000_000}},
+		{"Blob Transactions", Load{BlobTransactions: 6}},
+		{"Mixed Load", Load{Transfers: 150, StorageWrites: 100, ContractCreations: 2, ContractCreationGas: 1_000_000, BlobTransactions: 3}},
+	}
+	for _, fork := range []config.Fork{config.Cancun, config.Prague} {
+		for _, l := range loads {
+			Tests = append(Tests, PerfTest{
+				BaseSpec: test.BaseSpec{
+					Name:           fmt.Sprintf("Performance, %s", l.name),
+					MainFork:       fork,
+					TimeoutSeconds: 900,
+				},
+				Load: l.load,
+			})
+		}
+	}
+}
+
+func measuredBlocks(t *test.Env) int {
+	if val := os.Getenv(EnvBlocks); val != "" {
+		blocks, err := strconv.Atoi(val)
+		if err == nil && blocks > 0 {
+			return blocks
+		}
+		t.Logf("Warning: invalid %s value %q", EnvBlocks, val)
+	}
+	return defaultBlocks
+}
+
+func (s PerfTest) Execute(t *test.Env) {
+	if t.HiveEngine == nil {
+		t.Fatalf("FAIL (%s): Client does not support latency measurements", t.TestName)
+	}
+	if tr := t.HiveEngine.EngineTransport(); tr != client.HTTP {
+		t.Fatalf("FAIL (%s): Latency measurements are not supported over the %s transport", t.TestName, tr)
+	}
+	t.CLMock.WaitForTTD()
+
+	produceBlock := func() {
+		t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+			OnPayloadProducerSelected: func() {
+				if err := s.Load.send(t.TestContext, t.TransactionSender, t.CLMock.NextBlockProducer, t.ForkConfig, t.CLMock.GetNextBlockTimestamp()); err != nil {
+					t.Fatalf("FAIL (%s): Unable to send transactions: %v", t.TestName, err)
+				}
+			},
+		})
+	}
+
+	// Warm up the client before measuring, so the first blocks after genesis don't
+	// skew the results.
+	for i := 0; i < warmupBlocks; i++ {
+		produceBlock()
+	}
+
+	rec := metrics.NewRecorder()
+	t.HiveEngine.SetMetricsRecorder(rec)
+	report := &metrics.Report{
+		Test:   s.GetName(),
+		Client: t.Client.Type,
+		Fork:   string(s.GetMainFork()),
+		Blocks: measuredBlocks(t),
+	}
+	start := time.Now()
+	for i := 0; i < report.Blocks; i++ {
+		produceBlock()
+		report.Transactions += len(t.CLMock.LatestExecutedPayload.Transactions)
+		report.GasUsed += t.CLMock.LatestExecutedPayload.GasUsed
+	}
+	elapsed := time.Since(start)
+	t.HiveEngine.SetMetricsRecorder(nil)
+
+	report.Latencies = rec.Summaries()
+	report.BlocksPerSecond = float64(report.Blocks) / elapsed.Seconds()
+	var newPayloadTime time.Duration
+	for _, l := range report.Latencies {
+		if strings.HasPrefix(l.Method, "engine_newPayload") {
+			newPayloadTime += rec.Total(l.Method)
+		}
+	}
+	if newPayloadTime > 0 {
+		report.MGasPerSecond = float64(report.GasUsed) / 1e6 / newPayloadTime.Seconds()
+		report.TxPerSecond = float64(report.Transactions) / newPayloadTime.Seconds()
+	}
+	if expected := uint64(report.Blocks) * s.Load.Gas(); report.GasUsed < expected*9/10 {
+		t.Logf("Warning (%s): Blocks used %d gas, expected about %d, the client did not include all transactions", t.TestName, report.GasUsed, expected)
+	}
+
+	logReport(t, report)
+	line, err := report.LogLine()
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to encode report: %v", t.TestName, err)
+	}
+	t.Logf("%s", line)
+}
+
+// logReport logs the report in readable form.
+func logReport(t *test.Env, r *metrics.Report) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d blocks, %d transactions, %d gas\n", r.Blocks, r.Transactions, r.GasUsed)
+	fmt.Fprintf(&b, "newPayload throughput: %.2f Mgas/s, %.2f tx/s; %.2f blocks/s overall\n", r.MGasPerSecond, r.TxPerSecond, r.BlocksPerSecond)
+	fmt.Fprintf(&b, "%-36s %6s %9s %9s %9s %9s\n", "method", "count", "p50 ms", "p95 ms", "p99 ms", "max ms")
+	for _, l := range r.Latencies {
+		fmt.Fprintf(&b, "%-36s %6d %9.2f %9.2f %9.2f %9.2f\n", l.Method, l.Count, l.P50, l.P95, l.P99, l.Max)
+	}
+	t.Logf("INFO (%s): Performance results:\n%s", t.TestName, b.String())
+}
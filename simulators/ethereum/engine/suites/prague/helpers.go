@@ -0,0 +1,103 @@
+package suite_prague
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config/prague"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+	"github.com/holiman/uint256"
+)
+
+// Fee paid for every request added to the system contracts queue. The fee is 1 wei
+// while the queue is empty, any excess is kept by the contract.
+var REQUEST_FEE = big.NewInt(1)
+
+// Withdrawal request as sent to the EIP-7002 system contract.
+type WithdrawalRequest struct {
+	ValidatorPubkey [48]byte
+	Amount          uint64
+}
+
+// Transaction input of the withdrawal request.
+func (r WithdrawalRequest) Input() []byte {
+	input := make([]byte, 0, prague.WITHDRAWAL_REQUEST_INPUT_SIZE)
+	input = append(input, r.ValidatorPubkey[:]...)
+	return binary.BigEndian.AppendUint64(input, r.Amount)
+}
+
+// Execution request expected in the payload once the request is dequeued.
+// The amount is encoded as little endian.
+func (r WithdrawalRequest) ExecutionRequest(source common.Address) []byte {
+	req := []byte{prague.WITHDRAWAL_REQUEST_TYPE}
+	req = append(req, source[:]...)
+	req = append(req, r.ValidatorPubkey[:]...)
+	return binary.LittleEndian.AppendUint64(req, r.Amount)
+}
+
+// Consolidation request as sent to the EIP-7251 system contract.
+type ConsolidationRequest struct {
+	SourcePubkey [48]byte
+	TargetPubkey [48]byte
+}
+
+// Transaction input of the consolidation request.
+func (r ConsolidationRequest) Input() []byte {
+	input := make([]byte, 0, prague.CONSOLIDATION_REQUEST_INPUT_SIZE)
+	input = append(input, r.SourcePubkey[:]...)
+	return append(input, r.TargetPubkey[:]...)
+}
+
+// Execution request expected in the payload once the request is dequeued.
+func (r ConsolidationRequest) ExecutionRequest(source common.Address) []byte {
+	req := []byte{prague.CONSOLIDATION_REQUEST_TYPE}
+	req = append(req, source[:]...)
+	req = append(req, r.SourcePubkey[:]...)
+	return append(req, r.TargetPubkey[:]...)
+}
+
+// Transaction creator for requests to one of the system contracts.
+func requestTxCreator(contract common.Address, input []byte) helper.TransactionCreator {
+	return &helper.BaseTransactionCreator{
+		Recipient: &contract,
+		Amount:    REQUEST_FEE,
+		Payload:   input,
+		GasLimit:  200000,
+		TxType:    helper.DynamicFeeTxOnly,
+	}
+}
+
+// Creates EIP-7702 transactions which delegate the code of the authority account
+// to the given address, and call the authority in the same transaction.
+type SetCodeTransactionCreator struct {
+	Authority *ecdsa.PrivateKey
+	// Nonce of the authority account when the authorization is applied
+	AuthorityNonce uint64
+	Delegate       common.Address
+	GasLimit       uint64
+	Payload        []byte
+}
+
+func (tc *SetCodeTransactionCreator) MakeTransaction(sender helper.SenderAccount, nonce uint64, blockTimestamp uint64) (typ.Transaction, error) {
+	auth, err := typ.SignSetCodeAuthorization(tc.Authority, globals.ChainID, tc.Delegate, tc.AuthorityNonce)
+	if err != nil {
+		return nil, err
+	}
+	tx := &typ.SetCodeTx{
+		ChainID:   uint256.MustFromBig(globals.ChainID),
+		Nonce:     nonce,
+		GasTipCap: uint256.MustFromBig(globals.GasTipPrice),
+		GasFeeCap: uint256.MustFromBig(globals.GasPrice),
+		Gas:       tc.GasLimit,
+		To:        crypto.PubkeyToAddress(tc.Authority.PublicKey),
+		Value:     new(uint256.Int),
+		Data:      tc.Payload,
+		AuthList:  []typ.SetCodeAuthorization{auth},
+	}
+	return typ.SignSetCodeTx(tx, sender.GetKey())
+}
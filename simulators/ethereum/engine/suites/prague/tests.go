@@ -0,0 +1,267 @@
+// # Test suite for prague tests
+package suite_prague
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config/prague"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+// Contract in the default genesis which executes SSTORE(CALLDATALOAD(0), 1)
+var SSTORE_CONTRACT_ADDRESS = common.HexToAddress("0x0000000000000000000000000000000000000317")
+
+// Execution specification reference:
+// https://github.com/ethereum/execution-apis/blob/main/src/engine/prague.md
+
+// List of all prague tests
+var Tests = []test.Spec{
+	test.BaseSpec{
+		Name: "Prague Fork on Block 2",
+		About: `
+		Tests the transition to the Prague fork after one Cancun block.
+
+		Verifications performed:
+		- engine_newPayloadV4 is rejected with 'Unsupported fork' for Cancun payloads
+		- engine_newPayloadV3 is rejected with 'Unsupported fork' for Prague payloads
+		- engine_getPayloadV4 returns an empty list of execution requests
+		`,
+		MainFork:   config.Prague,
+		ForkHeight: 2,
+		Run:        pragueForkTransition,
+	},
+	test.BaseSpec{
+		Name: "Invalid Execution Requests",
+		About: `
+		Sends Prague payloads with malformed or modified execution requests.
+
+		Verifications performed:
+		- Missing requests, requests without data and unordered request types
+		  are rejected with 'Invalid params'
+		- Requests which don't match the requests hash of the block
+		  are rejected with INVALID status
+		`,
+		MainFork: config.Prague,
+		Run:      invalidExecutionRequests,
+	},
+	test.BaseSpec{
+		Name: "Withdrawal Request",
+		About: `
+		Sends an EIP-7002 withdrawal request to the system contract and verifies
+		it is included in the execution requests of the payload.
+		`,
+		MainFork: config.Prague,
+		Run:      withdrawalRequest,
+	},
+	test.BaseSpec{
+		Name: "Consolidation Request",
+		About: `
+		Sends an EIP-7251 consolidation request to the system contract and verifies
+		it is included in the execution requests of the payload.
+		`,
+		MainFork: config.Prague,
+		Run:      consolidationRequest,
+	},
+	test.BaseSpec{
+		Name: "Set Code Transaction",
+		About: `
+		Sends an EIP-7702 transaction which delegates an account to a contract
+		which writes to storage, and calls the account in the same transaction.
+		Verifies the storage of the delegating account is modified.
+		`,
+		MainFork: config.Prague,
+		Run:      setCodeTransaction,
+	},
+	test.BaseSpec{
+		Name: "Block Hash History Contract",
+		About: `
+		Verifies the EIP-2935 history contract stores the parent hash of every
+		block produced after the fork.
+		`,
+		MainFork:   config.Prague,
+		ForkHeight: 2,
+		Run:        blockHashHistory,
+	},
+}
+
+func pragueForkTransition(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	// Cancun payloads must not be accepted by newPayloadV4. Block 1 is the
+	// only block before the fork.
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			if t.ForkConfig.IsPrague(t.CLMock.LatestPayloadBuilt.Timestamp) {
+				t.Fatalf("FAIL (%s): Payload built before the fork is a Prague payload", t.TestName)
+			}
+			payload := t.CLMock.LatestPayloadBuilt
+			payload.ExecutionRequests = [][]byte{}
+			r := t.TestEngine.TestEngineNewPayloadV4(&payload)
+			r.ExpectErrorCode(*globals.UNSUPPORTED_FORK_ERROR)
+		},
+	})
+
+	t.CLMock.ProduceBlocks(2, clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			if !t.ForkConfig.IsPrague(t.CLMock.LatestPayloadBuilt.Timestamp) {
+				t.Fatalf("FAIL (%s): Payload built after the fork is not a Prague payload", t.TestName)
+			}
+			r := t.TestEngine.TestEngineGetPayloadV4(t.CLMock.NextPayloadID)
+			r.ExpectExecutionRequests([][]byte{})
+
+			payload := t.CLMock.LatestPayloadBuilt
+			n := t.TestEngine.TestEngineNewPayloadV3(&payload)
+			n.ExpectErrorCode(*globals.UNSUPPORTED_FORK_ERROR)
+		},
+	})
+}
+
+func invalidExecutionRequests(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	// Valid encodings of a withdrawal and a consolidation request, the contents
+	// are not checked by the execution client.
+	var (
+		withdrawal    = WithdrawalRequest{Amount: 1}.ExecutionRequest(globals.TestAccounts[0].GetAddress())
+		consolidation = ConsolidationRequest{}.ExecutionRequest(globals.TestAccounts[0].GetAddress())
+	)
+
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			for _, tc := range []struct {
+				name     string
+				requests [][]byte
+			}{
+				{"nil requests", nil},
+				{"request without data", [][]byte{{prague.WITHDRAWAL_REQUEST_TYPE}}},
+				{"unordered request types", [][]byte{consolidation, withdrawal}},
+				{"duplicate request types", [][]byte{withdrawal, withdrawal}},
+			} {
+				t.Logf("INFO (%s): Sending payload with %s", t.TestName, tc.name)
+				payload := t.CLMock.LatestPayloadBuilt
+				payload.ExecutionRequests = tc.requests
+				r := t.TestEngine.TestEngineNewPayloadV4(&payload)
+				r.ExpectErrorCode(*globals.INVALID_PARAMS_ERROR)
+			}
+
+			// A well-formed request which was not produced by the block changes
+			// the requests hash, so the block hash no longer matches.
+			payload := t.CLMock.LatestPayloadBuilt
+			payload.ExecutionRequests = append(append([][]byte{}, payload.ExecutionRequests...), withdrawal)
+			r := t.TestEngine.TestEngineNewPayloadV4(&payload)
+			r.ExpectStatus(test.Invalid)
+			r.ExpectLatestValidHash(nil)
+		},
+	})
+}
+
+func withdrawalRequest(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	var (
+		sender  = globals.TestAccounts[0]
+		request = WithdrawalRequest{Amount: 1000000000}
+	)
+	t.Rand.Read(request.ValidatorPubkey[:])
+	sendRequestAndVerify(t, sender, prague.WITHDRAWAL_REQUEST_PREDEPLOY_ADDRESS, request.Input(), request.ExecutionRequest(sender.GetAddress()))
+}
+
+func consolidationRequest(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	var (
+		sender  = globals.TestAccounts[0]
+		request = ConsolidationRequest{}
+	)
+	t.Rand.Read(request.SourcePubkey[:])
+	t.Rand.Read(request.TargetPubkey[:])
+	sendRequestAndVerify(t, sender, prague.CONSOLIDATION_REQUEST_PREDEPLOY_ADDRESS, request.Input(), request.ExecutionRequest(sender.GetAddress()))
+}
+
+// Sends a request to a system contract and verifies the next payload contains
+// the expected execution request.
+func sendRequestAndVerify(t *test.Env, sender *globals.TestAccount, contract common.Address, input []byte, expected []byte) {
+	tx, err := t.SendTransaction(t.TestContext, sender, t.Engine, requestTxCreator(contract, input))
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to send request transaction: %v", t.TestName, err)
+	}
+
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			r := t.TestEngine.TestEngineGetPayloadV4(t.CLMock.NextPayloadID)
+			r.ExpectExecutionRequests([][]byte{expected})
+		},
+	})
+	r := t.TestEngine.TestTransactionReceipt(tx.Hash())
+	r.ExpectBlockHash(t.CLMock.LatestExecutedPayload.BlockHash)
+
+	// The queue is empty again, so the next payload contains no requests.
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			r := t.TestEngine.TestEngineGetPayloadV4(t.CLMock.NextPayloadID)
+			r.ExpectExecutionRequests([][]byte{})
+		},
+	})
+}
+
+func setCodeTransaction(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	// The authority is a new account, it doesn't need any balance since the
+	// transaction is paid for by the sender.
+	authority, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to generate key: %v", t.TestName, err)
+	}
+	authorityAddress := crypto.PubkeyToAddress(authority.PublicKey)
+
+	tx, err := t.SendNextTransaction(t.TestContext, t.Engine, &SetCodeTransactionCreator{
+		Authority: authority,
+		Delegate:  SSTORE_CONTRACT_ADDRESS,
+		GasLimit:  100000,
+	})
+	if err != nil {
+		t.Fatalf("FAIL (%s): Unable to send set code transaction: %v", t.TestName, err)
+	}
+	if tx.Type() != typ.SetCodeTxType {
+		t.Fatalf("FAIL (%s): Unexpected transaction type: %d", t.TestName, tx.Type())
+	}
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{})
+
+	r := t.TestEngine.TestTransactionReceipt(tx.Hash())
+	r.ExpectBlockHash(t.CLMock.LatestExecutedPayload.BlockHash)
+
+	// The delegated code runs in the context of the authority.
+	s := t.TestEngine.TestStorageAt(authorityAddress, common.Hash{}, nil)
+	s.ExpectBigIntStorageEqual(big.NewInt(1))
+	s = t.TestEngine.TestStorageAt(SSTORE_CONTRACT_ADDRESS, common.Hash{}, nil)
+	s.ExpectBigIntStorageEqual(big.NewInt(0))
+}
+
+func blockHashHistory(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	historySlot := func(number uint64) common.Hash {
+		return common.BigToHash(new(big.Int).SetUint64(number % prague.HISTORY_SERVE_WINDOW))
+	}
+
+	t.CLMock.ProduceBlocks(5, clmock.BlockProcessCallbacks{
+		OnForkchoiceBroadcast: func() {
+			payload := t.CLMock.LatestExecutedPayload
+			if !t.ForkConfig.IsPrague(payload.Timestamp) {
+				// Nothing is written to the contract before the fork.
+				s := t.TestEngine.TestStorageAt(prague.HISTORY_STORAGE_ADDRESS, historySlot(payload.Number-1), nil)
+				s.ExpectStorageEqual(common.Hash{})
+				return
+			}
+			s := t.TestEngine.TestStorageAt(prague.HISTORY_STORAGE_ADDRESS, historySlot(payload.Number-1), nil)
+			s.ExpectStorageEqual(payload.ParentHash)
+		},
+	})
+}
@@ -0,0 +1,179 @@
+// # Declarative engine test scenarios
+//
+// Scenarios are engine tests described in YAML or JSON files instead of Go code.
+// A scenario is a list of steps, such as producing blocks, sending transactions,
+// sending invalid payloads and re-orgs, which are executed in order.
+package suite_scenario
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a test loaded from a file.
+type Scenario struct {
+	Name  string `yaml:"name"`
+	About string `yaml:"about"`
+
+	// Main fork of the test and block number at which it is activated
+	Fork       config.Fork `yaml:"fork"`
+	ForkHeight uint64      `yaml:"forkHeight"`
+
+	// Test maximum execution time in seconds
+	Timeout int `yaml:"timeout"`
+
+	Steps []StepEntry `yaml:"steps"`
+}
+
+// StepEntry is a step as written in the scenario file. Exactly one of the fields
+// must be set.
+type StepEntry struct {
+	ProduceBlocks    *ProduceBlocks    `yaml:"produceBlocks"`
+	SendTransactions *SendTransactions `yaml:"sendTransactions"`
+	InvalidPayload   *InvalidPayload   `yaml:"invalidPayload"`
+	ReOrg            *ReOrg            `yaml:"reorg"`
+	ExpectHead       *ExpectHead       `yaml:"expectHead"`
+}
+
+// Step returns the step selected by the entry.
+func (e StepEntry) Step() (Step, error) {
+	var steps []Step
+	if e.ProduceBlocks != nil {
+		steps = append(steps, e.ProduceBlocks)
+	}
+	if e.SendTransactions != nil {
+		steps = append(steps, e.SendTransactions)
+	}
+	if e.InvalidPayload != nil {
+		steps = append(steps, e.InvalidPayload)
+	}
+	if e.ReOrg != nil {
+		steps = append(steps, e.ReOrg)
+	}
+	if e.ExpectHead != nil {
+		steps = append(steps, e.ExpectHead)
+	}
+	if len(steps) != 1 {
+		return nil, fmt.Errorf("step must contain exactly one action, found %d", len(steps))
+	}
+	return steps[0], nil
+}
+
+// Parse reads a scenario from YAML or JSON and validates it.
+func Parse(r io.Reader) (*Scenario, error) {
+	var s Scenario
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario has no name")
+	}
+	if s.Fork != config.NA && s.Fork != config.London && s.Fork.PreviousFork() == config.NA {
+		return fmt.Errorf("unknown fork %q", s.Fork)
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	for i, e := range s.Steps {
+		step, err := e.Step()
+		if err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Spec returns the test spec which runs the scenario.
+func (s *Scenario) Spec() test.Spec {
+	return test.BaseSpec{
+		Name:           s.Name,
+		About:          s.About,
+		MainFork:       s.Fork,
+		ForkHeight:     s.ForkHeight,
+		TimeoutSeconds: s.Timeout,
+		Run:            s.run,
+	}
+}
+
+func (s *Scenario) run(t *test.Env) {
+	t.CLMock.WaitForTTD()
+
+	// The steps were checked when the scenario was loaded.
+	steps := make(test.Sequence[*test.Env], len(s.Steps))
+	for i, e := range s.Steps {
+		steps[i], _ = e.Step()
+	}
+	steps.Run(t, t)
+}
+
+// LoadFile loads the scenario in a file.
+func LoadFile(file string) (*Scenario, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return s, nil
+}
+
+// LoadDir loads all scenario files (.yaml, .yml and .json) in a directory. A missing
+// directory is not an error.
+func LoadDir(dir string) ([]test.Spec, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var (
+		specs []test.Spec
+		names = make(map[string]string)
+	)
+	for _, file := range files {
+		s, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := names[s.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate scenario name %q (also in %s)", file, s.Name, prev)
+		}
+		names[s.Name] = file
+		specs = append(specs, s.Spec())
+	}
+	return specs, nil
+}
@@ -0,0 +1,274 @@
+package suite_scenario
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/hive/hivesim"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/config"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+func TestLoadExampleScenarios(t *testing.T) {
+	specs, err := LoadDir("../../scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) == 0 {
+		t.Fatal("no scenarios loaded")
+	}
+	for _, spec := range specs {
+		if spec.GetForkConfig() == nil {
+			t.Errorf("scenario %q has no valid fork config", spec.GetName())
+		}
+	}
+}
+
+func TestParseScenario(t *testing.T) {
+	s, err := Parse(strings.NewReader(`
+name: test
+fork: Cancun
+forkHeight: 2
+steps:
+  - produceBlocks: {count: 2}
+  - sendTransactions:
+      recipient: "0x0000000000000000000000000000000000000317"
+      amount: "0x10"
+      data: "0x01"
+  - invalidPayload:
+      custom:
+        stateRoot: "0x0000000000000000000000000000000000000000000000000000000000000001"
+        gasLimit: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Fork != config.Cancun || s.ForkHeight != 2 || len(s.Steps) != 3 {
+		t.Fatalf("wrong scenario: %+v", s)
+	}
+	send := s.Steps[1].SendTransactions
+	if *send.Recipient != common.HexToAddress("0x317") || send.Amount.ToInt().Uint64() != 16 || len(send.Data) != 1 {
+		t.Fatalf("wrong sendTransactions step: %+v", send)
+	}
+	custom := s.Steps[2].InvalidPayload.Custom.CustomPayloadData()
+	if *custom.StateRoot != common.HexToHash("0x01") || *custom.GasLimit != 100 {
+		t.Fatalf("wrong payload fields: %v", custom)
+	}
+	if _, ok := interface{}(custom).(helper.PayloadCustomizer); !ok {
+		t.Fatal("custom payload data is not a payload customizer")
+	}
+}
+
+func TestParseInvalidScenario(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"name: x\nsteps: []", "scenario has no steps"},
+		{"name: x\nfork: Foo\nsteps: [{produceBlocks: {}}]", `unknown fork "Foo"`},
+		{"name: x\nsteps: [{produceBlocks: {}, expectHead: {}}]", "exactly one action"},
+		{"name: x\nsteps: [{invalidPayload: {field: Foo}}]", `unknown payload field "Foo"`},
+		{"name: x\nsteps: [{invalidPayload: {field: StateRoot, expectStatus: [BAD]}}]", `unknown payload status "BAD"`},
+		{"name: x\nsteps: [{reorg: {to: 0}}]", "genesis"},
+		{"name: x\nsteps: [{produceBlocks: {cnt: 1}}]", "field cnt not found"},
+	}
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("input %q: got error %v, want %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestRunReOrgScenario(t *testing.T) {
+	s, err := LoadFile("../../scenarios/reorg-back-to-canonical.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := s.Spec().(test.BaseSpec)
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.TerminalTotalDifficulty = common.Big0
+	chainConfig.TerminalTotalDifficultyPassed = true
+	chainConfig.ShanghaiTime = new(uint64)
+	*chainConfig.ShanghaiTime = spec.GetForkConfig().ShanghaiTimestamp.Uint64()
+	genesis := &core.Genesis{
+		Config:     &chainConfig,
+		Timestamp:  spec.GetGenesisTimestamp(),
+		GasLimit:   30_000_000,
+		Difficulty: common.Big0,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	engine := newFakeEngine(genesis.ToBlock().Header())
+
+	env := &test.Env{
+		T:              &hivesim.T{},
+		TestName:       spec.GetName(),
+		TimeoutContext: context.Background(),
+		TestContext:    context.Background(),
+		Rand:           rand.New(rand.NewSource(0)),
+		Engine:         engine,
+		Eth:            engine,
+		Genesis:        genesis,
+		ForkConfig:     spec.GetForkConfig(),
+	}
+	env.CLMock = clmock.NewCLMocker(env.T, genesis, env.ForkConfig, env.Rand)
+	spec.ConfigureCLMock(env.CLMock)
+	env.CLMock.PayloadProductionClientDelay = 0
+	env.CLMock.AddEngineClient(engine)
+	env.TestEngine = test.NewTestEngineClient(env, engine)
+
+	// Failures end the goroutine of the scenario without reaching the end.
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		spec.Run(env)
+		done <- true
+	}()
+	if !<-done {
+		t.Fatal("scenario failed")
+	}
+}
+
+// fakeEngine is an in-memory engine client which builds empty blocks, and
+// rejects forkchoice states whose safe or finalized block is not an ancestor
+// of the head.
+type fakeEngine struct {
+	client.EngineClient
+	headers  map[common.Hash]*types.Header
+	payloads map[api.PayloadID]*typ.ExecutableData
+	head     common.Hash
+	status   api.PayloadStatusV1
+}
+
+func newFakeEngine(genesis *types.Header) *fakeEngine {
+	return &fakeEngine{
+		headers:  map[common.Hash]*types.Header{genesis.Hash(): genesis},
+		payloads: make(map[api.PayloadID]*typ.ExecutableData),
+		head:     genesis.Hash(),
+	}
+}
+
+func (e *fakeEngine) ID() string {
+	return "fake"
+}
+
+func (e *fakeEngine) TerminalTotalDifficulty() *big.Int {
+	return common.Big0
+}
+
+func (e *fakeEngine) GetTotalDifficulty(ctx context.Context) (*big.Int, error) {
+	return common.Big0, nil
+}
+
+func (e *fakeEngine) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	h := e.headers[e.head]
+	for number != nil && h != nil && h.Number.Cmp(number) > 0 {
+		h = e.headers[h.ParentHash]
+	}
+	if h == nil || (number != nil && h.Number.Cmp(number) != 0) {
+		return nil, errors.New("not found")
+	}
+	return h, nil
+}
+
+// isAncestor reports whether the block is the head or one of its ancestors.
+func (e *fakeEngine) isAncestor(hash, head common.Hash) bool {
+	for h := e.headers[head]; h != nil; h = e.headers[h.ParentHash] {
+		if h.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *fakeEngine) ForkchoiceUpdated(ctx context.Context, version int, fcState *api.ForkchoiceStateV1, pAttributes *typ.PayloadAttributes) (api.ForkChoiceResponse, error) {
+	parent, ok := e.headers[fcState.HeadBlockHash]
+	if !ok {
+		return api.ForkChoiceResponse{}, errors.New("unknown head")
+	}
+	for _, hash := range []common.Hash{fcState.SafeBlockHash, fcState.FinalizedBlockHash} {
+		if hash != (common.Hash{}) && !e.isAncestor(hash, fcState.HeadBlockHash) {
+			return api.ForkChoiceResponse{}, errors.New("invalid forkchoice state")
+		}
+	}
+	e.head = fcState.HeadBlockHash
+	resp := api.ForkChoiceResponse{
+		PayloadStatus: api.PayloadStatusV1{Status: api.VALID, LatestValidHash: &e.head},
+	}
+	if pAttributes == nil {
+		return resp, nil
+	}
+	header := &types.Header{
+		ParentHash:      parent.Hash(),
+		UncleHash:       types.EmptyUncleHash,
+		Coinbase:        pAttributes.SuggestedFeeRecipient,
+		Root:            parent.Root,
+		TxHash:          types.EmptyTxsHash,
+		ReceiptHash:     types.EmptyReceiptsHash,
+		Difficulty:      common.Big0,
+		Number:          new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:        parent.GasLimit,
+		Time:            pAttributes.Timestamp,
+		MixDigest:       pAttributes.Random,
+		BaseFee:         parent.BaseFee,
+		WithdrawalsHash: new(common.Hash),
+	}
+	*header.WithdrawalsHash = types.DeriveSha(types.Withdrawals(pAttributes.Withdrawals), trie.NewStackTrie(nil))
+	e.headers[header.Hash()] = header
+
+	var id api.PayloadID
+	binary.BigEndian.PutUint64(id[:], uint64(len(e.payloads)))
+	e.payloads[id] = &typ.ExecutableData{
+		ParentHash:    header.ParentHash,
+		FeeRecipient:  header.Coinbase,
+		StateRoot:     header.Root,
+		ReceiptsRoot:  header.ReceiptHash,
+		LogsBloom:     header.Bloom.Bytes(),
+		Random:        header.MixDigest,
+		Number:        header.Number.Uint64(),
+		GasLimit:      header.GasLimit,
+		Timestamp:     header.Time,
+		BaseFeePerGas: header.BaseFee,
+		BlockHash:     header.Hash(),
+		Transactions:  [][]byte{},
+		Withdrawals:   pAttributes.Withdrawals,
+	}
+	resp.PayloadID = &id
+	return resp, nil
+}
+
+func (e *fakeEngine) GetPayload(ctx context.Context, version int, payloadId *api.PayloadID) (typ.ExecutableData, *big.Int, *typ.BlobsBundle, *bool, error) {
+	payload, ok := e.payloads[*payloadId]
+	if !ok {
+		return typ.ExecutableData{}, nil, nil, nil, errors.New("unknown payload")
+	}
+	return *payload, common.Big0, nil, nil, nil
+}
+
+func (e *fakeEngine) NewPayload(ctx context.Context, version int, payload *typ.ExecutableData) (api.PayloadStatusV1, error) {
+	if _, ok := e.headers[payload.BlockHash]; !ok {
+		return api.PayloadStatusV1{}, errors.New("unknown payload")
+	}
+	e.status = api.PayloadStatusV1{Status: api.VALID, LatestValidHash: &payload.BlockHash}
+	return e.status, nil
+}
+
+func (e *fakeEngine) LatestNewPayloadResponse() *api.PayloadStatusV1 {
+	return &e.status
+}
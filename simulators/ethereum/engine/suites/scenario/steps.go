@@ -0,0 +1,396 @@
+package suite_scenario
+
+import (
+	"fmt"
+	"math/big"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/hive/simulators/ethereum/engine/clmock"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/test"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+// Interface to represent a single step of a scenario
+type Step interface {
+	test.Step[*test.Env]
+	// Checks the parameters of the step after loading
+	Validate() error
+}
+
+// Payload statuses which can be expected by a step
+var payloadStatuses = map[string]test.PayloadStatus{
+	"VALID":              test.Valid,
+	"INVALID":            test.Invalid,
+	"SYNCING":            test.Syncing,
+	"ACCEPTED":           test.Accepted,
+	"INVALID_BLOCK_HASH": test.InvalidBlockHash,
+}
+
+func parseStatuses(statuses []string, def test.PayloadStatus) ([]test.PayloadStatus, error) {
+	if len(statuses) == 0 {
+		return []test.PayloadStatus{def}, nil
+	}
+	result := make([]test.PayloadStatus, len(statuses))
+	for i, s := range statuses {
+		ps, ok := payloadStatuses[s]
+		if !ok {
+			return nil, fmt.Errorf("unknown payload status %q", s)
+		}
+		result[i] = ps
+	}
+	return result, nil
+}
+
+// Produces blocks using the CL Mocker, optionally sending transactions before each block
+type ProduceBlocks struct {
+	Count                uint64 `yaml:"count"`
+	TransactionsPerBlock uint64 `yaml:"transactionsPerBlock"`
+}
+
+func (step *ProduceBlocks) Validate() error {
+	return nil
+}
+
+func (step *ProduceBlocks) GetCount() uint64 {
+	if step.Count == 0 {
+		return 1
+	}
+	return step.Count
+}
+
+func (step *ProduceBlocks) Execute(t *test.Env) error {
+	var err error
+	t.CLMock.ProduceBlocks(int(step.GetCount()), clmock.BlockProcessCallbacks{
+		OnPayloadProducerSelected: func() {
+			if step.TransactionsPerBlock == 0 || err != nil {
+				return
+			}
+			_, err = t.SendNextTransactions(t.TestContext, t.CLMock.NextBlockProducer, &helper.BaseTransactionCreator{
+				Recipient:  &common.Address{},
+				Amount:     big.NewInt(1),
+				GasLimit:   75000,
+				TxType:     t.TestTransactionType,
+				ForkConfig: t.ForkConfig,
+			}, step.TransactionsPerBlock)
+		},
+	})
+	return err
+}
+
+func (step *ProduceBlocks) Description() string {
+	desc := fmt.Sprintf("ProduceBlocks: %d block(s)", step.GetCount())
+	if step.TransactionsPerBlock > 0 {
+		desc += fmt.Sprintf(", %d transaction(s) per block", step.TransactionsPerBlock)
+	}
+	return desc
+}
+
+// Sends transactions to the client, which are included in the next produced block
+type SendTransactions struct {
+	Count     uint64          `yaml:"count"`
+	Recipient *common.Address `yaml:"recipient"`
+	Amount    *hexutil.Big    `yaml:"amount"`
+	GasLimit  uint64          `yaml:"gasLimit"`
+	Data      hexutil.Bytes   `yaml:"data"`
+	// Transaction type: "legacy" or "dynamic". By default, the type is chosen
+	// from the types supported by the fork.
+	Type string `yaml:"type"`
+}
+
+var transactionTypes = map[string]helper.TestTransactionType{
+	"":        helper.UnspecifiedTransactionType,
+	"legacy":  helper.LegacyTxOnly,
+	"dynamic": helper.DynamicFeeTxOnly,
+}
+
+func (step *SendTransactions) Validate() error {
+	if _, ok := transactionTypes[step.Type]; !ok {
+		return fmt.Errorf("unsupported transaction type %q", step.Type)
+	}
+	return nil
+}
+
+func (step *SendTransactions) GetCount() uint64 {
+	if step.Count == 0 {
+		return 1
+	}
+	return step.Count
+}
+
+func (step *SendTransactions) Execute(t *test.Env) error {
+	txCreator := &helper.BaseTransactionCreator{
+		Recipient:  step.Recipient,
+		Amount:     new(big.Int),
+		GasLimit:   step.GasLimit,
+		Payload:    step.Data,
+		TxType:     transactionTypes[step.Type],
+		ForkConfig: t.ForkConfig,
+	}
+	if txCreator.Recipient == nil {
+		txCreator.Recipient = &common.Address{}
+	}
+	if step.Amount != nil {
+		txCreator.Amount = step.Amount.ToInt()
+	}
+	if txCreator.GasLimit == 0 {
+		txCreator.GasLimit = 75000
+	}
+	if txCreator.TxType == helper.UnspecifiedTransactionType {
+		txCreator.TxType = t.TestTransactionType
+	}
+	_, err := t.SendNextTransactions(t.TestContext, t.Engine, txCreator, step.GetCount())
+	return err
+}
+
+func (step *SendTransactions) Description() string {
+	return fmt.Sprintf("SendTransactions: %d transaction(s)", step.GetCount())
+}
+
+// Fields of a payload which can be modified by InvalidPayload. The block hash is
+// recalculated unless it is also given.
+type PayloadFields struct {
+	ParentHash        *common.Hash    `yaml:"parentHash"`
+	FeeRecipient      *common.Address `yaml:"feeRecipient"`
+	StateRoot         *common.Hash    `yaml:"stateRoot"`
+	ReceiptsRoot      *common.Hash    `yaml:"receiptsRoot"`
+	PrevRandao        *common.Hash    `yaml:"prevRandao"`
+	Number            *uint64         `yaml:"number"`
+	GasLimit          *uint64         `yaml:"gasLimit"`
+	GasUsed           *uint64         `yaml:"gasUsed"`
+	Timestamp         *uint64         `yaml:"timestamp"`
+	ExtraData         *hexutil.Bytes  `yaml:"extraData"`
+	BaseFeePerGas     *hexutil.Big    `yaml:"baseFeePerGas"`
+	BlockHash         *common.Hash    `yaml:"blockHash"`
+	RemoveWithdrawals bool            `yaml:"removeWithdrawals"`
+	BlobGasUsed       *uint64         `yaml:"blobGasUsed"`
+	ExcessBlobGas     *uint64         `yaml:"excessBlobGas"`
+	ParentBeaconRoot  *common.Hash    `yaml:"parentBeaconRoot"`
+}
+
+// Converts the fields to the payload customizer used by the Go tests.
+func (f *PayloadFields) CustomPayloadData() *helper.CustomPayloadData {
+	c := &helper.CustomPayloadData{
+		ParentHash:        f.ParentHash,
+		FeeRecipient:      f.FeeRecipient,
+		StateRoot:         f.StateRoot,
+		ReceiptsRoot:      f.ReceiptsRoot,
+		PrevRandao:        f.PrevRandao,
+		Number:            f.Number,
+		GasLimit:          f.GasLimit,
+		GasUsed:           f.GasUsed,
+		Timestamp:         f.Timestamp,
+		BlockHash:         f.BlockHash,
+		RemoveWithdrawals: f.RemoveWithdrawals,
+		BlobGasUsed:       f.BlobGasUsed,
+		ExcessBlobGas:     f.ExcessBlobGas,
+		ParentBeaconRoot:  f.ParentBeaconRoot,
+	}
+	if f.ExtraData != nil {
+		extraData := []byte(*f.ExtraData)
+		c.ExtraData = &extraData
+	}
+	if f.BaseFeePerGas != nil {
+		c.BaseFeePerGas = f.BaseFeePerGas.ToInt()
+	}
+	return c
+}
+
+// Fields which can be invalidated by name, see helper.GenerateInvalidPayload
+var invalidPayloadFields = []helper.InvalidPayloadBlockField{
+	helper.InvalidParentHash,
+	helper.InvalidStateRoot,
+	helper.InvalidReceiptsRoot,
+	helper.InvalidNumber,
+	helper.InvalidGasLimit,
+	helper.InvalidGasUsed,
+	helper.InvalidTimestamp,
+	helper.InvalidPrevRandao,
+	helper.RemoveTransaction,
+	helper.InvalidTransactionSignature,
+	helper.InvalidTransactionNonce,
+	helper.InvalidTransactionGas,
+	helper.InvalidTransactionGasPrice,
+	helper.InvalidTransactionGasTipPrice,
+	helper.InvalidTransactionValue,
+	helper.InvalidTransactionChainID,
+}
+
+// Sends a modified version of the next payload built by the client before the CL
+// Mocker broadcasts the valid payload
+type InvalidPayload struct {
+	// Name of the field to invalidate, e.g. "StateRoot"
+	Field helper.InvalidPayloadBlockField `yaml:"field"`
+	// Custom field values, used instead of Field
+	Custom *PayloadFields `yaml:"custom"`
+
+	// Expected payload statuses, INVALID by default
+	ExpectStatus []string `yaml:"expectStatus"`
+	// Expected error code, instead of a payload status
+	ExpectError *int `yaml:"expectError"`
+	// Expected latest valid hash: "parent", "null", or empty to skip the check
+	ExpectLatestValidHash string `yaml:"expectLatestValidHash"`
+}
+
+func (step *InvalidPayload) Validate() error {
+	if (step.Field == "") == (step.Custom == nil) {
+		return fmt.Errorf("invalidPayload requires exactly one of field and custom")
+	}
+	if step.Field != "" {
+		known := false
+		for _, f := range invalidPayloadFields {
+			known = known || f == step.Field
+		}
+		if !known {
+			return fmt.Errorf("unknown payload field %q", step.Field)
+		}
+	}
+	if _, err := parseStatuses(step.ExpectStatus, test.Invalid); err != nil {
+		return err
+	}
+	switch step.ExpectLatestValidHash {
+	case "", "parent", "null":
+	default:
+		return fmt.Errorf("invalid expectLatestValidHash %q", step.ExpectLatestValidHash)
+	}
+	return nil
+}
+
+func (step *InvalidPayload) Execute(t *test.Env) error {
+	var err error
+	t.CLMock.ProduceSingleBlock(clmock.BlockProcessCallbacks{
+		OnGetPayload: func() {
+			var (
+				basePayload = t.CLMock.LatestPayloadBuilt
+				payload     *typ.ExecutableData
+			)
+			if step.Custom != nil {
+				payload, err = step.Custom.CustomPayloadData().CustomizePayload(t.Rand, &basePayload)
+			} else {
+				payload, err = helper.GenerateInvalidPayload(t.Rand, &basePayload, step.Field)
+			}
+			if err != nil {
+				err = fmt.Errorf("unable to modify payload: %v", err)
+				return
+			}
+
+			r := t.TestEngine.TestEngineNewPayload(payload)
+			if step.ExpectError != nil {
+				r.ExpectErrorCode(*step.ExpectError)
+				return
+			}
+			statuses, _ := parseStatuses(step.ExpectStatus, test.Invalid)
+			r.ExpectStatusEither(statuses...)
+			switch step.ExpectLatestValidHash {
+			case "parent":
+				r.ExpectLatestValidHash(&basePayload.ParentHash)
+			case "null":
+				r.ExpectLatestValidHash(nil)
+			}
+		},
+	})
+	return err
+}
+
+func (step *InvalidPayload) Description() string {
+	if step.Custom != nil {
+		return "InvalidPayload: custom payload fields"
+	}
+	return fmt.Sprintf("InvalidPayload: invalid %s", step.Field)
+}
+
+// Sets the head of the client back to a previous canonical block
+type ReOrg struct {
+	// Number of the new head block
+	To uint64 `yaml:"to"`
+	// Expected payload statuses of the forkchoice update, VALID by default
+	ExpectStatus []string `yaml:"expectStatus"`
+	// Whether to skip re-applying the CL Mocker forkchoice after the re-org
+	KeepHead bool `yaml:"keepHead"`
+}
+
+func (step *ReOrg) Validate() error {
+	if step.To == 0 {
+		return fmt.Errorf("cannot re-org to the genesis block")
+	}
+	_, err := parseStatuses(step.ExpectStatus, test.Valid)
+	return err
+}
+
+func (step *ReOrg) Execute(t *test.Env) error {
+	payload, ok := t.CLMock.ExecutedPayloadHistory[step.To]
+	if !ok {
+		return fmt.Errorf("block %d was not produced by the CL Mocker", step.To)
+	}
+	statuses, _ := parseStatuses(step.ExpectStatus, test.Valid)
+	// The safe and finalized blocks must be ancestors of the new head
+	fcU := api.ForkchoiceStateV1{
+		HeadBlockHash:      payload.BlockHash,
+		SafeBlockHash:      step.ancestor(t, t.CLMock.LatestForkchoice.SafeBlockHash),
+		FinalizedBlockHash: step.ancestor(t, t.CLMock.LatestForkchoice.FinalizedBlockHash),
+	}
+	r := t.TestEngine.TestEngineForkchoiceUpdated(&fcU, nil, payload.Timestamp)
+	r.ExpectAnyPayloadStatus(statuses...)
+
+	if step.KeepHead {
+		return nil
+	}
+	// Re-send the forkchoice of the CL Mocker so it can continue producing blocks
+	r = t.TestEngine.TestEngineForkchoiceUpdated(&t.CLMock.LatestForkchoice, nil, t.CLMock.LatestExecutedPayload.Timestamp)
+	r.ExpectPayloadStatus(test.Valid)
+	return nil
+}
+
+// Returns the given block if it is not above the re-org target, and the target
+// block otherwise.
+func (step *ReOrg) ancestor(t *test.Env, hash common.Hash) common.Hash {
+	for number, payload := range t.CLMock.ExecutedPayloadHistory {
+		if payload.BlockHash == hash && number > step.To {
+			return t.CLMock.ExecutedPayloadHistory[step.To].BlockHash
+		}
+	}
+	return hash
+}
+
+func (step *ReOrg) Description() string {
+	return fmt.Sprintf("ReOrg: head to block %d", step.To)
+}
+
+// Verifies the head block of the client
+type ExpectHead struct {
+	// Expected head block number, the latest block of the CL Mocker by default
+	Number *uint64 `yaml:"number"`
+}
+
+func (step *ExpectHead) Validate() error {
+	return nil
+}
+
+func (step *ExpectHead) Execute(t *test.Env) error {
+	number := t.CLMock.LatestExecutedPayload.Number
+	if step.Number != nil {
+		number = *step.Number
+	}
+	payload, ok := t.CLMock.ExecutedPayloadHistory[number]
+	if !ok {
+		return fmt.Errorf("block %d was not produced by the CL Mocker", number)
+	}
+	r := t.TestEngine.TestHeaderByNumber(nil)
+	r.ExpectNoError()
+	if got := r.Header.Number.Uint64(); got != number {
+		return fmt.Errorf("unexpected head block number: got %d, want %d", got, number)
+	}
+	if got := t.CLMock.HeaderHash(r.Header, payload.ExecutionRequests); got != payload.BlockHash {
+		return fmt.Errorf("unexpected head block hash: got %v, want %v", got, payload.BlockHash)
+	}
+	return nil
+}
+
+func (step *ExpectHead) Description() string {
+	if step.Number == nil {
+		return "ExpectHead: latest block"
+	}
+	return fmt.Sprintf("ExpectHead: block %d", *step.Number)
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"math/rand"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/ethereum/hive/simulators/ethereum/engine/client"
@@ -13,6 +14,8 @@ import (
 	"github.com/ethereum/hive/simulators/ethereum/engine/config"
 	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
 	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+	"github.com/ethereum/hive/simulators/ethereum/engine/schema"
+	"github.com/ethereum/hive/simulators/ethereum/engine/trace"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/hive/hivesim"
@@ -74,7 +77,47 @@ func Run(testSpec Spec, ttd *big.Int, timeout time.Duration, t *hivesim.T, c *hi
 	}()
 
 	// Create Engine client from main hivesim.Client to be used by tests
-	ec := hive_rpc.NewHiveRPCEngineClient(c, globals.EnginePortHTTP, globals.EthPortHTTP, globals.DefaultJwtTokenSecretBytes, ttd, &helper.LoggingRoundTrip{
+	engineTransport, err := client.TransportFromEnv()
+	if err != nil {
+		t.Fatalf("FAIL (%s): %v", testSpec.GetName(), err)
+	}
+	if engineTransport != client.HTTP {
+		t.Logf("INFO (%s): Engine API requests are sent over %s, request logging only covers the Eth API", testSpec.GetName(), engineTransport)
+	}
+
+	// Record the requests of all clients to the test log if tracing is enabled
+	if os.Getenv(trace.EnvTrace) != "" {
+		if engineTransport != client.HTTP {
+			t.Fatalf("FAIL (%s): Engine API traces can't be recorded over the %s transport", testSpec.GetName(), engineTransport)
+		}
+		rec := trace.NewRecorder()
+		defer func() {
+			log, err := rec.Log()
+			if err != nil {
+				t.Errorf("FAIL (%s): Unable to encode trace: %v", testSpec.GetName(), err)
+				return
+			}
+			t.Logf("INFO (%s): Engine API trace:\n%s", testSpec.GetName(), log)
+		}()
+		clMocker.TraceRecorder = rec
+	}
+
+	// Validate all responses against the specification in strict mode
+	if os.Getenv(schema.EnvStrict) != "" {
+		if engineTransport != client.HTTP {
+			t.Fatalf("FAIL (%s): Strict schema mode is not supported over the %s transport", testSpec.GetName(), engineTransport)
+		}
+		checker := schema.NewChecker(schema.Default())
+		defer func() {
+			for _, v := range checker.Violations() {
+				t.Errorf("FAIL (%s): Schema violation: %s", testSpec.GetName(), v)
+			}
+			t.Logf("INFO (%s): %s", testSpec.GetName(), checker.Summary())
+		}()
+		clMocker.SchemaChecker = checker
+	}
+
+	ec := hive_rpc.NewHiveRPCEngineClient(c, globals.EnginePortHTTP, globals.EthPortHTTP, globals.DefaultJwtTokenSecretBytes, ttd, engineTransport, &helper.LoggingRoundTrip{
 		Logger: t,
 		ID:     c.Container,
 		Inner:  http.DefaultTransport,
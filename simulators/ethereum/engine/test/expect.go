@@ -286,12 +286,31 @@ func (tec *TestEngineClient) TestEngineNewPayloadV3(payload *typ.ExecutableData)
 	return ret
 }
 
+func (tec *TestEngineClient) TestEngineNewPayloadV4(payload *typ.ExecutableData) *NewPayloadResponseExpectObject {
+	ctx, cancel := context.WithTimeout(tec.TestContext, globals.RPCTimeout)
+	defer cancel()
+	status, err := tec.Engine.NewPayloadV4(ctx, payload)
+	ret := &NewPayloadResponseExpectObject{
+		ExpectEnv: &ExpectEnv{Env: tec.Env},
+		Payload:   payload,
+		Status:    status,
+		Version:   4,
+		Error:     err,
+	}
+	if err, ok := err.(rpc.Error); ok {
+		ret.ErrorCode = err.ErrorCode()
+	}
+	return ret
+}
+
 func (tec *TestEngineClient) TestEngineNewPayload(payload *typ.ExecutableData) *NewPayloadResponseExpectObject {
 	if payload == nil {
 		panic("Payload is nil")
 	}
 	version := tec.EngineAPIVersionResolver.NewPayloadVersion(payload.Timestamp)
-	if version == 3 {
+	if version == 4 {
+		return tec.TestEngineNewPayloadV4(payload)
+	} else if version == 3 {
 		return tec.TestEngineNewPayloadV3(payload)
 	} else if version == 2 {
 		return tec.TestEngineNewPayloadV2(payload)
@@ -422,6 +441,25 @@ func (tec *TestEngineClient) TestEngineGetPayloadV3(payloadID *api.PayloadID) *G
 	return ret
 }
 
+func (tec *TestEngineClient) TestEngineGetPayloadV4(payloadID *api.PayloadID) *GetPayloadResponseExpectObject {
+	ctx, cancel := context.WithTimeout(tec.TestContext, globals.RPCTimeout)
+	defer cancel()
+	payload, blockValue, blobsBundle, shouldOverride, err := tec.Engine.GetPayloadV4(ctx, payloadID)
+	ret := &GetPayloadResponseExpectObject{
+		ExpectEnv:             &ExpectEnv{Env: tec.Env},
+		Payload:               payload,
+		Version:               4,
+		BlockValue:            blockValue,
+		BlobsBundle:           blobsBundle,
+		ShouldOverrideBuilder: shouldOverride,
+		Error:                 err,
+	}
+	if err, ok := err.(rpc.Error); ok {
+		ret.ErrorCode = err.ErrorCode()
+	}
+	return ret
+}
+
 func (tec *TestEngineClient) TestEngineGetPayload(payloadID *api.PayloadID, payloadAttributes *typ.PayloadAttributes) *GetPayloadResponseExpectObject {
 	version := tec.EngineAPIVersionResolver.GetPayloadVersion(payloadAttributes.Timestamp)
 	ctx, cancel := context.WithTimeout(tec.TestContext, globals.RPCTimeout)
@@ -548,6 +586,21 @@ func (exp *GetPayloadResponseExpectObject) ExpectPayload(expectedPayload *typ.Ex
 	}
 }
 
+func (exp *GetPayloadResponseExpectObject) ExpectExecutionRequests(expectedRequests [][]byte) {
+	exp.ExpectNoError()
+	if exp.Payload.ExecutionRequests == nil {
+		exp.Fatalf("FAIL (%s): Expected execution requests on EngineGetPayloadV%d, got none", exp.TestName, exp.Version)
+	}
+	if len(expectedRequests) != len(exp.Payload.ExecutionRequests) {
+		exp.Fatalf("FAIL (%s): Unexpected execution requests count on EngineGetPayloadV%d: want=%d, got=%d", exp.TestName, exp.Version, len(expectedRequests), len(exp.Payload.ExecutionRequests))
+	}
+	for i, want := range expectedRequests {
+		if got := exp.Payload.ExecutionRequests[i]; !bytes.Equal(want, got) {
+			exp.Fatalf("FAIL (%s): Unexpected execution request %d on EngineGetPayloadV%d: want=%x, got=%x", exp.TestName, i, exp.Version, want, got)
+		}
+	}
+}
+
 func (exp *GetPayloadResponseExpectObject) ExpectPayloadParentHash(expectedParentHash common.Hash) {
 	exp.ExpectNoError()
 	if exp.Payload.ParentHash != expectedParentHash {
@@ -650,6 +703,57 @@ func (exp *GetPayloadBodiesResponseExpectObject) ExpectPayloadBodiesCount(count
 	}
 }
 
+// GetBlobs
+type GetBlobsResponseExpectObject struct {
+	*ExpectEnv
+	BlobsAndProofs []*typ.BlobAndProofV1
+	Version        int
+	Error          error
+	ErrorCode      int
+}
+
+func (tec *TestEngineClient) TestEngineGetBlobsV1(versionedHashes []common.Hash) *GetBlobsResponseExpectObject {
+	ctx, cancel := context.WithTimeout(tec.TestContext, globals.RPCTimeout)
+	defer cancel()
+	blobsAndProofs, err := tec.Engine.GetBlobsV1(ctx, versionedHashes)
+	ret := &GetBlobsResponseExpectObject{
+		ExpectEnv:      &ExpectEnv{Env: tec.Env},
+		BlobsAndProofs: blobsAndProofs,
+		Version:        1,
+		Error:          err,
+	}
+	if err, ok := err.(rpc.Error); ok {
+		ret.ErrorCode = err.ErrorCode()
+	}
+	return ret
+}
+
+func (exp *GetBlobsResponseExpectObject) ExpectNoError() {
+	if exp.Error != nil {
+		exp.Fatalf("FAIL (%s): Expected no error on EngineGetBlobsV%d: error=%v", exp.TestName, exp.Version, exp.Error)
+	}
+}
+
+func (exp *GetBlobsResponseExpectObject) ExpectError() {
+	if exp.Error == nil {
+		exp.Fatalf("FAIL (%s): Expected error on EngineGetBlobsV%d: count=%d", exp.TestName, exp.Version, len(exp.BlobsAndProofs))
+	}
+}
+
+func (exp *GetBlobsResponseExpectObject) ExpectErrorCode(code int) {
+	exp.ExpectError()
+	if exp.ErrorCode != code {
+		exp.Fatalf("FAIL (%s): Expected error code on EngineGetBlobsV%d: want=%d, got=%d", exp.TestName, exp.Version, code, exp.ErrorCode)
+	}
+}
+
+func (exp *GetBlobsResponseExpectObject) ExpectBlobsCount(count uint64) {
+	exp.ExpectNoError()
+	if uint64(len(exp.BlobsAndProofs)) != count {
+		exp.Fatalf("FAIL (%s): Expected blobs list count on EngineGetBlobsV%d: want=%d, got=%d", exp.TestName, exp.Version, count, len(exp.BlobsAndProofs))
+	}
+}
+
 func CompareTransactions(want [][]byte, got [][]byte) error {
 	if len(want) != len(got) {
 		return fmt.Errorf("incorrect tx length: want=%d, got=%d", len(want), len(got))
@@ -0,0 +1,23 @@
+package test
+
+// Interface to represent a single step in a test sequence, which is executed
+// with a suite specific context of type C
+type Step[C any] interface {
+	// Executes the step
+	Execute(testCtx C) error
+	Description() string
+}
+
+// Steps of a test, executed in order
+type Sequence[C any] []Step[C]
+
+// Executes the steps in order, and fails the test at the first step returning
+// an error
+func (s Sequence[C]) Run(t *Env, testCtx C) {
+	for stepId, step := range s {
+		t.Logf("INFO: Executing step %d: %s", stepId+1, step.Description())
+		if err := step.Execute(testCtx); err != nil {
+			t.Fatalf("FAIL: Error executing step %d: %v", stepId+1, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Replayer sends the requests of a trace to a client and compares the responses
+// with the recorded ones.
+type Replayer struct {
+	EngineURL  string
+	EthURL     string
+	JWTSecret  []byte
+	HTTPClient *http.Client
+
+	// payload IDs are chosen by the client, so they are mapped from the
+	// recorded to the replayed value.
+	payloadIDs map[string]string
+}
+
+// Divergence describes the first response which differs from the trace.
+type Divergence struct {
+	Index    int
+	Entry    Entry
+	Response json.RawMessage
+	Reason   string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("entry %d (%s): %s\n  request:  %s\n  recorded: %s\n  replayed: %s",
+		d.Index, d.Entry.Endpoint, d.Reason, d.Entry.Request, d.Entry.Response, d.Response)
+}
+
+// Replay sends all entries in order. It stops at the first response which doesn't
+// match the trace and returns it. The error is non-nil when a request could not be
+// sent at all.
+func (r *Replayer) Replay(ctx context.Context, entries []Entry) (*Divergence, error) {
+	r.payloadIDs = make(map[string]string)
+	for i, e := range entries {
+		got, err := r.send(ctx, e)
+		if err != nil {
+			if e.Error != "" {
+				// The request failed when it was recorded too.
+				continue
+			}
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		if e.Response == nil {
+			continue
+		}
+		r.mapPayloadID(e.Response, got)
+		if reason := compareResponses(e.Response, got); reason != "" {
+			return &Divergence{Index: i, Entry: e, Response: got, Reason: reason}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Replayer) send(ctx context.Context, e Entry) (json.RawMessage, error) {
+	url := r.EthURL
+	if e.Endpoint == EngineEndpoint {
+		url = r.EngineURL
+	}
+	body := string(e.Request)
+	for recorded, replayed := range r.payloadIDs {
+		body = strings.ReplaceAll(body, recorded, replayed)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("content-type", "application/json")
+	if e.Endpoint == EngineEndpoint && r.JWTSecret != nil {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iat": time.Now().Unix(),
+		}).SignedString(r.JWTSecret)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(respBytes) {
+		return nil, fmt.Errorf("invalid response (%s): %q", resp.Status, respBytes)
+	}
+	return compact(respBytes), nil
+}
+
+// mapPayloadID stores the payload ID returned by a forkchoiceUpdated call.
+func (r *Replayer) mapPayloadID(recorded, replayed json.RawMessage) {
+	var rec, rep struct {
+		Result struct {
+			PayloadID *string `json:"payloadId"`
+		} `json:"result"`
+	}
+	if json.Unmarshal(recorded, &rec) != nil || json.Unmarshal(replayed, &rep) != nil {
+		return
+	}
+	if rec.Result.PayloadID != nil && rep.Result.PayloadID != nil {
+		r.payloadIDs[*rec.Result.PayloadID] = *rep.Result.PayloadID
+	}
+}
+
+// compareResponses compares two JSON-RPC responses, ignoring fields which are
+// expected to differ between clients. It returns the reason when they differ.
+func compareResponses(recorded, replayed json.RawMessage) string {
+	var a, b interface{}
+	if err := json.Unmarshal(recorded, &a); err != nil {
+		return fmt.Sprintf("invalid recorded response: %v", err)
+	}
+	if err := json.Unmarshal(replayed, &b); err != nil {
+		return fmt.Sprintf("invalid response: %v", err)
+	}
+	a, b = normalize(a), normalize(b)
+	if !reflect.DeepEqual(a, b) {
+		return "response differs"
+	}
+	return ""
+}
+
+// normalize removes the message ID, error messages, validation errors and the
+// value of payload IDs from a response.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		delete(v, "id")
+		delete(v, "validationError")
+		if e, ok := v["error"].(map[string]interface{}); ok {
+			v["error"] = map[string]interface{}{"code": e["code"]}
+		}
+		if id, ok := v["payloadId"]; ok && id != nil {
+			v["payloadId"] = "<payloadId>"
+		}
+		for k, x := range v {
+			v[k] = normalize(x)
+		}
+	case []interface{}:
+		for i, x := range v {
+			v[i] = normalize(x)
+		}
+	}
+	return v
+}
@@ -0,0 +1,267 @@
+// Package trace records the Engine and Eth API traffic of a test to the test log,
+// and replays recorded traces against a client endpoint.
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/results"
+)
+
+// EnvTrace is the environment variable which enables recording. When set to a
+// non-empty value, every test writes its trace to the test log. The simulator sets
+// it from the trace build argument.
+const EnvTrace = "HIVE_ENGINE_TRACE"
+
+// LogPrefix marks the lines of a test log which contain trace entries.
+const LogPrefix = "ENGINE_TRACE "
+
+// Endpoints of the execution client.
+const (
+	EngineEndpoint = "engine"
+	EthEndpoint    = "eth"
+)
+
+// Entry is a single request/response exchange with a client.
+type Entry struct {
+	Time     time.Time       `json:"time"`
+	Client   string          `json:"client"`
+	Endpoint string          `json:"endpoint"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// Error is set when the request failed without a response.
+	Error string `json:"error,omitempty"`
+}
+
+// LogLine returns the entry as a line of the test log.
+func (e Entry) LogLine() (string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return LogPrefix + string(data), nil
+}
+
+// Recorder collects the entries of a trace.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewRecorder creates an empty recorder.
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+// Record appends an entry to the trace.
+func (r *Recorder) Record(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// Entries returns the recorded entries.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Log returns the recorded entries as lines of the test log.
+func (r *Recorder) Log() (string, error) {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		line, err := e.LogLine()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// Transport is a http.RoundTripper which records all exchanges of a client once a
+// recorder has been set.
+type Transport struct {
+	Client string
+	Inner  http.RoundTripper
+
+	mu  sync.Mutex
+	rec *Recorder
+}
+
+// SetRecorder sets the recorder of the transport. Recording stops when rec is nil.
+func (t *Transport) SetRecorder(rec *Recorder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rec = rec
+}
+
+func (t *Transport) recorder() *Recorder {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rec
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := t.recorder()
+	if rec == nil || req.Body == nil {
+		return t.Inner.RoundTrip(req)
+	}
+
+	reqBytes, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	reqCopy := *req
+	reqCopy.Body = io.NopCloser(bytes.NewReader(reqBytes))
+	entry := Entry{
+		Time:     time.Now(),
+		Client:   t.Client,
+		Endpoint: endpointOf(reqBytes),
+		Request:  compact(reqBytes),
+	}
+
+	resp, err := t.Inner.RoundTrip(&reqCopy)
+	if err != nil {
+		entry.Error = err.Error()
+		rec.Record(entry)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		entry.Error = err.Error()
+		rec.Record(entry)
+		return nil, err
+	}
+	respCopy := *resp
+	respCopy.Body = io.NopCloser(bytes.NewReader(respBytes))
+
+	if json.Valid(respBytes) {
+		entry.Response = compact(respBytes)
+	} else {
+		entry.Error = resp.Status
+	}
+	rec.Record(entry)
+	return &respCopy, nil
+}
+
+// endpointOf determines the endpoint of a request from its method name. Batches are
+// assigned by their first request.
+func endpointOf(body []byte) string {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		var batch []json.RawMessage
+		if json.Unmarshal(body, &batch) == nil && len(batch) > 0 {
+			return endpointOf(batch[0])
+		}
+	}
+	if strings.HasPrefix(msg.Method, "engine_") {
+		return EngineEndpoint
+	}
+	return EthEndpoint
+}
+
+func compact(data []byte) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return json.RawMessage(data)
+	}
+	return buf.Bytes()
+}
+
+// Parse reads the entries of a trace. The data can be a test log, a hive suite
+// result file, or entries in JSON-lines format. Result files have to be read with
+// ReadFile, unless they store the test logs inline.
+func Parse(data []byte) ([]Entry, error) {
+	return parse(data, "")
+}
+
+// parse reads the entries of a trace, resolving the test details log of a result
+// file relative to dir.
+func parse(data []byte, dir string) ([]Entry, error) {
+	logs, ok, err := results.TestLogs(data, dir)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		data = []byte(strings.Join(logs, "\n"))
+	}
+
+	// Logs contain other output besides the trace, which is skipped.
+	isLog := bytes.Contains(data, []byte(LogPrefix))
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, LogPrefix); i >= 0 {
+			line = line[i+len(LogPrefix):]
+		} else if isLog || strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("invalid trace entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Read reads all entries of a trace.
+func Read(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// ReadFile reads all entries of a trace file.
+func ReadFile(file string) ([]Entry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data, filepath.Dir(file))
+}
+
+// Clients returns the clients of a trace, in order of their first request.
+func Clients(entries []Entry) []string {
+	var clients []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !seen[e.Client] {
+			seen[e.Client] = true
+			clients = append(clients, e.Client)
+		}
+	}
+	return clients
+}
+
+// FilterClient returns the entries of a single client.
+func FilterClient(entries []Entry, client string) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		if e.Client == client {
+			result = append(result, e)
+		}
+	}
+	return result
+}
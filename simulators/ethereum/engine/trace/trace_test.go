@@ -0,0 +1,151 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/hive/simulators/ethereum/engine/results"
+)
+
+// fakeClient answers forkchoiceUpdated with a payload ID and getPayload with the
+// status given by the test.
+type fakeClient struct {
+	payloadID string
+	status    string
+	gotIDs    []string
+}
+
+func (c *fakeClient) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	var result string
+	switch req.Method {
+	case "engine_forkchoiceUpdatedV1":
+		result = fmt.Sprintf(`{"payloadStatus":{"status":"VALID","validationError":null},"payloadId":"%s"}`, c.payloadID)
+	case "engine_getPayloadV1":
+		var id string
+		json.Unmarshal(req.Params[0], &id)
+		c.gotIDs = append(c.gotIDs, id)
+		result = fmt.Sprintf(`{"status":"%s","validationError":"client specific"}`, c.status)
+	default:
+		result = `"0x1"`
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+func call(t *testing.T, client *http.Client, url, method, params string) {
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":[%s]}`, method, params)
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestRecordReplay(t *testing.T) {
+	recorded := &fakeClient{payloadID: "0x0000000000000001", status: "VALID"}
+	srv := httptest.NewServer(recorded)
+	defer srv.Close()
+
+	// Record a short session.
+	rec := NewRecorder()
+	transport := &Transport{Client: "c1", Inner: http.DefaultTransport}
+	transport.SetRecorder(rec)
+	client := &http.Client{Transport: transport}
+	call(t, client, srv.URL, "eth_blockNumber", "")
+	call(t, client, srv.URL, "engine_forkchoiceUpdatedV1", "{}")
+	call(t, client, srv.URL, "engine_getPayloadV1", `"0x0000000000000001"`)
+
+	// The trace is read back from the test log, which also contains other output.
+	log, err := rec.Log()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Parse([]byte("INFO (test): Engine API trace:\n" + log + ">> (c1) {\"id\":1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("wrong number of entries: %d", len(entries))
+	}
+	if entries[0].Endpoint != EthEndpoint || entries[1].Endpoint != EngineEndpoint {
+		t.Fatalf("wrong endpoints: %s, %s", entries[0].Endpoint, entries[1].Endpoint)
+	}
+	if c := Clients(entries); len(c) != 1 || c[0] != "c1" {
+		t.Fatalf("wrong clients: %v", c)
+	}
+
+	// Replaying against a client with a different payload ID must succeed,
+	// and the payload ID has to be substituted in getPayload.
+	replayed := &fakeClient{payloadID: "0x0000000000000002", status: "VALID"}
+	srv2 := httptest.NewServer(replayed)
+	defer srv2.Close()
+	r := &Replayer{EngineURL: srv2.URL, EthURL: srv2.URL, JWTSecret: []byte("secret")}
+	div, err := r.Replay(context.Background(), entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if div != nil {
+		t.Fatalf("unexpected divergence: %v", div)
+	}
+	if len(replayed.gotIDs) != 1 || replayed.gotIDs[0] != "0x0000000000000002" {
+		t.Fatalf("payload ID not substituted: %v", replayed.gotIDs)
+	}
+
+	// A different status is reported as divergence.
+	replayed.status = "INVALID"
+	div, err = r.Replay(context.Background(), entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if div == nil || div.Index != 2 {
+		t.Fatalf("expected divergence at entry 2, got %v", div)
+	}
+}
+
+func TestParseJSONLines(t *testing.T) {
+	data := `{"client":"c1","endpoint":"eth","request":{"method":"eth_chainId"}}
+
+{"client":"c2","endpoint":"engine","request":{"method":"engine_getPayloadV1"}}
+`
+	entries, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := Clients(entries); len(c) != 2 || c[0] != "c1" || c[1] != "c2" {
+		t.Fatalf("wrong clients: %v", c)
+	}
+	if _, err := Parse([]byte("not json\n")); err == nil {
+		t.Fatal("no error for invalid entry")
+	}
+}
+
+func TestReadResultFile(t *testing.T) {
+	// Hive moves the test logs of a result file to the details log of the suite.
+	entries, err := ReadFile("testdata/results.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Endpoint != EngineEndpoint || entries[1].Client != "c1" {
+		t.Fatalf("wrong entries: %+v", entries)
+	}
+
+	// Without the location of the result file, the logs can't be found.
+	data, err := os.ReadFile("testdata/results.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(data); err != results.ErrNoDir {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
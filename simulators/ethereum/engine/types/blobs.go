@@ -141,6 +141,13 @@ type BlobTxWrapData struct {
 	Proofs      KZGProofs
 }
 
+// BlobAndProofV1 holds a blob of the transaction pool and its proof, as returned
+// by engine_getBlobsV1
+type BlobAndProofV1 struct {
+	Blob  Blob     `json:"blob"  gencodec:"required"`
+	Proof KZGProof `json:"proof" gencodec:"required"`
+}
+
 // BlobsBundle holds the blobs of an execution payload
 type BlobsBundle struct {
 	Commitments []KZGCommitment `json:"commitments" gencodec:"required"`
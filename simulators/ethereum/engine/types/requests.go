@@ -0,0 +1,88 @@
+package types
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RequestsHash computes the EIP-7685 commitment to the execution requests of a block.
+// Each request is expected to be prefixed with its request type, as returned by
+// engine_getPayloadV4. Requests without data are not included in the commitment.
+func RequestsHash(requests [][]byte) common.Hash {
+	h := sha256.New()
+	for _, r := range requests {
+		if len(r) > 1 {
+			rh := sha256.Sum256(r)
+			h.Write(rh[:])
+		}
+	}
+	var out common.Hash
+	h.Sum(out[:0])
+	return out
+}
+
+// pragueHeader is the RLP encoding of a Prague block header, which adds the
+// requests hash to the header fields known by go-ethereum's types.Header.
+type pragueHeader struct {
+	ParentHash       common.Hash
+	UncleHash        common.Hash
+	Coinbase         common.Address
+	Root             common.Hash
+	TxHash           common.Hash
+	ReceiptHash      common.Hash
+	Bloom            types.Bloom
+	Difficulty       *big.Int
+	Number           *big.Int
+	GasLimit         uint64
+	GasUsed          uint64
+	Time             uint64
+	Extra            []byte
+	MixDigest        common.Hash
+	Nonce            types.BlockNonce
+	BaseFee          *big.Int
+	WithdrawalsHash  *common.Hash
+	BlobGasUsed      *uint64
+	ExcessBlobGas    *uint64
+	ParentBeaconRoot *common.Hash
+	RequestsHash     common.Hash
+}
+
+// HeaderHash returns the hash of a header. When requests is non-nil, the header
+// is hashed as a Prague header committing to the given execution requests.
+func HeaderHash(h *types.Header, requests [][]byte) common.Hash {
+	if requests == nil {
+		return h.Hash()
+	}
+	enc, err := rlp.EncodeToBytes(&pragueHeader{
+		ParentHash:       h.ParentHash,
+		UncleHash:        h.UncleHash,
+		Coinbase:         h.Coinbase,
+		Root:             h.Root,
+		TxHash:           h.TxHash,
+		ReceiptHash:      h.ReceiptHash,
+		Bloom:            h.Bloom,
+		Difficulty:       h.Difficulty,
+		Number:           h.Number,
+		GasLimit:         h.GasLimit,
+		GasUsed:          h.GasUsed,
+		Time:             h.Time,
+		Extra:            h.Extra,
+		MixDigest:        h.MixDigest,
+		Nonce:            h.Nonce,
+		BaseFee:          h.BaseFee,
+		WithdrawalsHash:  h.WithdrawalsHash,
+		BlobGasUsed:      h.BlobGasUsed,
+		ExcessBlobGas:    h.ExcessBlobGas,
+		ParentBeaconRoot: h.ParentBeaconRoot,
+		RequestsHash:     RequestsHash(requests),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(enc)
+}
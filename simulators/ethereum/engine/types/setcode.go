@@ -0,0 +1,190 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+const (
+	SetCodeTxType = 0x04
+
+	// Prefix of the message signed by an EIP-7702 authorization.
+	setCodeAuthMagic = 0x05
+)
+
+// SetCodeAuthorization is an EIP-7702 authorization to delegate the code of the
+// signing account to Address.
+type SetCodeAuthorization struct {
+	ChainID *uint256.Int
+	Address common.Address
+	Nonce   uint64
+	V       uint8
+	R       *uint256.Int
+	S       *uint256.Int
+}
+
+// SignSetCodeAuthorization creates a signed authorization.
+func SignSetCodeAuthorization(key *ecdsa.PrivateKey, chainID *big.Int, address common.Address, nonce uint64) (SetCodeAuthorization, error) {
+	auth := SetCodeAuthorization{
+		ChainID: uint256.MustFromBig(chainID),
+		Address: address,
+		Nonce:   nonce,
+	}
+	msg, err := rlp.EncodeToBytes([]interface{}{auth.ChainID, auth.Address, auth.Nonce})
+	if err != nil {
+		return auth, err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(append([]byte{setCodeAuthMagic}, msg...)), key)
+	if err != nil {
+		return auth, err
+	}
+	auth.R = new(uint256.Int).SetBytes(sig[:32])
+	auth.S = new(uint256.Int).SetBytes(sig[32:64])
+	auth.V = sig[64]
+	return auth, nil
+}
+
+// SetCodeTx holds the fields of an EIP-7702 transaction.
+type SetCodeTx struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int
+	GasFeeCap  *uint256.Int
+	Gas        uint64
+	To         common.Address
+	Value      *uint256.Int
+	Data       []byte
+	AccessList types.AccessList
+	AuthList   []SetCodeAuthorization
+
+	// Signature values
+	V *uint256.Int
+	R *uint256.Int
+	S *uint256.Int
+}
+
+// unsignedFields returns the fields covered by the transaction signature.
+func (tx *SetCodeTx) unsignedFields() []interface{} {
+	return []interface{}{
+		tx.ChainID,
+		tx.Nonce,
+		tx.GasTipCap,
+		tx.GasFeeCap,
+		tx.Gas,
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.AccessList,
+		tx.AuthList,
+	}
+}
+
+// SetCodeTransaction is a signed EIP-7702 transaction. The go-ethereum version used
+// by the simulator doesn't support this transaction type, so it is encoded and
+// signed here.
+type SetCodeTransaction struct {
+	Tx *SetCodeTx
+}
+
+// SignSetCodeTx signs the transaction with the given key.
+func SignSetCodeTx(tx *SetCodeTx, key *ecdsa.PrivateKey) (*SetCodeTransaction, error) {
+	enc, err := rlp.EncodeToBytes(tx.unsignedFields())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(append([]byte{SetCodeTxType}, enc...)), key)
+	if err != nil {
+		return nil, err
+	}
+	signed := *tx
+	signed.R = new(uint256.Int).SetBytes(sig[:32])
+	signed.S = new(uint256.Int).SetBytes(sig[32:64])
+	signed.V = uint256.NewInt(uint64(sig[64]))
+	return &SetCodeTransaction{Tx: &signed}, nil
+}
+
+func (tx *SetCodeTransaction) MarshalBinary() ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(append(tx.Tx.unsignedFields(), tx.Tx.V, tx.Tx.R, tx.Tx.S))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{SetCodeTxType}, enc...), nil
+}
+
+func (tx *SetCodeTransaction) Protected() bool {
+	return true
+}
+
+func (tx *SetCodeTransaction) Type() uint8 {
+	return SetCodeTxType
+}
+
+func (tx *SetCodeTransaction) ChainId() *big.Int {
+	return tx.Tx.ChainID.ToBig()
+}
+
+func (tx *SetCodeTransaction) Data() []byte {
+	return tx.Tx.Data
+}
+
+func (tx *SetCodeTransaction) AccessList() types.AccessList {
+	return tx.Tx.AccessList
+}
+
+func (tx *SetCodeTransaction) Gas() uint64 {
+	return tx.Tx.Gas
+}
+
+func (tx *SetCodeTransaction) GasPrice() *big.Int {
+	return tx.Tx.GasFeeCap.ToBig()
+}
+
+func (tx *SetCodeTransaction) GasTipCap() *big.Int {
+	return tx.Tx.GasTipCap.ToBig()
+}
+
+func (tx *SetCodeTransaction) GasFeeCap() *big.Int {
+	return tx.Tx.GasFeeCap.ToBig()
+}
+
+func (tx *SetCodeTransaction) Value() *big.Int {
+	return tx.Tx.Value.ToBig()
+}
+
+func (tx *SetCodeTransaction) Nonce() uint64 {
+	return tx.Tx.Nonce
+}
+
+func (tx *SetCodeTransaction) To() *common.Address {
+	to := tx.Tx.To
+	return &to
+}
+
+func (tx *SetCodeTransaction) Hash() common.Hash {
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(enc)
+}
+
+func (tx *SetCodeTransaction) BlobGas() uint64 {
+	return 0
+}
+
+func (tx *SetCodeTransaction) BlobGasFeeCap() *big.Int {
+	return nil
+}
+
+func (tx *SetCodeTransaction) BlobHashes() []common.Hash {
+	return nil
+}
+
+// Set code transactions must also implement the interface
+var _ Transaction = (*SetCodeTransaction)(nil)
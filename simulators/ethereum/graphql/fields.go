@@ -0,0 +1,522 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// testChain is the chain imported by the client, decoded from the chain file.
+type testChain struct {
+	genesis core.Genesis
+	blocks  []*types.Block
+	// total difficulty of every block
+	td []*big.Int
+}
+
+// loadChain decodes the blocks of a chain file, which starts with the genesis block.
+func loadChain(file string, genesis core.Genesis) (*testChain, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	chain := &testChain{genesis: genesis}
+	stream := rlp.NewStream(f, 0)
+	td := new(big.Int)
+	for {
+		var block types.Block
+		if err := stream.Decode(&block); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid block %d in %s: %v", len(chain.blocks), file, err)
+		}
+		td = new(big.Int).Add(td, block.Difficulty())
+		chain.blocks = append(chain.blocks, &block)
+		chain.td = append(chain.td, td)
+	}
+	return chain, nil
+}
+
+// blockTx returns the block of a transaction, and its index in the block.
+func (chain *testChain) blockTx(hash common.Hash) (*types.Block, int) {
+	for _, block := range chain.blocks {
+		for i, tx := range block.Transactions() {
+			if tx.Hash() == hash {
+				return block, i
+			}
+		}
+	}
+	return nil, 0
+}
+
+// fieldTarget is an object of the test chain whose fields are queried one by one,
+// e.g. a block or a transaction.
+type fieldTarget struct {
+	name string
+	// GraphQL type of the object
+	typ string
+	// query of the object, with a placeholder for the selection
+	query string
+	// path of the object in the response data, lists are resolved to their
+	// first element
+	path []string
+	// the expected values of the fields known from the chain, in the canonical
+	// JSON form of the reference schema
+	expect map[string]interface{}
+}
+
+// Accounts and transactions of the test chain.
+var (
+	senderAccount   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	contractAccount = common.HexToAddress("0x6295ee1b4f6dd65047762f924ecd367c17eabf8f")
+	dynamicFeeTx    = common.HexToHash("0x3ecd2ca6cf26c864d0ea5f038a58d4cd4a46a3e242fe92f446f392fdc232dd98")
+	blobTx          = common.HexToHash("0x7c80f35a47f1d432d46add30f262c45f9578e2b1352dbaca0256af1ff3235532")
+	logTx           = common.HexToHash("0x97a385bf570ced7821c6495b3877ddd2afd5c452f350f0d4876e98d9161389c6")
+)
+
+// fieldArgs are the arguments of the fields with required arguments.
+var fieldArgs = map[string]string{
+	"Account.storage":     `(slot: "0x0000000000000000000000000000000000000000000000000000000000000000")`,
+	"Block.account":       fmt.Sprintf(`(address: "%s")`, strings.ToLower(senderAccount.Hex())),
+	"Block.call":          fmt.Sprintf(`(data: {to: "%s", data: "0x"})`, strings.ToLower(contractAccount.Hex())),
+	"Block.estimateGas":   fmt.Sprintf(`(data: {from: "%s", to: "%s"})`, strings.ToLower(senderAccount.Hex()), strings.ToLower(contractAccount.Hex())),
+	"Block.logs":          "(filter: {})",
+	"Block.ommerAt":       "(index: 0)",
+	"Block.transactionAt": "(index: 0)",
+}
+
+// selectionFields are the fields selected from objects returned by a field.
+var selectionFields = map[string]string{
+	"AccessTuple": "address",
+	"Account":     "address",
+	"Block":       "hash",
+	"CallResult":  "status",
+	"Log":         "index",
+	"Transaction": "hash",
+	"Withdrawal":  "index",
+}
+
+// fieldTargets returns the objects of the test chain whose fields are tested: the
+// Shanghai and Cancun blocks, a dynamic fee and a blob transaction, a log and an
+// account.
+func fieldTargets(chain *testChain) []*fieldTarget {
+	var targets []*fieldTarget
+	for _, number := range []int{33, 34} {
+		targets = append(targets, &fieldTarget{
+			name:   fmt.Sprintf("block %d", number),
+			typ:    "Block",
+			query:  fmt.Sprintf("{ block(number: %d) { %%s } }", number),
+			path:   []string{"block"},
+			expect: chain.blockFields(number),
+		})
+	}
+	for _, hash := range []common.Hash{dynamicFeeTx, blobTx} {
+		targets = append(targets, &fieldTarget{
+			name:   fmt.Sprintf("transaction %s", hash.TerminalString()),
+			typ:    "Transaction",
+			query:  fmt.Sprintf(`{ transaction(hash: "%s") { %%s } }`, hash.Hex()),
+			path:   []string{"transaction"},
+			expect: chain.transactionFields(hash),
+		})
+	}
+	targets = append(targets, &fieldTarget{
+		name:   fmt.Sprintf("log of transaction %s", logTx.TerminalString()),
+		typ:    "Log",
+		query:  fmt.Sprintf(`{ transaction(hash: "%s") { logs { %%s } } }`, logTx.Hex()),
+		path:   []string{"transaction", "logs"},
+		expect: map[string]interface{}{"transaction": map[string]interface{}{"hash": logTx.Hex()}},
+	})
+	head := len(chain.blocks) - 1
+	targets = append(targets, &fieldTarget{
+		name:   fmt.Sprintf("account %s", senderAccount.Hex()),
+		typ:    "Account",
+		query:  fmt.Sprintf(`{ block(number: %d) { account(address: "%s") { %%s } } }`, head, strings.ToLower(senderAccount.Hex())),
+		path:   []string{"block", "account"},
+		expect: chain.accountFields(senderAccount, head),
+	})
+	return targets
+}
+
+// blockFields returns the field values of a block known from the chain.
+func (chain *testChain) blockFields(number int) map[string]interface{} {
+	block := chain.blocks[number]
+	h := block.Header()
+	rawHeader, _ := rlp.EncodeToBytes(h)
+	raw, _ := rlp.EncodeToBytes(block)
+	fields := map[string]interface{}{
+		"number":           hexutil.EncodeUint64(h.Number.Uint64()),
+		"hash":             block.Hash().Hex(),
+		"parent":           map[string]interface{}{"hash": h.ParentHash.Hex()},
+		"nonce":            hexutil.Encode(h.Nonce[:]),
+		"transactionsRoot": h.TxHash.Hex(),
+		"transactionCount": hexutil.EncodeUint64(uint64(len(block.Transactions()))),
+		"stateRoot":        h.Root.Hex(),
+		"receiptsRoot":     h.ReceiptHash.Hex(),
+		"miner":            map[string]interface{}{"address": h.Coinbase.Hex()},
+		"extraData":        hexutil.Encode(h.Extra),
+		"gasLimit":         hexutil.EncodeUint64(h.GasLimit),
+		"gasUsed":          hexutil.EncodeUint64(h.GasUsed),
+		"baseFeePerGas":    bigValue(h.BaseFee),
+		"timestamp":        hexutil.EncodeUint64(h.Time),
+		"logsBloom":        hexutil.Encode(h.Bloom[:]),
+		"mixHash":          h.MixDigest.Hex(),
+		"difficulty":       hexutil.EncodeBig(h.Difficulty),
+		"totalDifficulty":  hexutil.EncodeBig(chain.td[number]),
+		"ommerCount":       hexutil.EncodeUint64(uint64(len(block.Uncles()))),
+		"ommers":           []interface{}{},
+		"ommerAt":          nil,
+		"ommerHash":        h.UncleHash.Hex(),
+		"account":          map[string]interface{}{"address": senderAccount.Hex()},
+		"rawHeader":        hexutil.Encode(rawHeader),
+		"raw":              hexutil.Encode(raw),
+		"withdrawalsRoot":  nil,
+		"withdrawals":      nil,
+		"blobGasUsed":      uint64Value(h.BlobGasUsed),
+		"excessBlobGas":    uint64Value(h.ExcessBlobGas),
+	}
+	if next := new(big.Int).Add(h.Number, common.Big1); chain.genesis.Config.IsLondon(next) {
+		fields["nextBaseFeePerGas"] = hexutil.EncodeBig(eip1559.CalcBaseFee(chain.genesis.Config, h))
+	}
+	txs := []interface{}{}
+	for _, tx := range block.Transactions() {
+		txs = append(txs, map[string]interface{}{"hash": tx.Hash().Hex()})
+	}
+	fields["transactions"] = txs
+	if len(txs) > 0 {
+		fields["transactionAt"] = txs[0]
+	}
+	if h.WithdrawalsHash != nil {
+		fields["withdrawalsRoot"] = h.WithdrawalsHash.Hex()
+		withdrawals := []interface{}{}
+		for _, w := range block.Withdrawals() {
+			withdrawals = append(withdrawals, map[string]interface{}{"index": hexutil.EncodeUint64(w.Index)})
+		}
+		fields["withdrawals"] = withdrawals
+	}
+	return fields
+}
+
+// transactionFields returns the field values of a transaction known from the
+// chain. The fields of the receipt are only checked for their format.
+func (chain *testChain) transactionFields(hash common.Hash) map[string]interface{} {
+	block, index := chain.blockTx(hash)
+	if block == nil {
+		return nil
+	}
+	tx := block.Transactions()[index]
+	signer := types.LatestSignerForChainID(chain.genesis.Config.ChainID)
+	from, _ := types.Sender(signer, tx)
+	raw, _ := tx.MarshalBinary()
+	v, r, s := tx.RawSignatureValues()
+	fields := map[string]interface{}{
+		"hash":                 tx.Hash().Hex(),
+		"nonce":                hexutil.EncodeUint64(tx.Nonce()),
+		"index":                hexutil.EncodeUint64(uint64(index)),
+		"from":                 map[string]interface{}{"address": from.Hex()},
+		"to":                   nil,
+		"value":                hexutil.EncodeBig(tx.Value()),
+		"maxFeePerGas":         hexutil.EncodeBig(tx.GasFeeCap()),
+		"maxPriorityFeePerGas": hexutil.EncodeBig(tx.GasTipCap()),
+		"maxFeePerBlobGas":     nil,
+		"gas":                  hexutil.EncodeUint64(tx.Gas()),
+		"inputData":            hexutil.Encode(tx.Data()),
+		"block":                map[string]interface{}{"hash": block.Hash().Hex()},
+		"blobGasUsed":          nil,
+		"blobGasPrice":         nil,
+		"r":                    hexutil.EncodeBig(r),
+		"s":                    hexutil.EncodeBig(s),
+		"v":                    hexutil.EncodeBig(v),
+		"yParity":              hexutil.EncodeBig(v),
+		"type":                 hexutil.EncodeUint64(uint64(tx.Type())),
+		"raw":                  hexutil.Encode(raw),
+		"blobVersionedHashes":  nil,
+	}
+	if to := tx.To(); to != nil {
+		fields["to"] = map[string]interface{}{"address": to.Hex()}
+	}
+	if tip, err := tx.EffectiveGasTip(block.BaseFee()); err == nil {
+		fields["effectiveTip"] = hexutil.EncodeBig(tip)
+		fields["effectiveGasPrice"] = hexutil.EncodeBig(new(big.Int).Add(block.BaseFee(), tip))
+	}
+	accessList := []interface{}{}
+	for _, tuple := range tx.AccessList() {
+		accessList = append(accessList, map[string]interface{}{"address": tuple.Address.Hex()})
+	}
+	fields["accessList"] = accessList
+	if tx.Type() == types.BlobTxType {
+		fields["maxFeePerBlobGas"] = hexutil.EncodeBig(tx.BlobGasFeeCap())
+		fields["blobGasUsed"] = hexutil.EncodeUint64(tx.BlobGas())
+		fields["blobGasPrice"] = hexutil.EncodeBig(eip4844.CalcBlobFee(*block.ExcessBlobGas()))
+		hashes := []interface{}{}
+		for _, h := range tx.BlobHashes() {
+			hashes = append(hashes, h.Hex())
+		}
+		fields["blobVersionedHashes"] = hashes
+	}
+	return fields
+}
+
+// accountFields returns the field values of an externally owned account at a block
+// known from the chain.
+func (chain *testChain) accountFields(addr common.Address, number int) map[string]interface{} {
+	signer := types.LatestSignerForChainID(chain.genesis.Config.ChainID)
+	var nonce uint64
+	for _, block := range chain.blocks[:number+1] {
+		for _, tx := range block.Transactions() {
+			if from, _ := types.Sender(signer, tx); from == addr {
+				nonce++
+			}
+		}
+	}
+	return map[string]interface{}{
+		"address":          addr.Hex(),
+		"transactionCount": hexutil.EncodeUint64(nonce),
+		"code":             "0x",
+		"storage":          common.Hash{}.Hex(),
+	}
+}
+
+func bigValue(v *big.Int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return hexutil.EncodeBig(v)
+}
+
+func uint64Value(v *uint64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return hexutil.EncodeUint64(*v)
+}
+
+// selection returns the selection of a field, with its arguments and the selected
+// field of an object type. It returns false if the field has required arguments
+// which aren't known.
+func (target *fieldTarget) selection(schema *introspectionSchema, f *field) (string, bool) {
+	sel := f.Name
+	for _, arg := range f.Args {
+		if arg.Type.Kind == "NON_NULL" {
+			args, ok := fieldArgs[target.typ+"."+f.Name]
+			if !ok {
+				return "", false
+			}
+			sel += args
+			break
+		}
+	}
+	if sub := subSelection(schema, f.Type); sub != "" {
+		sel += " { " + sub + " }"
+	}
+	return sel, true
+}
+
+// subSelection returns the field selected from an object type, or the empty
+// string for other types.
+func subSelection(schema *introspectionSchema, t *typeRef) string {
+	named := t.named()
+	if named.Kind != "OBJECT" || named.Name == nil {
+		return ""
+	}
+	if sub, ok := selectionFields[*named.Name]; ok {
+		return sub
+	}
+	if typ := schema.lookup(*named.Name); typ != nil {
+		for _, f := range typ.Fields {
+			if len(f.Args) == 0 && f.Type.named().Kind == "SCALAR" {
+				return f.Name
+			}
+		}
+	}
+	return "__typename"
+}
+
+// fieldTest queries the fields of the reference type of a target one by one, and
+// compares their values to the expected values of the chain. Fields without
+// expected values are checked for their format. Every failing field is reported.
+func fieldTest(t *hivesim.T, c *hivesim.Client, schema *introspectionSchema, target *fieldTarget) {
+	typ := schema.lookup(target.typ)
+	if typ == nil {
+		t.Fatalf("type %s not in reference schema", target.typ)
+	}
+	var checked int
+	for _, f := range typ.Fields {
+		sel, ok := target.selection(schema, f)
+		if !ok {
+			t.Logf("%s.%s: skipped, arguments unknown", target.typ, f.Name)
+			continue
+		}
+		query := fmt.Sprintf(target.query, sel)
+		got, err := queryField(c, query, target.path, f.Name)
+		if err == nil {
+			want, known := target.expect[f.Name]
+			if known {
+				err = compareValue(got, want)
+			} else {
+				err = checkFormat(schema, f.Type, got)
+			}
+		}
+		if err != nil {
+			t.Errorf("%s.%s: %v\nquery: %s", target.typ, f.Name, err, query)
+		}
+		checked++
+	}
+	t.Logf("checked %d fields of %s", checked, target.name)
+}
+
+// queryField sends a query and returns the value of a field of the object at the
+// given path in the response.
+func queryField(c *hivesim.Client, query string, path []string, name string) (interface{}, error) {
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := postQuery(c, query, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		var msgs []string
+		for _, e := range result.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return nil, fmt.Errorf("query failed: %s", strings.Join(msgs, "; "))
+	}
+	var obj interface{} = result.Data
+	for _, key := range path {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: no object in response", key)
+		}
+		obj = m[key]
+		if list, ok := obj.([]interface{}); ok && len(list) > 0 {
+			obj = list[0]
+		}
+		if obj == nil {
+			return nil, fmt.Errorf("%s is null", key)
+		}
+	}
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no object in response")
+	}
+	value, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("field missing in response")
+	}
+	return value, nil
+}
+
+// compareValue compares a value of the response to the expected value.
+func compareValue(got, want interface{}) error {
+	if g, w := canonicalValue(got), canonicalValue(want); !jsonEqual(g, w) {
+		return fmt.Errorf("got %s, want %s", jsonString(got), jsonString(want))
+	}
+	return nil
+}
+
+// canonicalValue converts numbers to hexadecimal strings and all strings to lower
+// case, since clients may return Long values as JSON numbers and hex strings in
+// mixed case.
+func canonicalValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return hexutil.EncodeBig(n)
+		}
+		return v.String()
+	case string:
+		return strings.ToLower(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = canonicalValue(v[i])
+		}
+		return list
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			obj[key] = canonicalValue(value)
+		}
+		return obj
+	default:
+		return v
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return jsonString(a) == jsonString(b)
+}
+
+// Formats of the scalar types of the reference schema.
+var scalarFormats = map[string]*regexp.Regexp{
+	"Address": regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`),
+	"Bytes32": regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`),
+	"Bytes":   regexp.MustCompile(`^0x([0-9a-fA-F]{2})*$`),
+	"BigInt":  regexp.MustCompile(`^0x(0|[1-9a-fA-F][0-9a-fA-F]*)$`),
+	"Long":    regexp.MustCompile(`^0x(0|[1-9a-fA-F][0-9a-fA-F]*)$`),
+}
+
+// checkFormat checks that a value of the response has the given type.
+func checkFormat(schema *introspectionSchema, t *typeRef, v interface{}) error {
+	switch {
+	case t.Kind == "NON_NULL":
+		if v == nil {
+			return fmt.Errorf("null value of non-null type %v", t)
+		}
+		return checkFormat(schema, t.OfType, v)
+	case v == nil:
+		return nil
+	case t.Kind == "LIST":
+		list, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("value %s is not a list", jsonString(v))
+		}
+		for i, elem := range list {
+			if err := checkFormat(schema, t.OfType, elem); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return nil
+	case t.Kind == "OBJECT":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("value %s is not an object", jsonString(v))
+		}
+		sub := subSelection(schema, t)
+		if typ := schema.lookup(*t.Name); typ != nil {
+			if f := typ.field(sub); f != nil {
+				if err := checkFormat(schema, f.Type, obj[sub]); err != nil {
+					return fmt.Errorf("%s: %v", sub, err)
+				}
+			}
+		}
+		return nil
+	}
+	format, ok := scalarFormats[*t.Name]
+	if !ok {
+		return nil
+	}
+	if _, isNumber := v.(json.Number); isNumber && (*t.Name == "Long" || *t.Name == "BigInt") {
+		return nil
+	}
+	if s, ok := v.(string); !ok || !format.MatchString(s) {
+		return fmt.Errorf("value %s is not a valid %s", jsonString(v), *t.Name)
+	}
+	return nil
+}
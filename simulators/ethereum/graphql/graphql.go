@@ -18,11 +18,16 @@ import (
 	"github.com/ethereum/hive/hivesim"
 )
 
+const (
+	genesisPath = "./init/testGenesis.json"
+	chainPath   = "./init/testBlockchain.blocks"
+	schemaPath  = "./init/schema.graphqls"
+)
+
 func main() {
 	var (
-		genesisPath = "./init/testGenesis.json"
-		genesis     = loadGenesis(genesisPath)
-		params      = getParameters(genesis)
+		genesis = loadGenesis(genesisPath)
+		params  = getParameters(genesis)
 	)
 
 	suite := hivesim.Suite{
@@ -41,7 +46,7 @@ the client launched by this test.`,
 			// The chain has originated from the Besu client. It consisted of Frontier blocks.
 			// It has been since extended with post-merge blocks.
 			"/genesis.json": genesisPath,
-			"/chain.rlp":    "./init/testBlockchain.blocks",
+			"/chain.rlp":    chainPath,
 		},
 		Run: graphqlTest,
 	})
@@ -49,6 +54,15 @@ the client launched by this test.`,
 }
 
 func graphqlTest(t *hivesim.T, c *hivesim.Client) {
+	reference, _, err := loadReferenceSchema(schemaPath)
+	if err != nil {
+		t.Fatal("can't load reference schema:", err)
+	}
+	chain, err := loadChain(chainPath, loadGenesis(genesisPath))
+	if err != nil {
+		t.Fatal("can't load test chain:", err)
+	}
+
 	parallelism := 16
 	if val, ok := os.LookupEnv("HIVE_PARALLELISM"); ok {
 		if p, err := strconv.Atoi(val); err != nil {
@@ -75,6 +89,20 @@ func graphqlTest(t *hivesim.T, c *hivesim.Client) {
 		}()
 	}
 	wg.Wait()
+
+	t.Run(hivesim.TestSpec{
+		Name:        fmt.Sprintf("schema introspection (%s)", c.Type),
+		Description: "This test compares the schema of the client, queried with introspection, to the EIP-1767 schema.",
+		Run:         func(t *hivesim.T) { schemaTest(t, c, reference) },
+	})
+	for _, target := range fieldTargets(chain) {
+		target := target
+		t.Run(hivesim.TestSpec{
+			Name:        fmt.Sprintf("%s fields (%s)", target.name, c.Type),
+			Description: fmt.Sprintf("This test queries every field of the %s type for the %s of the test chain.", target.typ, target.name),
+			Run:         func(t *hivesim.T) { fieldTest(t, c, reference, target) },
+		})
+	}
 }
 
 // deliverTests reads the test case files, sending them to the output channel.
@@ -138,20 +166,10 @@ func (tc *testCase) run(t *hivesim.T, c *hivesim.Client) {
 	// Example of working queries:
 	// curl 'http://127.0.0.1:8545/graphql' --data-binary '{"query":"query blockNumber {\n  block {\n    number\n  }\n}\n"}'
 	// curl 'http://127.0.0.1:8545/graphql' --data-binary '{"query":"query blockNumber {\n  block {\n    number\n  }\n}\n","variables":null,"operationName":"blockNumber"}'
-	postData, err := json.Marshal(qlQuery{Query: tc.gqlTest.Request})
-	if err != nil {
-		t.Fatal("can't marshal query:", err)
-	}
-	url := fmt.Sprintf("http://%v:8545/graphql", c.IP)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(postData))
+	resp, respBytes, err := post(c, tc.gqlTest.Request)
 	if err != nil {
-		t.Fatal("HTTP post failed:", err)
+		t.Fatal(err)
 	}
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		t.Fatal("can't read HTTP response:", err)
-	}
-	resp.Body.Close()
 
 	if resp.StatusCode != tc.gqlTest.StatusCode {
 		t.Errorf("HTTP response code is %d, want %d \n response body: %s", resp.StatusCode, tc.gqlTest.StatusCode, string(respBytes))
@@ -199,6 +217,51 @@ func (tc *testCase) responseMatch(t *hivesim.T, respStatus string, respBytes []b
 	return fmt.Errorf("test failed")
 }
 
+// post sends a query to the GraphQL endpoint of the client.
+func post(c *hivesim.Client, query string) (*http.Response, []byte, error) {
+	postData, err := json.Marshal(qlQuery{Query: query})
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't marshal query: %v", err)
+	}
+	url := fmt.Sprintf("http://%v:8545/graphql", c.IP)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(postData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP post failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't read HTTP response: %v", err)
+	}
+	return resp, respBytes, nil
+}
+
+// postQuery sends a query to the client and decodes the response into result.
+// Numbers are decoded as json.Number.
+func postQuery(c *hivesim.Client, query string, result interface{}) error {
+	resp, respBytes, err := post(c, query)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP response code is %d, response body: %s", resp.StatusCode, respBytes)
+	}
+	dec := json.NewDecoder(bytes.NewReader(respBytes))
+	dec.UseNumber()
+	if err := dec.Decode(result); err != nil {
+		return fmt.Errorf("can't decode response: %v", err)
+	}
+	return nil
+}
+
+func jsonString(v interface{}) string {
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(enc)
+}
+
 func reindentJSON(text string) (string, bool) {
 	var obj interface{}
 	if json.Unmarshal([]byte(text), &obj) != nil {
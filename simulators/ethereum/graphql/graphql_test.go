@@ -2,7 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/hive/hivesim"
@@ -91,3 +95,119 @@ func Test_responseMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareSchema(t *testing.T) {
+	reference, _, err := loadReferenceSchema(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := compareSchema(reference, reference); len(diffs) != 0 {
+		t.Fatalf("reference schema differs from itself: %v", diffs)
+	}
+
+	// Modify a copy of the reference schema.
+	client, _, _ := loadReferenceSchema(schemaPath)
+	client.MutationType = nil
+	block := client.lookup("Block")
+	block.Fields = block.Fields[1:] // number
+	long := "Long"
+	client.lookup("Account").field("balance").Type.OfType.Name = &long
+	want := []string{
+		"Account.balance: type Long!, want BigInt!",
+		"Block.number: field missing",
+		"schema mutation type: <none>, want Mutation",
+	}
+	if diffs := compareSchema(reference, client); !reflect.DeepEqual(diffs, want) {
+		t.Fatalf("wrong differences\ngot:  %q\nwant: %q", diffs, want)
+	}
+}
+
+func TestFieldTargets(t *testing.T) {
+	reference, schema, err := loadReferenceSchema(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := loadChain(chainPath, loadGenesis(genesisPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := schema.Validate(introspectionQuery); len(errs) > 0 {
+		t.Fatalf("invalid introspection query: %v", errs)
+	}
+	if len(chain.blocks) != 35 {
+		t.Fatalf("wrong number of blocks %d", len(chain.blocks))
+	}
+
+	for _, target := range fieldTargets(chain) {
+		typ := reference.lookup(target.typ)
+		for _, f := range typ.Fields {
+			sel, ok := target.selection(reference, f)
+			if !ok {
+				t.Errorf("%s.%s: no query", target.typ, f.Name)
+				continue
+			}
+			query := fmt.Sprintf(target.query, sel)
+			if errs := schema.Validate(query); len(errs) > 0 {
+				t.Errorf("%s.%s: invalid query %s: %v", target.typ, f.Name, query, errs)
+			}
+		}
+		for name := range target.expect {
+			if typ.field(name) == nil {
+				t.Errorf("%s: expected value of unknown field %s", target.name, name)
+			}
+		}
+	}
+
+	block := chain.blockFields(34)
+	if hash := block["hash"].(string); !strings.HasPrefix(hash, "0x4859ea10") {
+		t.Errorf("wrong hash %s of block 34", hash)
+	}
+	tx := chain.transactionFields(blobTx)
+	if tx["blobGasPrice"] != "0x1" || tx["index"] != "0x0" || tx["type"] != "0x3" {
+		t.Errorf("wrong blob transaction fields %v", tx)
+	}
+}
+
+func TestCheckValue(t *testing.T) {
+	reference, _, err := loadReferenceSchema(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockType := reference.lookup("Block")
+
+	// Long values may be returned as numbers, hex strings in any case.
+	if err := compareValue(json.Number("33"), "0x21"); err != nil {
+		t.Error(err)
+	}
+	if err := compareValue(map[string]interface{}{"hash": "0xABCD"}, map[string]interface{}{"hash": "0xabcd"}); err != nil {
+		t.Error(err)
+	}
+	if err := compareValue("0x22", "0x21"); err == nil {
+		t.Error("no error for wrong value")
+	}
+
+	tests := []struct {
+		field string
+		value interface{}
+		ok    bool
+	}{
+		{"hash", "0x" + strings.Repeat("ab", 32), true},
+		{"hash", "0xabcd", false},
+		{"hash", nil, false},
+		{"nonce", "0x0000000000000000", true},
+		{"nonce", "0x000", false},
+		{"gasUsed", "0x5208", true},
+		{"gasUsed", "0x05208", false},
+		{"gasUsed", json.Number("21000"), true},
+		{"baseFeePerGas", nil, true},
+		{"miner", map[string]interface{}{"address": "0x" + strings.Repeat("00", 20)}, true},
+		{"miner", map[string]interface{}{"address": "0x00"}, false},
+		{"transactions", []interface{}{map[string]interface{}{"hash": "0x"}}, false},
+	}
+	for _, test := range tests {
+		err := checkFormat(reference, blockType.field(test.field).Type, test.value)
+		if (err == nil) != test.ok {
+			t.Errorf("%s %v: wrong result %v", test.field, test.value, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/hive/hivesim"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// introspectionQuery requests the parts of the client schema which are compared
+// against the reference schema.
+const introspectionQuery = `{
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) { name args { ...InputValue } type { ...TypeRef } }
+      inputFields { ...InputValue }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+fragment InputValue on __InputValue { name type { ...TypeRef } }
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// introspectionSchema is the result of a schema introspection query.
+type introspectionSchema struct {
+	QueryType        *namedType           `json:"queryType"`
+	MutationType     *namedType           `json:"mutationType"`
+	SubscriptionType *namedType           `json:"subscriptionType"`
+	Types            []*introspectionType `json:"types"`
+}
+
+type namedType struct {
+	Name string `json:"name"`
+}
+
+type introspectionType struct {
+	Kind        string        `json:"kind"`
+	Name        string        `json:"name"`
+	Fields      []*field      `json:"fields"`
+	InputFields []*inputValue `json:"inputFields"`
+	EnumValues  []*namedType  `json:"enumValues"`
+}
+
+type field struct {
+	Name string        `json:"name"`
+	Args []*inputValue `json:"args"`
+	Type *typeRef      `json:"type"`
+}
+
+type inputValue struct {
+	Name string   `json:"name"`
+	Type *typeRef `json:"type"`
+}
+
+// typeRef is a reference to a type, possibly wrapped in lists and non-null types.
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   *string  `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String returns the type in GraphQL notation, e.g. [Bytes32!]!.
+func (t *typeRef) String() string {
+	switch {
+	case t == nil:
+		return "<none>"
+	case t.Kind == "NON_NULL":
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST":
+		return "[" + t.OfType.String() + "]"
+	case t.Name == nil:
+		return "<unnamed>"
+	default:
+		return *t.Name
+	}
+}
+
+// named returns the named type of a type reference, without lists and non-null
+// types.
+func (t *typeRef) named() *typeRef {
+	for t != nil && t.OfType != nil {
+		t = t.OfType
+	}
+	return t
+}
+
+// lookup returns the type with the given name.
+func (s *introspectionSchema) lookup(name string) *introspectionType {
+	for _, typ := range s.Types {
+		if typ.Name == name {
+			return typ
+		}
+	}
+	return nil
+}
+
+// field returns the field of a type.
+func (t *introspectionType) field(name string) *field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// loadReferenceSchema reads the reference schema and returns its introspection.
+func loadReferenceSchema(file string) (*introspectionSchema, *graphql.Schema, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := graphql.ParseSchema(string(text), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid reference schema: %v", err)
+	}
+	data, err := schema.ToJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	var result struct {
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, nil, err
+	}
+	return result.Schema, schema, nil
+}
+
+// compareSchema returns the differences of the client schema to the reference
+// schema, one for every missing or mismatching type, field, argument and enum
+// value. Additional types and fields of the client are allowed.
+func compareSchema(want, got *introspectionSchema) []string {
+	var diffs []string
+	report := func(format string, args ...interface{}) {
+		diffs = append(diffs, fmt.Sprintf(format, args...))
+	}
+	rootName := func(t *namedType) string {
+		if t == nil {
+			return "<none>"
+		}
+		return t.Name
+	}
+	for _, root := range []struct {
+		name      string
+		want, got *namedType
+	}{
+		{"query", want.QueryType, got.QueryType},
+		{"mutation", want.MutationType, got.MutationType},
+		{"subscription", want.SubscriptionType, got.SubscriptionType},
+	} {
+		if rootName(root.want) != rootName(root.got) {
+			report("schema %s type: %s, want %s", root.name, rootName(root.got), rootName(root.want))
+		}
+	}
+
+	for _, wantType := range want.Types {
+		if strings.HasPrefix(wantType.Name, "__") {
+			continue
+		}
+		gotType := got.lookup(wantType.Name)
+		if gotType == nil {
+			report("%s: type missing", wantType.Name)
+			continue
+		}
+		if gotType.Kind != wantType.Kind {
+			report("%s: kind %s, want %s", wantType.Name, gotType.Kind, wantType.Kind)
+			continue
+		}
+		for _, wantField := range wantType.Fields {
+			gotField := gotType.field(wantField.Name)
+			if gotField == nil {
+				report("%s.%s: field missing", wantType.Name, wantField.Name)
+				continue
+			}
+			if gotField.Type.String() != wantField.Type.String() {
+				report("%s.%s: type %v, want %v", wantType.Name, wantField.Name, gotField.Type, wantField.Type)
+			}
+			compareInputValues(report, wantType.Name+"."+wantField.Name, wantField.Args, gotField.Args)
+		}
+		compareInputValues(report, wantType.Name, wantType.InputFields, gotType.InputFields)
+		for _, wantValue := range wantType.EnumValues {
+			found := false
+			for _, gotValue := range gotType.EnumValues {
+				found = found || gotValue.Name == wantValue.Name
+			}
+			if !found {
+				report("%s.%s: enum value missing", wantType.Name, wantValue.Name)
+			}
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// compareInputValues compares the arguments of a field, or the fields of an input
+// object.
+func compareInputValues(report func(string, ...interface{}), prefix string, want, got []*inputValue) {
+	for _, w := range want {
+		var g *inputValue
+		for _, v := range got {
+			if v.Name == w.Name {
+				g = v
+			}
+		}
+		switch {
+		case g == nil:
+			report("%s(%s): argument missing", prefix, w.Name)
+		case g.Type.String() != w.Type.String():
+			report("%s(%s): type %v, want %v", prefix, w.Name, g.Type, w.Type)
+		}
+	}
+}
+
+// schemaTest compares the schema of the client, queried with introspection, to the
+// reference schema. Every difference is reported.
+func schemaTest(t *hivesim.T, c *hivesim.Client, reference *introspectionSchema) {
+	var result struct {
+		Data struct {
+			Schema *introspectionSchema `json:"__schema"`
+		} `json:"data"`
+		Errors []interface{} `json:"errors"`
+	}
+	if err := postQuery(c, introspectionQuery, &result); err != nil {
+		t.Fatal("introspection query failed:", err)
+	}
+	if len(result.Errors) > 0 || result.Data.Schema == nil {
+		t.Fatalf("introspection query failed: %s", jsonString(result.Errors))
+	}
+	diffs := compareSchema(reference, result.Data.Schema)
+	for _, diff := range diffs {
+		t.Error(diff)
+	}
+	if len(diffs) == 0 {
+		t.Log("client schema matches the reference schema")
+	}
+}
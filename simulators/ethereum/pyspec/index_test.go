@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixtureIndex(t *testing.T) {
+	root := t.TempDir() + "/"
+	file := filepath.Join(root, "cancun", "test.json")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{
+		"engine_test": {"network": "Cancun", "engineNewPayloads": []},
+		"state_test": {"env": {}, "transaction": {}, "post": {"Shanghai": [], "Cancun": []}},
+		"tx_test": {"txbytes": "0x", "result": {"Prague": {}}}
+	}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := buildIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexFile := filepath.Join(t.TempDir(), "index.json")
+	if err := index.write(indexFile); err != nil {
+		t.Fatal(err)
+	}
+	index, err = loadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, ok := index.lookup(file, info)
+	if !ok || len(tests) != 3 {
+		t.Fatalf("wrong indexed tests %v", tests)
+	}
+	forks := make(map[string][]string)
+	for _, test := range tests {
+		forks[test.Format] = test.Forks
+	}
+	want := map[string][]string{
+		blockchainTestEngine: {"Cancun"},
+		stateTest:            {"Cancun", "Shanghai"},
+		transactionTest:      {"Prague"},
+	}
+	if !reflect.DeepEqual(forks, want) {
+		t.Fatalf("wrong forks by format %v", forks)
+	}
+
+	// Forks are selected per format.
+	for _, check := range []struct {
+		forks  forkSet
+		format string
+		want   bool
+	}{
+		{forkSet{}, blockchainTestEngine, true},
+		{forkSet{"Cancun": true}, blockchainTestEngine, true},
+		{forkSet{"Shanghai": true}, blockchainTestEngine, false},
+		{forkSet{"Shanghai": true}, stateTest, true},
+		{forkSet{"Cancun": true}, transactionTest, false},
+		{forkSet{}, blockchainTest, false},
+	} {
+		if got := check.forks.anySelected(tests, check.format); got != check.want {
+			t.Errorf("anySelected(%v, %s) = %v, want %v", check.forks, check.format, got, check.want)
+		}
+	}
+
+	// Entries of changed files are ignored.
+	if err := os.WriteFile(file, []byte(content+" "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = os.Stat(file)
+	if _, ok := index.lookup(file, info); ok {
+		t.Error("index entry of changed file used")
+	}
+
+	// A missing index is empty.
+	index, err = loadIndex(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(index.Files) != 0 {
+		t.Errorf("wrong missing index %v (err %v)", index, err)
+	}
+}
+
+func TestForksFromEnv(t *testing.T) {
+	t.Setenv("FORKS", " Shanghai,,Cancun ")
+	forks := forksFromEnv()
+	if forks.String() != "Cancun,Shanghai" {
+		t.Fatalf("wrong forks %v", forks)
+	}
+	if !forks.has("Cancun") || forks.has("Prague") {
+		t.Fatal("wrong fork selection")
+	}
+	if !(forkSet{}).has("Prague") {
+		t.Fatal("empty set doesn't select all forks")
+	}
+}
@@ -6,37 +6,85 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/ethereum/hive/hivesim"
 )
 
+// fixtureType is a fixture format of execution-spec-tests, which is run by its own
+// meta-test.
+type fixtureType struct {
+	format string
+	// name of the meta-test
+	runner string
+	// environment variable with the fixtures directory
+	pathEnv string
+	// environment variable which enables an optional fixture type
+	enableEnv string
+	load      func(t *hivesim.T, root string, re *regexp.Regexp, index *fixtureIndex, forks forkSet, fn func(TestCase))
+}
+
+var fixtureTypes = []fixtureType{
+	{format: blockchainTestEngine, runner: "pytest_fixture_runner", pathEnv: "TESTPATH", load: loadFixtureTests},
+	{format: stateTest, runner: "state_test_fixture_runner", pathEnv: "STATE_TESTPATH", enableEnv: "STATE_TESTS", load: loadStateTests},
+	{format: transactionTest, runner: "transaction_test_fixture_runner", pathEnv: "TRANSACTION_TESTPATH", enableEnv: "TRANSACTION_TESTS", load: loadTransactionTests},
+}
+
+// enabled reports whether the fixture type is run. Fixture types other than
+// blockchain_test_engine are optional.
+func (ft fixtureType) enabled() bool {
+	if ft.enableEnv == "" {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(ft.enableEnv))
+	return enabled
+}
+
 func main() {
+	indexFile := flag.String("index", "", "write the fixture index of the fixture directories to the file and exit")
+	flag.Parse()
+	if *indexFile != "" {
+		if err := writeIndex(*indexFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	suite := hivesim.Suite{
 		Name: "pyspec",
 		Description: "The pyspec test suite runs every fixture from " +
 			"the execution-spec-tests repo (https://github.com/ethereum/execution-spec-tests) where the fork >= Merge. " +
-			"For each test clients are first fed the fixture genesis data followed by engine new payloads specific to the test.",
-	}
-	suite.Add(hivesim.TestSpec{
-		Name: "pytest_fixture_runner",
-		Description: "This is a meta-test. It loads the test fixture files and " +
-			"launches the actual client tests. Any errors in test files will be reported " +
-			"through this test.",
-		Run:       fixtureRunner,
-		AlwaysRun: true,
-	})
+			"For each test clients are first fed the fixture genesis data followed by engine new payloads specific to the test. " +
+			"State tests are run by building a single block with the Engine API, and transaction tests by sending the " +
+			"transaction with eth_sendRawTransaction.",
+	}
+	for _, ft := range fixtureTypes {
+		ft := ft
+		if !ft.enabled() {
+			continue
+		}
+		suite.Add(hivesim.TestSpec{
+			Name: ft.runner,
+			Description: "This is a meta-test. It loads the " + ft.format + " fixture files and " +
+				"launches the actual client tests. Any errors in test files will be reported " +
+				"through this test.",
+			Run:       func(t *hivesim.T) { fixtureRunner(t, ft) },
+			AlwaysRun: true,
+		})
+	}
 	hivesim.MustRunSuite(hivesim.New(), suite)
 }
 
-// fixtureRunner loads the pyspec test files and spawns the client tests.
-func fixtureRunner(t *hivesim.T) {
+// fixtureRunner loads the pyspec test files of a fixture type and spawns the client
+// tests.
+func fixtureRunner(t *hivesim.T, ft fixtureType) {
 
 	// retrieve clients available for testing
 	clientTypes, err := t.Sim.ClientTypes()
@@ -56,33 +104,72 @@ func fixtureRunner(t *hivesim.T) {
 	t.Log("parallelism set to:", parallelism)
 
 	// find and set the fixtures directory as root
-	testPath, isset := os.LookupEnv("TESTPATH")
-	if !isset {
-		t.Fatal("$TESTPATH not set")
+	testPath := os.Getenv(ft.pathEnv)
+	if testPath == "" {
+		t.Fatalf("$%s not set", ft.pathEnv)
 	}
 	fileRoot := fmt.Sprintf("%s/", testPath)
 	t.Log("file root directory:", fileRoot)
 
+	// load the fixture index and the selected forks
+	index, err := loadIndex(os.Getenv("FIXTURE_INDEX"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("fixture index: %d files", len(index.Files))
+	forks := forksFromEnv()
+	if len(forks) > 0 {
+		t.Log("forks:", forks)
+	}
+
+	// blockchain_test_engine tests with the same genesis can share a client
+	reuse := false
+	if val, ok := os.LookupEnv("REUSE_CLIENTS"); ok && ft.format == blockchainTestEngine {
+		if reuse, err = strconv.ParseBool(val); err != nil {
+			t.Logf("warning: invalid REUSE_CLIENTS value %q", val)
+		}
+	}
+	t.Log("reuse clients:", reuse)
+
 	// to log all failing tests at the end of sim
 	failedTests := make(map[string]error)
 
-	// spawn `parallelism` workers to run fixtures against clients
+	// spawn `parallelism` workers to run fixtures against clients, the tests of a
+	// group are run one after another on a shared client
 	var wg sync.WaitGroup
-	var testCh = make(chan *TestCase)
+	var groupCh = make(chan []*TestCase)
 	wg.Add(parallelism)
 	for i := 0; i < parallelism; i++ {
 		go func() {
 			defer wg.Done()
-			for test := range testCh {
-				t.Run(hivesim.TestSpec{
-					Name: test.Name,
-					Description: ("Test Link: " +
-						repoLink(test.FilePath)),
-					Run:       test.run,
-					AlwaysRun: false,
-				})
-				if test.FailedErr != nil {
-					failedTests[test.ClientType+"/"+test.Name] = test.FailedErr
+			for group := range groupCh {
+				var shared *sharedClient
+				if len(group) > 1 {
+					shared = &sharedClient{t: t}
+				}
+				for _, test := range group {
+					test := test
+					run := test.run
+					if shared != nil {
+						run = func(t *hivesim.T) { test.runShared(t, shared) }
+					}
+					t.Run(hivesim.TestSpec{
+						Name: test.Name,
+						Description: ("Test Link: " +
+							repoLink(fileRoot, test.FilePath)),
+						Run:       run,
+						AlwaysRun: false,
+					})
+					if test.FailedErr != nil {
+						failedTests[test.ClientType+"/"+test.Name] = test.FailedErr
+						// don't run further tests on a client in an unknown state
+						if shared != nil {
+							shared.stop()
+						}
+					}
+				}
+				if shared != nil {
+					shared.stop()
 				}
 			}
 		}()
@@ -91,18 +178,48 @@ func fixtureRunner(t *hivesim.T) {
 	_, testPattern := t.Sim.TestPattern()
 	re := regexp.MustCompile(testPattern)
 
-	// deliver and run test cases against each client
-	loadFixtureTests(t, fileRoot, re, func(tc TestCase) {
+	// deliver and run test cases against each client. Tests of a fixture file
+	// which can share a client are grouped, and the groups are delivered when
+	// the next file is loaded.
+	var (
+		groups    = make(map[string][]*TestCase)
+		groupKeys []string
+		groupFile string
+	)
+	flushGroups := func() {
+		for _, key := range groupKeys {
+			groupCh <- groups[key]
+		}
+		groups = make(map[string][]*TestCase)
+		groupKeys = nil
+	}
+	ft.load(t, fileRoot, re, index, forks, func(tc TestCase) {
+		if tc.FilePath != groupFile {
+			flushGroups()
+			groupFile = tc.FilePath
+		}
 		for _, client := range clientTypes {
 			if !client.HasRole("eth1") {
 				continue
 			}
 			tc := tc // shallow copy
 			tc.ClientType = client.Name
-			testCh <- &tc
+			key := ""
+			if reuse {
+				key = tc.sharedKey()
+			}
+			if key == "" {
+				groupCh <- []*TestCase{&tc}
+				continue
+			}
+			if _, ok := groups[key]; !ok {
+				groupKeys = append(groupKeys, key)
+			}
+			groups[key] = append(groups[key], &tc)
 		}
 	})
-	close(testCh)
+	flushGroups()
+	close(groupCh)
 
 	// wait for all workers to finish
 	wg.Wait()
@@ -116,11 +233,29 @@ func fixtureRunner(t *hivesim.T) {
 	}
 }
 
+// writeIndex indexes the fixture files in the directories of all fixture types.
+func writeIndex(file string) error {
+	var roots []string
+	for _, ft := range fixtureTypes {
+		if testPath := os.Getenv(ft.pathEnv); testPath != "" && ft.enabled() {
+			roots = append(roots, fmt.Sprintf("%s/", testPath))
+		}
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("$%s not set", fixtureTypes[0].pathEnv)
+	}
+	index, err := buildIndex(roots...)
+	if err != nil {
+		return err
+	}
+	return index.write(file)
+}
+
 // repoLink coverts a pyspec test path into a github repository link.
-func repoLink(testPath string) string {
+func repoLink(root, testPath string) string {
 	// Example: Converts '/fixtures/cancun/eip4844_blobs/blob_txs/invalid_normal_gas.json'
 	// into 'tests/cancun/eip4844_blobs/test_blob_txs.py', and appends onto main branch repo link.
-	filePath := strings.Replace(testPath, "/fixtures", "tests", -1)
+	filePath := filepath.Join("tests", fixturePath(root, testPath))
 	fileDir := filepath.Dir(filePath)
 	fileBase := filepath.Base(fileDir)
 	fileName := filepath.Join(filepath.Dir(fileDir), "test_"+fileBase+".py")
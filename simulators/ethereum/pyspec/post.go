@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// postDiff lists the differences between the post state of a fixture and the
+// state of the client, expected vs received, for every account and storage slot,
+// and the hash of the latest block.
+type postDiff struct {
+	accounts []string
+	head     string
+}
+
+func (d *postDiff) empty() bool {
+	return len(d.accounts) == 0 && d.head == ""
+}
+
+func (d *postDiff) account(address common.Address, field string, want, got interface{}) {
+	d.accounts = append(d.accounts, fmt.Sprintf("account %v %s: expected %v, received %v", address, field, want, got))
+}
+
+func (d *postDiff) slot(address common.Address, key, want common.Hash, got *common.Hash) {
+	received := "no value"
+	if got != nil {
+		received = got.Hex()
+	}
+	d.accounts = append(d.accounts, fmt.Sprintf("account %v storage %v: expected %v, received %v", address, key, want, received))
+}
+
+// hash records a different hash of the latest block. The differing header fields
+// are listed as the explanation of the difference.
+func (d *postDiff) hash(want, got common.Hash, fields []string) {
+	d.head = fmt.Sprintf("latest block hash: expected %v, received %v", want, got)
+	if len(fields) > 0 {
+		d.head += ", differing fields:\n\t\t" + strings.Join(fields, "\n\t\t")
+	}
+}
+
+// Error returns the report of all differences, the accounts sorted by address.
+func (d *postDiff) Error() string {
+	sort.Strings(d.accounts)
+	var lines []string
+	if d.head != "" {
+		lines = append(lines, d.head)
+	}
+	lines = append(lines, d.accounts...)
+	return fmt.Sprintf("post state doesn't match fixture (%d differences):\n\t%s", len(lines), strings.Join(lines, "\n\t"))
+}
+
+// headerDiffs returns the header fields of the latest block which differ from the
+// payload: receipts root, logs bloom, withdrawals root and blob gas fields.
+func headerDiffs(payload *api.ExecutableData, head *types.Header) []string {
+	var fields []string
+	field := func(name string, want, got interface{}) {
+		fields = append(fields, fmt.Sprintf("%s: expected %v, received %v", name, want, got))
+	}
+	if head.ReceiptHash != payload.ReceiptsRoot {
+		field("receiptsRoot", payload.ReceiptsRoot, head.ReceiptHash)
+	}
+	if !bytes.Equal(head.Bloom.Bytes(), payload.LogsBloom) {
+		field("logsBloom", hexutil.Bytes(payload.LogsBloom), hexutil.Bytes(head.Bloom.Bytes()))
+	}
+	if payload.Withdrawals != nil {
+		want := types.DeriveSha(types.Withdrawals(payload.Withdrawals), trie.NewStackTrie(nil))
+		if head.WithdrawalsHash == nil || *head.WithdrawalsHash != want {
+			field("withdrawalsRoot", want, head.WithdrawalsHash)
+		}
+	}
+	if !equalUint64(payload.BlobGasUsed, head.BlobGasUsed) {
+		field("blobGasUsed", optUint64(payload.BlobGasUsed), optUint64(head.BlobGasUsed))
+	}
+	if !equalUint64(payload.ExcessBlobGas, head.ExcessBlobGas) {
+		field("excessBlobGas", optUint64(payload.ExcessBlobGas), optUint64(head.ExcessBlobGas))
+	}
+	return fields
+}
+
+// codeSummary describes contract code by its size and hash.
+func codeSummary(code []byte) string {
+	if len(code) == 0 {
+		return "no code"
+	}
+	return fmt.Sprintf("%d bytes with hash %v", len(code), crypto.Keccak256Hash(code))
+}
+
+func equalUint64(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func optUint64(v *uint64) string {
+	if v == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d", *v)
+}
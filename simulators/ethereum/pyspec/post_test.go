@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestPostDiff(t *testing.T) {
+	var (
+		a    = common.Address{0xaa}
+		b    = common.Address{0xbb}
+		one  = common.Hash{1}
+		two  = common.Hash{2}
+		diff = new(postDiff)
+	)
+	if !diff.empty() {
+		t.Fatal("new diff not empty")
+	}
+	diff.slot(b, one, two, nil)
+	diff.account(b, "balance", big.NewInt(1), big.NewInt(2))
+	diff.slot(a, two, one, &two)
+	diff.account(a, "nonce", uint64(1), uint64(0))
+	diff.hash(one, two, []string{"receiptsRoot: expected x, received y"})
+	if diff.empty() {
+		t.Fatal("diff empty")
+	}
+
+	want := strings.Join([]string{
+		"post state doesn't match fixture (5 differences):",
+		"\tlatest block hash: expected " + one.Hex() + ", received " + two.Hex() + ", differing fields:",
+		"\t\treceiptsRoot: expected x, received y",
+		"\taccount " + a.Hex() + " nonce: expected 1, received 0",
+		"\taccount " + a.Hex() + " storage " + two.Hex() + ": expected " + one.Hex() + ", received " + two.Hex(),
+		"\taccount " + b.Hex() + " balance: expected 1, received 2",
+		"\taccount " + b.Hex() + " storage " + one.Hex() + ": expected " + two.Hex() + ", received no value",
+	}, "\n")
+	if got := diff.Error(); got != want {
+		t.Fatalf("wrong report:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHeaderDiffs(t *testing.T) {
+	var (
+		blobGas = uint64(131072)
+		payload = &api.ExecutableData{
+			ReceiptsRoot: common.Hash{1},
+			LogsBloom:    make([]byte, types.BloomByteLength),
+			Withdrawals:  []*types.Withdrawal{},
+			BlobGasUsed:  &blobGas,
+		}
+		head = &types.Header{
+			ReceiptHash:     common.Hash{1},
+			WithdrawalsHash: &types.EmptyWithdrawalsHash,
+			BlobGasUsed:     &blobGas,
+		}
+	)
+	if fields := headerDiffs(payload, head); len(fields) != 0 {
+		t.Fatalf("differences in equal header: %v", fields)
+	}
+
+	head.ReceiptHash = common.Hash{2}
+	head.WithdrawalsHash = nil
+	head.BlobGasUsed = nil
+	fields := headerDiffs(payload, head)
+	want := []string{
+		"receiptsRoot: expected " + common.Hash{1}.Hex() + ", received " + common.Hash{2}.Hex(),
+		"withdrawalsRoot: expected " + types.EmptyWithdrawalsHash.Hex() + ", received <nil>",
+		"blobGasUsed: expected 131072, received none",
+	}
+	if strings.Join(fields, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("wrong differences:\n%s\nwant:\n%s", strings.Join(fields, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"math/big"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/hive/hivesim"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
 	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
 	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
 )
@@ -20,9 +22,49 @@ var (
 	SyncTimeout = 10 * time.Second
 )
 
-// loadFixtureTests extracts tests from fixture.json files in a given directory,
-// creates a testcase for each test, and passes the testcase struct to fn.
-func loadFixtureTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(TestCase)) {
+// Fixture formats of execution-spec-tests.
+const (
+	blockchainTestEngine = "blockchain_test_engine"
+	blockchainTest       = "blockchain_test"
+	stateTest            = "state_test"
+	transactionTest      = "transaction_test"
+)
+
+// fixtureFormat returns the format of a test in a fixture file, or the empty
+// string if it is unknown.
+func fixtureFormat(test map[string]json.RawMessage) string {
+	has := func(keys ...string) bool {
+		for _, key := range keys {
+			if _, ok := test[key]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case has("engineNewPayloads"):
+		return blockchainTestEngine
+	case has("blocks"):
+		return blockchainTest
+	case has("env", "transaction", "post"):
+		return stateTest
+	case has("txbytes", "result"):
+		return transactionTest
+	default:
+		return ""
+	}
+}
+
+// walkFixtureFiles calls fn with the tests of the given format in every fixture
+// file below root. Tests of other formats are logged and skipped, and indexed
+// files without tests of the format and selected forks are not loaded.
+func walkFixtureFiles(t *hivesim.T, root, format string, index *fixtureIndex, forks forkSet, fn func(path string, tests map[string]json.RawMessage)) {
+	var skipped int
+	defer func() {
+		if skipped > 0 {
+			t.Logf("skipped %d indexed files without %s tests of the selected forks", skipped, format)
+		}
+	}()
 	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		// check file is actually a fixture
 		if err != nil {
@@ -37,18 +79,66 @@ func loadFixtureTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(Test
 			return nil
 		}
 
-		// extract fixture.json tests (multiple forks) into fixture structs
-		var fixtureTests map[string]*Fixture
+		if info, err := d.Info(); err == nil {
+			if tests, ok := index.lookup(path, info); ok && !forks.anySelected(tests, format) {
+				skipped++
+				return nil
+			}
+		}
+
+		// extract fixture.json tests (multiple forks), keeping those of the format
+		var fixtureTests map[string]json.RawMessage
 		if err := common.LoadJSON(path, &fixtureTests); err != nil {
 			t.Logf("invalid test file: %v, unable to load json", err)
 			return nil
 		}
+		tests := make(map[string]json.RawMessage)
+		for name, raw := range fixtureTests {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				t.Logf("invalid test %s in %s: %v", name, path, err)
+				continue
+			}
+			if f := fixtureFormat(fields); f != format {
+				if f == "" {
+					f = "unknown"
+				}
+				t.Logf("skipping %s in %s: %s fixture, want %s", name, path, f, format)
+				continue
+			}
+			tests[name] = raw
+		}
+		if len(tests) > 0 {
+			fn(path, tests)
+		}
+		return nil
+	})
+}
+
+// fixturePath returns the path of a fixture file relative to the root, without
+// the extension.
+func fixturePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return strings.TrimSuffix(rel, ".json")
+}
 
+// loadFixtureTests extracts tests from fixture.json files in a given directory,
+// creates a testcase for each test, and passes the testcase struct to fn.
+func loadFixtureTests(t *hivesim.T, root string, re *regexp.Regexp, index *fixtureIndex, forks forkSet, fn func(TestCase)) {
+	walkFixtureFiles(t, root, blockchainTestEngine, index, forks, func(path string, tests map[string]json.RawMessage) {
 		// create testcase structure from fixtureTests
-		for name, fixture := range fixtureTests {
+		for name, raw := range tests {
+			var fixture *Fixture
+			if err := json.Unmarshal(raw, &fixture); err != nil {
+				t.Logf("invalid test %s in %s: %v", name, path, err)
+				continue
+			}
 			// skip networks post merge or not supported
 			network := fixture.Fork
-			if _, exist := envForks[network]; !exist {
+			if _, exist := envForks[network]; !exist || !forks.has(network) {
 				continue
 			}
 			// define testcase (tc) struct with initial fields
@@ -64,15 +154,27 @@ func loadFixtureTests(t *hivesim.T, root string, re *regexp.Regexp, fn func(Test
 			// feed tc to single worker within fixtureRunner()
 			fn(tc)
 		}
-		return nil
 	})
 }
 
-// run executes a testcase against the client, called within a test channel from
-// fixtureRunner, all testcase payloads are sent and executed using the EngineAPI. for
-// verification all fixture nonce, balance and storage values are checked against the
-// response received from the lastest block.
+// run executes a testcase against the client using the runner of its fixture
+// format.
 func (tc *TestCase) run(t *hivesim.T) {
+	switch {
+	case tc.State != nil:
+		tc.runState(t)
+	case tc.Transaction != nil:
+		tc.runTransaction(t)
+	default:
+		tc.runBlockchain(t)
+	}
+}
+
+// runBlockchain executes a blockchain_test_engine testcase against the client, all
+// testcase payloads are sent and executed using the EngineAPI. for verification all
+// fixture nonce, balance and storage values are checked against the response
+// received from the lastest block.
+func (tc *TestCase) runBlockchain(t *hivesim.T) {
 	start := time.Now()
 	tc.FailCallback = t
 
@@ -84,12 +186,7 @@ func (tc *TestCase) run(t *hivesim.T) {
 		JWTSecret:  globals.DefaultJwtTokenSecretBytes,
 	}
 	ctx := context.Background()
-	env := hivesim.Params{
-		"HIVE_FORK_DAO_VOTE": "1",
-		"HIVE_CHAIN_ID":      "1",
-		"HIVE_NODETYPE":      "full",
-	}
-	tc.updateEnv(env)
+	env := tc.clientParams()
 	t0 := time.Now()
 	// If test is already failed, don't bother spinning up a client
 	if tc.FailedErr != nil {
@@ -101,47 +198,8 @@ func (tc *TestCase) run(t *hivesim.T) {
 	if err != nil {
 		tc.Fatalf("can't start client with Engine API: %v", err)
 	}
-	// verify genesis hash matches that of the fixture
-	genesisBlock, err := engineClient.BlockByNumber(ctx, big.NewInt(0))
-	if err != nil {
-		tc.Fatalf("unable to get genesis block: %v", err)
-	}
-	if genesisBlock.Hash() != tc.GenesisBlock.Hash {
-		tc.Fatalf("genesis hash mismatch")
-	}
 	t1 := time.Now()
-
-	// send payloads and check response
-	var latestValidPayload *EngineNewPayload
-	for _, engineNewPayload := range tc.EngineNewPayloads {
-		engineNewPayload := engineNewPayload
-		if syncing, err := engineNewPayload.ExecuteValidate(
-			ctx,
-			engineClient,
-		); err != nil {
-			tc.Fatalf("Payload validation error: %v", err)
-		} else if syncing {
-			tc.Fatalf("Payload validation failed (not synced)")
-		}
-		// update latest valid block hash if payload status is VALID
-		if engineNewPayload.Valid() {
-			latestValidPayload = engineNewPayload
-		}
-	}
-	t2 := time.Now()
-
-	// only update head of beacon chain if valid response occurred
-	if latestValidPayload != nil {
-		if syncing, err := latestValidPayload.ForkchoiceValidate(ctx, engineClient, tc.EngineFcuVersion); err != nil {
-			tc.Fatalf("unable to update head of chain: %v", err)
-		} else if syncing {
-			tc.Fatalf("forkchoice update failed (not synced)")
-		}
-	}
-	t3 := time.Now()
-	if err := tc.ValidatePost(ctx, engineClient); err != nil {
-		tc.Fatalf("unable to verify post allocation in test %s: %v", tc.Name, err)
-	}
+	t2, t3 := tc.executeBlockchain(ctx, engineClient)
 
 	if tc.SyncPayload != nil {
 		// First send a new payload to the already running client
@@ -207,6 +265,65 @@ func (tc *TestCase) run(t *hivesim.T) {
 	}
 }
 
+// executeBlockchain checks the genesis of the client, sends the payloads of a
+// blockchain_test_engine testcase, updates the head to the latest valid payload and
+// verifies the post allocation. It returns the times at which the payloads were
+// sent and the head was updated.
+func (tc *TestCase) executeBlockchain(ctx context.Context, engineClient client.EngineClient) (time.Time, time.Time) {
+	// verify genesis hash matches that of the fixture
+	genesisBlock, err := engineClient.BlockByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		tc.Fatalf("unable to get genesis block: %v", err)
+	}
+	if genesisBlock.Hash() != tc.GenesisBlock.Hash {
+		tc.Fatalf("genesis hash mismatch")
+	}
+
+	// send payloads and check response
+	var latestValidPayload *EngineNewPayload
+	for _, engineNewPayload := range tc.EngineNewPayloads {
+		engineNewPayload := engineNewPayload
+		if syncing, err := engineNewPayload.ExecuteValidate(
+			ctx,
+			engineClient,
+		); err != nil {
+			tc.Fatalf("Payload validation error: %v", err)
+		} else if syncing {
+			tc.Fatalf("Payload validation failed (not synced)")
+		}
+		// update latest valid block hash if payload status is VALID
+		if engineNewPayload.Valid() {
+			latestValidPayload = engineNewPayload
+		}
+	}
+	t2 := time.Now()
+
+	// only update head of beacon chain if valid response occurred
+	if latestValidPayload != nil {
+		if syncing, err := latestValidPayload.ForkchoiceValidate(ctx, engineClient, tc.EngineFcuVersion); err != nil {
+			tc.Fatalf("unable to update head of chain: %v", err)
+		} else if syncing {
+			tc.Fatalf("forkchoice update failed (not synced)")
+		}
+	}
+	t3 := time.Now()
+	if err := tc.ValidatePost(ctx, engineClient); err != nil {
+		tc.Fatalf("unable to verify post allocation in test %s: %v", tc.Name, err)
+	}
+	return t2, t3
+}
+
+// clientParams returns the client parameters of a blockchain_test_engine testcase.
+func (tc *TestCase) clientParams() hivesim.Params {
+	env := hivesim.Params{
+		"HIVE_FORK_DAO_VOTE": "1",
+		"HIVE_CHAIN_ID":      "1",
+		"HIVE_NODETYPE":      "full",
+	}
+	tc.updateEnv(env)
+	return env
+}
+
 // updateEnv updates the environment variables against the fork rules
 // defined in envForks, for the network specified in the testcase fixture.
 func (tc *TestCase) updateEnv(env hivesim.Params) {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/hive/hivesim"
+)
+
+func TestFixtureFormat(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{`{"network": "Cancun", "engineNewPayloads": [], "blocks": []}`, blockchainTestEngine},
+		{`{"network": "Cancun", "blocks": []}`, blockchainTest},
+		{`{"env": {}, "transaction": {}, "post": {}}`, stateTest},
+		{`{"env": {}, "post": {}}`, ""},
+		{`{"txbytes": "0x", "result": {}}`, transactionTest},
+		{`{}`, ""},
+	}
+	for _, tt := range tests {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(tt.fixture), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if got := fixtureFormat(fields); got != tt.want {
+			t.Errorf("wrong format %q for %s, want %q", got, tt.fixture, tt.want)
+		}
+	}
+}
+
+func TestWalkFixtureFiles(t *testing.T) {
+	root := t.TempDir() + "/"
+	files := map[string]string{
+		"cancun/a.json": `{
+			"engine": {"network": "Cancun", "engineNewPayloads": []},
+			"state": {"env": {}, "transaction": {}, "post": {"Cancun": []}}
+		}`,
+		"shanghai/b.json": `{
+			"state": {"env": {}, "transaction": {}, "post": {"Shanghai": []}},
+			"tx": {"txbytes": "0x", "result": {"Shanghai": {}}}
+		}`,
+		"example/c.json": `{"state": {"env": {}, "transaction": {}, "post": {"Cancun": []}}}`,
+		"cancun/d.txt":   `{"state": {"env": {}, "transaction": {}, "post": {"Cancun": []}}}`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index, err := buildIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(format string, forks forkSet) []string {
+		var found []string
+		walkFixtureFiles(&hivesim.T{}, root, format, index, forks, func(path string, tests map[string]json.RawMessage) {
+			for name := range tests {
+				found = append(found, fixturePath(root, path)+"/"+name)
+			}
+		})
+		sort.Strings(found)
+		return found
+	}
+
+	// Only tests of the format are passed, and examples and non-JSON files are skipped.
+	if got, want := walk(stateTest, nil), []string{"cancun/a/state", "shanghai/b/state"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong state tests %v, want %v", got, want)
+	}
+	if got, want := walk(transactionTest, nil), []string{"shanghai/b/tx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong transaction tests %v, want %v", got, want)
+	}
+	// Indexed files without tests of the selected forks are not loaded.
+	if got, want := walk(stateTest, forkSet{"Cancun": true}), []string{"cancun/a/state"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong state tests %v with fork selection, want %v", got, want)
+	}
+	if got := walk(transactionTest, forkSet{"Cancun": true}); len(got) != 0 {
+		t.Errorf("wrong transaction tests %v with fork selection, want none", got)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/hive/hivesim"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+)
+
+// sharedKey returns the key of the client a blockchain_test_engine testcase can
+// share with other testcases, or the empty string if it needs its own client.
+// Testcases with a sync payload start a second client, which syncs the chain of
+// the first, and are not run on a shared client.
+func (tc *TestCase) sharedKey() string {
+	if tc.Fixture == nil || tc.SyncPayload != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%x", tc.ClientType, tc.Fork, tc.GenesisBlock.Hash)
+}
+
+// sharedClient is a client which runs blockchain_test_engine testcases with the
+// same genesis and fork one after another. The chain of the client is reset to the
+// genesis block with debug_setHead before every testcase but the first, and the
+// client is restarted if the reset fails or a testcase fails.
+type sharedClient struct {
+	// meta-test which owns the client container
+	t      *hivesim.T
+	client *hivesim.Client
+	// whether the chain of the client has to be reset
+	used bool
+}
+
+// engineClient returns an engine client for a testcase, whose requests are logged
+// to the test. The client is started, or reset to the genesis of the testcase.
+func (s *sharedClient) engineClient(t *hivesim.T, tc *TestCase) (client.EngineClient, error) {
+	if s.client != nil && s.used {
+		if err := s.reset(tc.GenesisBlock.Hash); err != nil {
+			t.Logf("restarting client, reset to genesis failed: %v", err)
+			s.stop()
+		}
+	}
+	if s.client == nil {
+		genesisStart, err := helper.GenesisStartOption(tc.Genesis())
+		if err != nil {
+			return nil, err
+		}
+		t.Log("starting shared client with Engine API.")
+		s.client = s.t.StartClient(tc.ClientType, genesisStart, tc.clientParams())
+		if err := hive_rpc.CheckEthEngineLive(s.client); err != nil {
+			return nil, fmt.Errorf("Engine/Eth ports were never open for client: %v", err)
+		}
+	}
+	s.used = true
+	engineTransport, err := client.TransportFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return hive_rpc.NewHiveRPCEngineClient(s.client, globals.EnginePortHTTP, globals.EthPortHTTP, globals.DefaultJwtTokenSecretBytes, nil, engineTransport, &helper.LoggingRoundTrip{
+		Logger: t,
+		ID:     s.client.Container,
+		Inner:  http.DefaultTransport,
+	}), nil
+}
+
+// reset sets the head of the client to the genesis block.
+func (s *sharedClient) reset(genesis common.Hash) error {
+	ctx, cancel := context.WithTimeout(context.Background(), globals.RPCTimeout)
+	defer cancel()
+	if err := s.client.RPC().CallContext(ctx, nil, "debug_setHead", "0x0"); err != nil {
+		return err
+	}
+	var head struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := s.client.RPC().CallContext(ctx, &head, "eth_getBlockByNumber", "latest", false); err != nil {
+		return err
+	}
+	if head.Hash != genesis {
+		return fmt.Errorf("head %v is not genesis %v", head.Hash, genesis)
+	}
+	return nil
+}
+
+// stop stops the client, if it is running.
+func (s *sharedClient) stop() {
+	if s.client == nil {
+		return
+	}
+	if err := s.t.Sim.StopClient(s.t.SuiteID, s.t.TestID, s.client.Container); err != nil {
+		s.t.Logf("unable to stop client %s: %v", s.client.Container, err)
+	}
+	s.client, s.used = nil, false
+}
+
+// runShared executes a blockchain_test_engine testcase against the shared client.
+func (tc *TestCase) runShared(t *hivesim.T, s *sharedClient) {
+	start := time.Now()
+	tc.FailCallback = t
+	if tc.FailedErr != nil {
+		t.Fatalf("test failed early: %v", tc.FailedErr)
+	}
+	engineClient, err := s.engineClient(t, tc)
+	if err != nil {
+		tc.Fatalf("can't start client with Engine API: %v", err)
+	}
+	defer engineClient.Close()
+	tc.executeBlockchain(context.Background(), engineClient)
+	t.Logf("test time on shared client: %v", time.Since(start))
+}
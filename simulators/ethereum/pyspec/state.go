@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	api "github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/hive/hivesim"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client"
+	"github.com/ethereum/hive/simulators/ethereum/engine/client/hive_rpc"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	typ "github.com/ethereum/hive/simulators/ethereum/engine/types"
+)
+
+var (
+	// Time given to the client to include the transaction in the payload
+	PayloadBuildTime = time.Second
+)
+
+// StateFixture is a state_test fixture, which executes a transaction on top of the
+// pre state. Every post state of the fixture is a separate test case.
+type StateFixture struct {
+	Env  stateEnv                `json:"env"`
+	Pre  core.GenesisAlloc       `json:"pre"`
+	Post map[string][]*StatePost `json:"post"`
+}
+
+type stateEnv struct {
+	Coinbase         common.Address        `json:"currentCoinbase"`
+	GasLimit         math.HexOrDecimal64   `json:"currentGasLimit"`
+	Number           math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp        math.HexOrDecimal64   `json:"currentTimestamp"`
+	Random           *common.Hash          `json:"currentRandom"`
+	BaseFee          *math.HexOrDecimal256 `json:"currentBaseFee"`
+	ExcessBlobGas    *math.HexOrDecimal64  `json:"currentExcessBlobGas"`
+	ParentBeaconRoot *common.Hash          `json:"parentBeaconBlockRoot"`
+}
+
+// StatePost is the expected result of a transaction of a state test.
+type StatePost struct {
+	Hash            common.Hash       `json:"hash"`
+	Logs            common.Hash       `json:"logs"`
+	TxBytes         hexutil.Bytes     `json:"txbytes"`
+	ExpectException *string           `json:"expectException"`
+	State           core.GenesisAlloc `json:"state"`
+}
+
+// StateTestCase is a single post state of a state test.
+type StateTestCase struct {
+	*StateFixture
+	Fork string
+	Post *StatePost
+}
+
+// loadStateTests extracts the state tests from the fixture files in a given
+// directory, and passes a testcase for every post state of a supported fork to fn.
+func loadStateTests(t *hivesim.T, root string, re *regexp.Regexp, index *fixtureIndex, forks forkSet, fn func(TestCase)) {
+	walkFixtureFiles(t, root, stateTest, index, forks, func(path string, tests map[string]json.RawMessage) {
+		for name, raw := range tests {
+			var fixture *StateFixture
+			if err := json.Unmarshal(raw, &fixture); err != nil {
+				t.Logf("invalid test %s in %s: %v", name, path, err)
+				continue
+			}
+			for fork, posts := range fixture.Post {
+				if _, exist := envForks[fork]; !exist || !forks.has(fork) {
+					continue
+				}
+				for i, post := range posts {
+					tc := TestCase{
+						Name:     fmt.Sprintf("%s/%s/%s/%s/%d", stateTest, fixturePath(root, path), name, fork, i),
+						FilePath: path,
+						State:    &StateTestCase{StateFixture: fixture, Fork: fork, Post: post},
+					}
+					if !re.MatchString(tc.Name) {
+						continue
+					}
+					var tx types.Transaction
+					if err := tx.UnmarshalBinary(post.TxBytes); err == nil && tx.Type() == types.BlobTxType {
+						t.Logf("skipping %s: blob transactions can't be sent without their blobs", tc.Name)
+						continue
+					}
+					fn(tc)
+				}
+			}
+		}
+	})
+}
+
+// Genesis returns a genesis block on top of which the client builds a block with
+// the environment of the test. The base fee and excess blob gas of the genesis
+// are chosen such that they result in the values of the environment in the built
+// block.
+func (s *StateTestCase) Genesis() *core.Genesis {
+	genesis := &core.Genesis{
+		Config:   tests.Forks[s.Fork],
+		GasLimit: uint64(s.Env.GasLimit),
+		Alloc:    s.Pre,
+	}
+	if s.Env.BaseFee != nil {
+		genesis.BaseFee = parentBaseFee((*big.Int)(s.Env.BaseFee))
+	}
+	if s.Env.ExcessBlobGas != nil && genesis.Config.CancunTime != nil {
+		excess, used := uint64(*s.Env.ExcessBlobGas), uint64(params.BlobTxTargetBlobGasPerBlock)
+		genesis.ExcessBlobGas, genesis.BlobGasUsed = &excess, &used
+	}
+	return genesis
+}
+
+// parentBaseFee returns the base fee of an empty parent block, such that the base
+// fee of the next block is the given value.
+func parentBaseFee(baseFee *big.Int) *big.Int {
+	// An empty block reduces the base fee by 1/8, rounded down. Start the search
+	// just below 8/7 of the value.
+	parent := new(big.Int).Mul(baseFee, big.NewInt(8))
+	parent.Div(parent, big.NewInt(7))
+	parent.Sub(parent, big.NewInt(2))
+	if parent.Cmp(baseFee) < 0 {
+		parent.Set(baseFee)
+	}
+	for {
+		next := new(big.Int).Sub(parent, new(big.Int).Div(parent, big.NewInt(8)))
+		if next.Cmp(baseFee) >= 0 {
+			return parent
+		}
+		parent.Add(parent, big.NewInt(1))
+	}
+}
+
+// engineVersion returns the Engine API version used to build the block.
+func (s *StateTestCase) engineVersion() int {
+	config := tests.Forks[s.Fork]
+	switch {
+	case config.IsCancun(common.Big1, uint64(s.Env.Timestamp)):
+		return 3
+	case config.IsShanghai(common.Big1, uint64(s.Env.Timestamp)):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// runState executes a state test against the client. The transaction of the test
+// is sent to the client, which builds a single block with the environment of the
+// test using the Engine API. The block must include the transaction unless an
+// exception is expected, and result in the expected state root, logs and post
+// state.
+//
+// The gas limit of the block is chosen by the client, so tests which depend on
+// the gas limit of the environment may fail.
+func (tc *TestCase) runState(t *hivesim.T) {
+	tc.FailCallback = t
+	s := tc.State
+	if s.Env.Number != 1 {
+		tc.Fatalf("unsupported environment, block number %d instead of 1", s.Env.Number)
+	}
+	if s.Env.Timestamp == 0 {
+		tc.Fatalf("unsupported environment, timestamp 0 of block is not after genesis")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(s.Post.TxBytes); err != nil {
+		if s.Post.ExpectException != nil {
+			t.Logf("transaction can't be decoded, expected exception: %s", *s.Post.ExpectException)
+			return
+		}
+		tc.Fatalf("invalid transaction in fixture: %v", err)
+	}
+
+	engineStarter := hive_rpc.HiveRPCEngineStarter{
+		ClientType: tc.ClientType,
+		EnginePort: globals.EnginePortHTTP,
+		EthPort:    globals.EthPortHTTP,
+		JWTSecret:  globals.DefaultJwtTokenSecretBytes,
+	}
+	ctx := context.Background()
+	env := hivesim.Params{
+		"HIVE_CHAIN_ID": "1",
+		"HIVE_NODETYPE": "full",
+	}
+	for k, v := range envForks[s.Fork] {
+		env[k] = fmt.Sprintf("%d", v)
+	}
+	genesis := s.Genesis()
+	t.Log("starting client with Engine API.")
+	engineClient, err := engineStarter.StartClient(t, ctx, genesis, env, nil)
+	if err != nil {
+		tc.Fatalf("can't start client with Engine API: %v", err)
+	}
+	genesisBlock, err := engineClient.BlockByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		tc.Fatalf("unable to get genesis block: %v", err)
+	}
+
+	// send the transaction, which may be rejected if it is invalid
+	if err := engineClient.SendTransaction(ctx, tx); err != nil {
+		if s.Post.ExpectException == nil {
+			tc.Fatalf("transaction rejected: %v", err)
+		}
+		t.Logf("transaction rejected: %v, expected exception: %s", err, *s.Post.ExpectException)
+	}
+
+	payload, err := tc.buildBlock(ctx, engineClient, genesisBlock.Hash())
+	if err != nil {
+		tc.Fatalf("unable to build block: %v", err)
+	}
+	included := false
+	for _, data := range payload.Transactions {
+		if bytes.Equal(data, s.Post.TxBytes) {
+			included = true
+		}
+	}
+	switch {
+	case included && s.Post.ExpectException != nil:
+		tc.Fatalf("transaction included in block, expected exception: %s", *s.Post.ExpectException)
+	case !included && s.Post.ExpectException == nil:
+		tc.Fatalf("transaction not included in block")
+	}
+
+	// verify the result of the transaction
+	if payload.StateRoot != s.Post.Hash {
+		tc.Fatalf(`state root doesn't match expected from fixture:
+			received from block: %v
+			expected in fixture: %v`, payload.StateRoot, s.Post.Hash)
+	}
+	if included {
+		receipt, err := engineClient.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			tc.Fatalf("unable to get transaction receipt: %v", err)
+		}
+		if logs := rlpHash(receipt.Logs); logs != s.Post.Logs {
+			tc.Fatalf(`logs hash doesn't match expected from fixture:
+			received from block: %v
+			expected in fixture: %v`, logs, s.Post.Logs)
+		}
+	}
+	if len(s.Post.State) > 0 {
+		post := &Fixture{PostAlloc: s.Post.State}
+		if err := post.ValidatePost(ctx, engineClient); err != nil {
+			tc.Fatalf("unable to verify post state in test %s: %v", tc.Name, err)
+		}
+	}
+}
+
+// buildBlock requests a block with the environment of the state test from the
+// client, and makes it the head of the chain.
+func (tc *TestCase) buildBlock(ctx context.Context, engineClient client.EngineClient, parent common.Hash) (*typ.ExecutableData, error) {
+	s := tc.State
+	version := s.engineVersion()
+	attributes := &typ.PayloadAttributes{
+		Timestamp:             uint64(s.Env.Timestamp),
+		SuggestedFeeRecipient: s.Env.Coinbase,
+	}
+	if s.Env.Random != nil {
+		attributes.Random = *s.Env.Random
+	}
+	if version >= 2 {
+		attributes.Withdrawals = []*types.Withdrawal{}
+	}
+	if version >= 3 {
+		attributes.BeaconRoot = new(common.Hash)
+		if s.Env.ParentBeaconRoot != nil {
+			attributes.BeaconRoot = s.Env.ParentBeaconRoot
+		}
+	}
+	fcState := &api.ForkchoiceStateV1{HeadBlockHash: parent, SafeBlockHash: parent, FinalizedBlockHash: parent}
+	response, err := engineClient.ForkchoiceUpdated(ctx, version, fcState, attributes)
+	if err != nil {
+		return nil, err
+	}
+	if response.PayloadID == nil {
+		return nil, fmt.Errorf("no payload id, status %s", response.PayloadStatus.Status)
+	}
+	time.Sleep(PayloadBuildTime)
+	payload, _, _, _, err := engineClient.GetPayload(ctx, version, response.PayloadID)
+	if err != nil {
+		return nil, err
+	}
+	if version >= 3 {
+		hashes := make([]common.Hash, 0)
+		for _, data := range payload.Transactions {
+			var tx types.Transaction
+			if err := tx.UnmarshalBinary(data); err == nil {
+				hashes = append(hashes, tx.BlobHashes()...)
+			}
+		}
+		payload.VersionedHashes = &hashes
+		payload.ParentBeaconBlockRoot = attributes.BeaconRoot
+	}
+	status, err := engineClient.NewPayload(ctx, version, &payload)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != "VALID" {
+		return nil, fmt.Errorf("payload status %s", status.Status)
+	}
+	head := &api.ForkchoiceStateV1{HeadBlockHash: payload.BlockHash}
+	if _, err := engineClient.ForkchoiceUpdated(ctx, version, head, nil); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+func rlpHash(x interface{}) common.Hash {
+	data, _ := rlp.EncodeToBytes(x)
+	return crypto.Keccak256Hash(data)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParentBaseFee(t *testing.T) {
+	// An empty block reduces the base fee by 1/8, rounded down.
+	next := func(parent *big.Int) *big.Int {
+		return new(big.Int).Sub(parent, new(big.Int).Div(parent, big.NewInt(8)))
+	}
+	for _, fee := range []int64{1, 7, 8, 9, 10, 100, 875, 1000, 1_000_000_007} {
+		baseFee := big.NewInt(fee)
+		parent := parentBaseFee(baseFee)
+		if next(parent).Cmp(baseFee) < 0 {
+			t.Errorf("base fee %d: parent %v results in %v", fee, parent, next(parent))
+		}
+		// The parent must be the smallest one, so the next base fee is exact
+		// whenever it can be.
+		smaller := new(big.Int).Sub(parent, big.NewInt(1))
+		if next(smaller).Cmp(baseFee) >= 0 {
+			t.Errorf("base fee %d: parent %v is not the smallest, %v also works", fee, parent, smaller)
+		}
+	}
+	if got := parentBaseFee(big.NewInt(875)); got.Int64() != 999 {
+		t.Errorf("wrong parent base fee %v for 875, want 999", got)
+	}
+}
+
+func TestStateTestGenesis(t *testing.T) {
+	var (
+		baseFee = math.HexOrDecimal256(*big.NewInt(1000))
+		excess  = math.HexOrDecimal64(3 * 131072)
+	)
+	s := &StateTestCase{
+		StateFixture: &StateFixture{Env: stateEnv{
+			GasLimit:      30_000_000,
+			Timestamp:     1000,
+			BaseFee:       &baseFee,
+			ExcessBlobGas: &excess,
+		}},
+		Fork: "Cancun",
+	}
+	genesis := s.Genesis()
+	if genesis.GasLimit != 30_000_000 {
+		t.Fatalf("wrong gas limit %d", genesis.GasLimit)
+	}
+
+	// The block built on top of the genesis must have the environment values.
+	header := &types.Header{
+		Number:        big.NewInt(0),
+		GasLimit:      genesis.GasLimit,
+		BaseFee:       genesis.BaseFee,
+		ExcessBlobGas: genesis.ExcessBlobGas,
+		BlobGasUsed:   genesis.BlobGasUsed,
+	}
+	if got := eip1559.CalcBaseFee(genesis.Config, header); got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong base fee %v of the built block, want 1000", got)
+	}
+	if genesis.ExcessBlobGas == nil || genesis.BlobGasUsed == nil {
+		t.Fatal("blob gas fields not set for Cancun")
+	}
+	if got := eip4844.CalcExcessBlobGas(*genesis.ExcessBlobGas, *genesis.BlobGasUsed); got != uint64(excess) {
+		t.Errorf("wrong excess blob gas %d of the built block, want %d", got, uint64(excess))
+	}
+
+	// Blob gas fields are only set for Cancun.
+	s.Fork = "Shanghai"
+	if genesis := s.Genesis(); genesis.ExcessBlobGas != nil {
+		t.Error("excess blob gas set for Shanghai")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/hive/hivesim"
+	"github.com/ethereum/hive/simulators/ethereum/engine/globals"
+	"github.com/ethereum/hive/simulators/ethereum/engine/helper"
+)
+
+// TransactionFixture is a transaction_test fixture, which checks the validity of an
+// encoded transaction for every fork.
+type TransactionFixture struct {
+	TxBytes hexutil.Bytes                 `json:"txbytes"`
+	Result  map[string]*TransactionResult `json:"result"`
+}
+
+// TransactionResult is the expected result of decoding and validating the
+// transaction in a fork.
+type TransactionResult struct {
+	Hash      *common.Hash    `json:"hash"`
+	Sender    *common.Address `json:"sender"`
+	Exception *string         `json:"exception"`
+}
+
+// TransactionTestCase is the transaction of a transaction test in a single fork.
+type TransactionTestCase struct {
+	*TransactionFixture
+	Fork   string
+	Result *TransactionResult
+}
+
+// loadTransactionTests extracts the transaction tests from the fixture files in a
+// given directory, and passes a testcase for every supported fork to fn.
+func loadTransactionTests(t *hivesim.T, root string, re *regexp.Regexp, index *fixtureIndex, forks forkSet, fn func(TestCase)) {
+	walkFixtureFiles(t, root, transactionTest, index, forks, func(path string, tests map[string]json.RawMessage) {
+		for name, raw := range tests {
+			var fixture *TransactionFixture
+			if err := json.Unmarshal(raw, &fixture); err != nil {
+				t.Logf("invalid test %s in %s: %v", name, path, err)
+				continue
+			}
+			for fork, result := range fixture.Result {
+				if _, exist := envForks[fork]; !exist || !forks.has(fork) {
+					continue
+				}
+				tc := TestCase{
+					Name:        fmt.Sprintf("%s/%s/%s/%s", transactionTest, fixturePath(root, path), name, fork),
+					FilePath:    path,
+					Transaction: &TransactionTestCase{TransactionFixture: fixture, Fork: fork, Result: result},
+				}
+				if !re.MatchString(tc.Name) {
+					continue
+				}
+				fn(tc)
+			}
+		}
+	})
+}
+
+// Genesis returns a genesis in which the sender of a valid transaction can pay for
+// it, so the transaction is only rejected if it is invalid.
+func (x *TransactionTestCase) Genesis() *core.Genesis {
+	genesis := &core.Genesis{
+		Config:   tests.Forks[x.Fork],
+		GasLimit: 0x016345785d8a0000,
+		Alloc:    core.GenesisAlloc{},
+	}
+	if x.Result.Sender != nil {
+		genesis.Alloc[*x.Result.Sender] = core.GenesisAccount{Balance: math.MaxBig256}
+	}
+	return genesis
+}
+
+// runTransaction sends the transaction of a transaction test to the client using
+// eth_sendRawTransaction. Valid transactions must be accepted with the expected
+// hash, invalid transactions must be rejected.
+//
+// Transactions are also subject to the transaction pool policies of the client, so
+// valid transactions with a very low gas price may be rejected.
+func (tc *TestCase) runTransaction(t *hivesim.T) {
+	tc.FailCallback = t
+	x := tc.Transaction
+	env := hivesim.Params{
+		"HIVE_CHAIN_ID": "1",
+		"HIVE_NODETYPE": "full",
+	}
+	for k, v := range envForks[x.Fork] {
+		env[k] = fmt.Sprintf("%d", v)
+	}
+	genesisStart, err := helper.GenesisStartOption(x.Genesis())
+	if err != nil {
+		tc.Fatalf("invalid genesis: %v", err)
+	}
+	t.Log("starting client.")
+	c := t.StartClient(tc.ClientType, genesisStart, env)
+
+	ctx, cancel := context.WithTimeout(context.Background(), globals.RPCTimeout)
+	defer cancel()
+	var hash common.Hash
+	err = c.RPC().CallContext(ctx, &hash, "eth_sendRawTransaction", x.TxBytes)
+	switch {
+	case err != nil && x.Result.Exception == nil:
+		tc.Fatalf("valid transaction rejected: %v", err)
+	case err == nil && x.Result.Exception != nil:
+		tc.Fatalf("invalid transaction accepted, expected exception: %s", *x.Result.Exception)
+	case err != nil:
+		t.Logf("transaction rejected: %v, expected exception: %s", err, *x.Result.Exception)
+	case x.Result.Hash != nil && hash != *x.Result.Hash:
+		tc.Fatalf(`transaction hash doesn't match expected from fixture:
+			received from client: %v
+			expected in fixture:  %v`, hash, *x.Result.Hash)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -29,8 +30,11 @@ type TestCase struct {
 	FilePath   string
 	ClientType string
 	FailedErr  error
-	// test fixture data
+	// test fixture data, the fixture of a blockchain_test_engine test or one of the
+	// state_test or transaction_test cases
 	*Fixture
+	State        *StateTestCase
+	Transaction  *TransactionTestCase
 	FailCallback Fail
 }
 
@@ -66,27 +70,47 @@ func (f *Fixture) Genesis() *core.Genesis {
 	}
 }
 
+// ValidatePost checks the accounts of the post allocation, and the hash of the
+// latest valid payload, against the latest block of the client. All differences
+// are collected in a post diff, which is returned as the error.
 func (f *Fixture) ValidatePost(ctx context.Context, engineClient client.EngineClient) error {
-	// check nonce, balance & storage of accounts in final block against fixture values
+	diff := new(postDiff)
+	if err := f.validateAccounts(ctx, engineClient, diff); err != nil {
+		return err
+	}
+	if err := f.validateHead(ctx, engineClient, diff); err != nil {
+		return err
+	}
+	if diff.empty() {
+		return nil
+	}
+	return diff
+}
+
+// validateAccounts checks the nonce, balance, code & storage of the accounts in the
+// post allocation against the latest block.
+func (f *Fixture) validateAccounts(ctx context.Context, engineClient client.EngineClient, diff *postDiff) error {
 	for address, account := range f.PostAlloc {
-		// get nonce & balance from last block (end of test execution)
+		// get nonce, balance & code from last block (end of test execution)
 		gotNonce, errN := engineClient.NonceAt(ctx, address, nil)
 		gotBalance, errB := engineClient.BalanceAt(ctx, address, nil)
+		gotCode, errC := engineClient.CodeAt(ctx, address, nil)
 		if errN != nil {
 			return fmt.Errorf("unable to call nonce from account: %v: %v", address, errN)
 		} else if errB != nil {
 			return fmt.Errorf("unable to call balance from account: %v: %v", address, errB)
+		} else if errC != nil {
+			return fmt.Errorf("unable to call code from account: %v: %v", address, errC)
 		}
-		// check final nonce & balance matches expected in fixture
+		// check final nonce, balance & code matches expected in fixture
 		if account.Nonce != gotNonce {
-			return fmt.Errorf(`nonce received from account %v doesn't match expected from fixture:
-			received from block: %v
-			expected in fixture: %v`, address, gotNonce, account.Nonce)
+			diff.account(address, "nonce", account.Nonce, gotNonce)
 		}
 		if account.Balance.Cmp(gotBalance) != 0 {
-			return fmt.Errorf(`balance received from account %v doesn't match expected from fixture:
-			received from block: %v
-			expected in fixture: %v`, address, gotBalance, account.Balance)
+			diff.account(address, "balance", account.Balance, gotBalance)
+		}
+		if !bytes.Equal(account.Code, gotCode) {
+			diff.account(address, "code", codeSummary(account.Code), codeSummary(gotCode))
 		}
 		// check final storage
 		if len(account.Storage) > 0 {
@@ -102,10 +126,8 @@ func (f *Fixture) ValidatePost(ctx context.Context, engineClient client.EngineCl
 			}
 			// check values in storage match with fixture
 			for _, key := range keys {
-				if account.Storage[key] != *gotStorage[key] {
-					return fmt.Errorf(`storage received from account %v doesn't match expected from fixture:
-					received from block:  %v
-					expected in fixture:  %v`, address, gotStorage[key], account.Storage[key])
+				if got := gotStorage[key]; got == nil || account.Storage[key] != *got {
+					diff.slot(address, key, account.Storage[key], got)
 				}
 			}
 		}
@@ -113,6 +135,29 @@ func (f *Fixture) ValidatePost(ctx context.Context, engineClient client.EngineCl
 	return nil
 }
 
+// validateHead checks the hash of the latest block against the latest valid
+// payload of the fixture. If the hash differs, the differing receipts root, logs
+// bloom, withdrawals root and blob gas fields are reported as the explanation.
+func (f *Fixture) validateHead(ctx context.Context, engineClient client.EngineClient, diff *postDiff) error {
+	var latest *api.ExecutableData
+	for _, p := range f.EngineNewPayloads {
+		if p.Valid() {
+			latest = p.ExecutionPayload
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	head, err := engineClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to get latest block: %v", err)
+	}
+	if head.Hash() != latest.BlockHash {
+		diff.hash(latest.BlockHash, head.Hash(), headerDiffs(latest, head))
+	}
+	return nil
+}
+
 //go:generate go run github.com/fjl/gencodec -type genesisBlock -field-override genesisBlockUnmarshaling -out gen_gb.go
 type genesisBlock struct {
 	Coinbase      common.Address   `json:"coinbase"`
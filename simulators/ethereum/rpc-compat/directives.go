@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Comparison directives are header lines of a test which relax the comparison
+// of the responses at a path, e.g.
+//
+//	// tolerance: result.baseFeePerGas.* 10%
+//	// unordered: result
+//	// match: result ^Geth/v1\.
+//	// exists: result.hash
+//
+// Paths use the syntax of the normalization rules, and are resolved in the
+// expected data. Where the response satisfies the directive, the value of the
+// response is replaced by the expected value before the comparison.
+const (
+	// The numeric value, a hex quantity or JSON number, may differ from the
+	// expected value by an absolute amount, or a percentage of the expected value.
+	directiveTolerance = "tolerance"
+	// The array must contain the expected elements in any order.
+	directiveUnordered = "unordered"
+	// The value must match the regular expression. Strings are matched without
+	// their quotes, other values in their JSON encoding.
+	directiveMatch = "match"
+	// The value must exist, with any value.
+	directiveExists = "exists"
+)
+
+// directive relaxes the comparison of the values at a path.
+type directive struct {
+	kind string
+	path string
+	// the argument of tolerance and match directives
+	arg string
+
+	re        *regexp.Regexp
+	tolerance *big.Float
+	percent   bool
+}
+
+// parseDirective parses a header line of a test. It returns nil if the line is not
+// a directive.
+func parseDirective(line string) (*directive, error) {
+	kind, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil, nil
+	}
+	switch kind {
+	case directiveTolerance, directiveUnordered, directiveMatch, directiveExists:
+	default:
+		return nil, nil
+	}
+	d := &directive{kind: kind}
+	d.path, d.arg, _ = strings.Cut(strings.TrimSpace(rest), " ")
+	d.arg = strings.TrimSpace(d.arg)
+	if d.path == "" {
+		return nil, fmt.Errorf("%s directive has no path", kind)
+	}
+	switch kind {
+	case directiveTolerance:
+		amount := strings.TrimSuffix(d.arg, "%")
+		d.percent = amount != d.arg
+		tolerance, ok := parseNumber(amount)
+		if !ok || tolerance.Sign() < 0 {
+			return nil, fmt.Errorf("invalid tolerance %q", d.arg)
+		}
+		d.tolerance = tolerance
+	case directiveMatch:
+		if d.arg == "" {
+			return nil, fmt.Errorf("match directive for %s has no pattern", d.path)
+		}
+		re, err := regexp.Compile(d.arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %v", d.arg, err)
+		}
+		d.re = re
+	default:
+		if d.arg != "" {
+			return nil, fmt.Errorf("%s directive takes no argument", kind)
+		}
+	}
+	return d, nil
+}
+
+func (d *directive) String() string {
+	if d.arg == "" {
+		return d.kind + ": " + d.path
+	}
+	return d.kind + ": " + d.path + " " + d.arg
+}
+
+// apply relaxes the comparison of the response with the expected data. It returns
+// the notes about the values to which the directive applied.
+func (d *directive) apply(resp, expected string) (string, []string) {
+	var notes []string
+	for _, p := range expandPath(expected, d.path) {
+		var (
+			want = gjson.Get(expected, p)
+			got  = gjson.Get(resp, p)
+			err  error
+		)
+		if !got.Exists() {
+			notes = append(notes, fmt.Sprintf("directive %q: %s missing in response", d, p))
+			continue
+		}
+		switch d.kind {
+		case directiveTolerance:
+			err = d.checkTolerance(got, want)
+		case directiveUnordered:
+			var reordered string
+			reordered, err = reorderArray(got, want)
+			if reordered != "" {
+				resp, _ = sjson.SetRaw(resp, p, reordered)
+			}
+		case directiveMatch:
+			value := got.Raw
+			if got.Type == gjson.String {
+				value = got.String()
+			}
+			if !d.re.MatchString(value) {
+				err = fmt.Errorf("value %s doesn't match", got.Raw)
+			}
+		}
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("directive %q: %s not accepted, %v", d, p, err))
+			continue
+		}
+		if got.Raw != want.Raw {
+			notes = append(notes, fmt.Sprintf("directive %q: accepted %s at %s", d, got.Raw, p))
+		}
+		resp, _ = sjson.SetRaw(resp, p, want.Raw)
+	}
+	return resp, notes
+}
+
+func (d *directive) checkTolerance(got, want gjson.Result) error {
+	gotNum, ok := parseNumber(numberText(got))
+	if !ok {
+		return fmt.Errorf("value %s is not a number", got.Raw)
+	}
+	wantNum, ok := parseNumber(numberText(want))
+	if !ok {
+		return fmt.Errorf("expected value %s is not a number", want.Raw)
+	}
+	allowed := new(big.Float).Set(d.tolerance)
+	if d.percent {
+		allowed.Mul(allowed, new(big.Float).Abs(wantNum))
+		allowed.Quo(allowed, big.NewFloat(100))
+	}
+	delta := new(big.Float).Sub(gotNum, wantNum)
+	if delta.Abs(delta).Cmp(allowed) > 0 {
+		return fmt.Errorf("value %s differs by more than %s", got.Raw, d.arg)
+	}
+	return nil
+}
+
+func numberText(v gjson.Result) string {
+	if v.Type == gjson.String {
+		return v.String()
+	}
+	return v.Raw
+}
+
+// parseNumber parses a hex quantity or a decimal number.
+func parseNumber(s string) (*big.Float, bool) {
+	if hex := strings.TrimPrefix(s, "0x"); hex != s {
+		n, ok := new(big.Int).SetString(hex, 16)
+		if !ok || hex == "" {
+			return nil, false
+		}
+		return new(big.Float).SetInt(n), true
+	}
+	n, ok := new(big.Float).SetString(s)
+	return n, ok
+}
+
+// reorderArray checks that the arrays contain the same elements in any order. If
+// they don't, it returns the response with the matching elements in the expected
+// order followed by the others, so the difference shows only the mismatches.
+func reorderArray(got, want gjson.Result) (string, error) {
+	if !got.IsArray() || !want.IsArray() {
+		return "", fmt.Errorf("value %s is not an array", got.Raw)
+	}
+	var (
+		gotElems = got.Array()
+		used     = make([]bool, len(gotElems))
+		ordered  []string
+		missing  int
+	)
+	for _, w := range want.Array() {
+		found := false
+		for i, g := range gotElems {
+			if !used[i] && equalJSON(g.Raw, w.Raw) {
+				used[i], found = true, true
+				ordered = append(ordered, g.Raw)
+				break
+			}
+		}
+		if !found {
+			missing++
+		}
+	}
+	for i, g := range gotElems {
+		if !used[i] {
+			ordered = append(ordered, g.Raw)
+		}
+	}
+	reordered := "[" + strings.Join(ordered, ",") + "]"
+	if missing > 0 {
+		return reordered, fmt.Errorf("%d expected elements missing", missing)
+	}
+	if extra := len(gotElems) - len(want.Array()); extra > 0 {
+		return reordered, fmt.Errorf("%d unexpected elements", extra)
+	}
+	return "", nil
+}
+
+func equalJSON(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// applyDirectives applies the directives of a test to a response.
+func applyDirectives(directives []*directive, resp, expected string) (string, []string) {
+	var notes []string
+	for _, d := range directives {
+		var n []string
+		resp, n = d.apply(resp, expected)
+		notes = append(notes, n...)
+	}
+	return resp, notes
+}
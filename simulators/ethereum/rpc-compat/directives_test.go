@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		err  bool
+	}{
+		{line: "this is a comment"},
+		{line: "speconly: client response is only checked for schema validity."},
+		{line: "tolerance: result.baseFeePerGas.* 10%", want: "tolerance: result.baseFeePerGas.* 10%"},
+		{line: "tolerance:  result   0x10 ", want: "tolerance: result 0x10"},
+		{line: "unordered: result", want: "unordered: result"},
+		{line: "match: result ^Geth/v1\\. .*$", want: "match: result ^Geth/v1\\. .*$"},
+		{line: "exists: result.hash", want: "exists: result.hash"},
+		{line: "tolerance: result", err: true},
+		{line: "tolerance: result -1", err: true},
+		{line: "match: result", err: true},
+		{line: "match: result (", err: true},
+		{line: "exists: result.hash 1", err: true},
+		{line: "unordered:", err: true},
+	}
+	for _, test := range tests {
+		d, err := parseDirective(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error", test.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.line, err)
+			continue
+		}
+		if test.want == "" {
+			if d != nil {
+				t.Errorf("%q: parsed as directive %q", test.line, d)
+			}
+			continue
+		}
+		if d == nil || d.String() != test.want {
+			t.Errorf("%q: parsed as %v, want %q", test.line, d, test.want)
+		}
+	}
+}
+
+func TestApplyDirectives(t *testing.T) {
+	tests := []struct {
+		directive string
+		resp      string
+		expected  string
+		accepted  bool
+	}{
+		// tolerance
+		{"tolerance: result 0x10", `{"result":"0x110"}`, `{"result":"0x100"}`, true},
+		{"tolerance: result 0x10", `{"result":"0x111"}`, `{"result":"0x100"}`, false},
+		{"tolerance: result.* 10%", `{"result":["0x64","0x6e"]}`, `{"result":["0x64","0x64"]}`, true},
+		{"tolerance: result.* 10%", `{"result":["0x64","0x6f"]}`, `{"result":["0x64","0x64"]}`, false},
+		{"tolerance: result.gasUsedRatio.* 0.05", `{"result":{"gasUsedRatio":[0.52]}}`, `{"result":{"gasUsedRatio":[0.5]}}`, true},
+		{"tolerance: result 1", `{"result":"abc"}`, `{"result":"0x1"}`, false},
+		// unordered
+		{"unordered: result", `{"result":[{"a":2},{"a":1}]}`, `{"result":[{"a":1},{"a":2}]}`, true},
+		{"unordered: result", `{"result":[{"a":2},{"a":3}]}`, `{"result":[{"a":1},{"a":2}]}`, false},
+		{"unordered: result", `{"result":[1,2,2]}`, `{"result":[2,1]}`, false},
+		// match
+		{"match: result ^Geth/", `{"result":"Geth/v1.13.5"}`, `{"result":"Geth/v1.13.4"}`, true},
+		{"match: result ^Geth/", `{"result":"Nethermind/v1.25"}`, `{"result":"Geth/v1.13.4"}`, false},
+		{"match: result ^[0-9]+$", `{"result":123}`, `{"result":456}`, true},
+		// exists
+		{"exists: result.hash", `{"result":{"hash":"0x02"}}`, `{"result":{"hash":"0x01"}}`, true},
+		{"exists: result.hash", `{"result":{}}`, `{"result":{"hash":"0x01"}}`, false},
+	}
+	for _, test := range tests {
+		d, err := parseDirective(test.directive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, notes := applyDirectives([]*directive{d}, test.resp, test.expected)
+		diff, _, err := compareJSON(resp, test.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accepted := !diff.Modified(); accepted != test.accepted {
+			t.Errorf("%q with response %s: accepted %t, want %t (notes %q)", test.directive, test.resp, accepted, test.accepted, notes)
+		}
+		if len(notes) == 0 || !strings.Contains(notes[0], test.directive) {
+			t.Errorf("%q with response %s: missing note about directive, got %q", test.directive, test.resp, notes)
+		}
+	}
+}
+
+func TestUnorderedShowsMismatches(t *testing.T) {
+	d, err := parseDirective("unordered: result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, _ := d.apply(`{"result":[3,2,1]}`, `{"result":[1,2,4]}`)
+	if want := `{"result":[1,2,3]}`; resp != want {
+		t.Errorf("wrong reordered response %s, want %s", resp, want)
+	}
+}
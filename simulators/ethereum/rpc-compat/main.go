@@ -1,25 +1,20 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/ethereum/hive/hivesim"
 	"github.com/tidwall/gjson"
-	"github.com/tidwall/sjson"
 	diff "github.com/yudai/gojsondiff"
 	"github.com/yudai/gojsondiff/formatter"
 )
 
-const EMPTY_ROOT = "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"
-
 var (
 	clientEnv = hivesim.Params{
 		"HIVE_NODETYPE":       "full",
@@ -55,17 +50,35 @@ The RPC-compatibility test suite runs a set of RPC related tests against a
 running node. It tests client implementations of the JSON-RPC API for
 conformance with the execution API specification.`[1:],
 	}
-	suite.Add(&hivesim.ClientTestSpec{
-		Role:        "eth1",
-		Name:        "client launch",
-		Description: `This test launches the client and collects its logs.`,
-		Parameters:  clientEnv,
-		Files:       files,
-		Run: func(t *hivesim.T, c *hivesim.Client) {
-			runAllTests(t, c, c.Type)
-		},
-		AlwaysRun: true,
-	})
+	if requestDir := os.Getenv(envRecord); requestDir != "" {
+		outDir := os.Getenv(envRecordDir)
+		if outDir == "" {
+			outDir = requestDir
+		}
+		suite.Add(&hivesim.ClientTestSpec{
+			Role:        "eth1",
+			Name:        "client launch (record)",
+			Description: `This test launches the reference client and records its responses to the requests in ` + requestDir + `.`,
+			Parameters:  clientEnv,
+			Files:       files,
+			Run: func(t *hivesim.T, c *hivesim.Client) {
+				recordAllTests(t, c, requestDir, outDir)
+			},
+			AlwaysRun: true,
+		})
+	} else {
+		suite.Add(&hivesim.ClientTestSpec{
+			Role:        "eth1",
+			Name:        "client launch",
+			Description: `This test launches the client and collects its logs.`,
+			Parameters:  clientEnv,
+			Files:       files,
+			Run: func(t *hivesim.T, c *hivesim.Client) {
+				runAllTests(t, c, c.Type)
+			},
+			AlwaysRun: true,
+		})
+	}
 	sim := hivesim.New()
 	hivesim.MustRunSuite(sim, suite)
 }
@@ -74,13 +87,28 @@ func runAllTests(t *hivesim.T, c *hivesim.Client, clientName string) {
 	_, testPattern := t.Sim.TestPattern()
 	re := regexp.MustCompile(testPattern)
 	tests := loadTests(t, "tests", re)
+	rules, err := loadRules(rulesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules = rulesForClient(rules, clientName)
+	for _, r := range rules {
+		t.Logf("normalization rule for %s: %s", clientName, r.Description)
+	}
+	transport, err := transportFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport != transportHTTP {
+		t.Logf("running all tests over %s", transport)
+	}
 	for _, test := range tests {
 		test := test
 		t.Run(hivesim.TestSpec{
 			Name:        test.name,
 			Description: test.comment,
 			Run: func(t *hivesim.T) {
-				if err := runTest(t, c, &test); err != nil {
+				if err := runTest(t, c, &test, rules, transport); err != nil {
 					t.Fatal(err)
 				}
 			},
@@ -88,72 +116,70 @@ func runAllTests(t *hivesim.T, c *hivesim.Client, clientName string) {
 	}
 }
 
-func runTest(t *hivesim.T, c *hivesim.Client, test *rpcTest) error {
+func runTest(t *hivesim.T, c *hivesim.Client, test *rpcTest, rules []*rule, defaultTransport string) error {
+	tr, err := dialTransport(c, test, defaultTransport)
+	if err != nil {
+		return err
+	}
+	defer tr.close()
 	var (
-		client    = &http.Client{Timeout: 5 * time.Second}
-		url       = fmt.Sprintf("http://%s", net.JoinHostPort(c.IP.String(), "8545"))
-		err       error
-		respBytes []byte
+		recv = &receiver{transport: tr}
+		norm = newNormalizer(t.Logf, rules)
 	)
 
 	for _, msg := range test.messages {
 		if msg.send {
 			// Send request.
-			t.Log(">> ", msg.data)
-			respBytes, err = postHttp(client, url, strings.NewReader(msg.data))
-			if err != nil {
+			data := norm.request(msg.data)
+			t.Log(">> ", data)
+			if err := tr.send(data); err != nil {
 				return err
 			}
 		} else {
-			// Receive a response.
-			if respBytes == nil {
-				return fmt.Errorf("invalid test, response before request")
+			// Receive a response or notification.
+			resp, err := recv.next(msg.data)
+			if err == errNoMessage {
+				return fmt.Errorf("invalid test, no message from client for %s", msg.data)
+			}
+			if err != nil {
+				return err
 			}
-			expectedData := msg.data
-			resp := string(bytes.TrimSpace(respBytes))
 			t.Log("<< ", resp)
 			if !gjson.Valid(resp) {
 				return fmt.Errorf("invalid JSON response")
 			}
-
-			// Patch JSON to remove error messages. We only do this in the specific case
-			// where an error is expected AND returned by the client.
-			var errorRedacted bool
-			if gjson.Get(resp, "error").Exists() && gjson.Get(expectedData, "error").Exists() {
-				resp, _ = sjson.Delete(resp, "error.message")
-				expectedData, _ = sjson.Delete(expectedData, "error.message")
-				errorRedacted = true
+			resp, expectedData := norm.response(resp, msg.data)
+			resp, notes := applyDirectives(test.directives, resp, expectedData)
+			for _, note := range notes {
+				t.Log("note:", note)
 			}
 
-			// Clean the response and expected data for Kakarot
-			resp, expectedData = cleanKakarot(resp, expectedData)
-
 			// Compare responses.
-			d, err := diff.New().Compare([]byte(resp), []byte(expectedData))
+			d, got, err := compareJSON(resp, expectedData)
 			if err != nil {
 				return fmt.Errorf("failed to unmarshal value: %s\n", err)
 			}
 
 			// If there is a discrepancy, return error.
 			if d.Modified() {
-				if errorRedacted {
+				if norm.errorRedacted {
 					t.Log("note: error messages removed from comparison")
 				}
-				var got map[string]any
-				json.Unmarshal([]byte(resp), &got)
 				config := formatter.AsciiFormatterConfig{
 					ShowArrayIndex: true,
 					Coloring:       false,
 				}
 				formatter := formatter.NewAsciiFormatter(got, config)
 				diffString, _ := formatter.Format(d)
+				if len(notes) > 0 {
+					diffString += "comparison directives:\n  " + strings.Join(notes, "\n  ") + "\n"
+				}
 				return fmt.Errorf("response differs from expected (-- client, ++ test):\n%s", diffString)
 			}
-			respBytes = nil
 		}
 	}
 
-	if respBytes != nil {
+	if recv.unhandled() {
 		t.Fatalf("unhandled response in test case")
 	}
 	return nil
@@ -171,134 +197,33 @@ func postHttp(c *http.Client, url string, d io.Reader) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("write error: %v", err)
 	}
+	defer resp.Body.Close()
 	return io.ReadAll(resp.Body)
 }
 
-// 🚧 WARNING KAKAROT
-// cleanKakarot modifies the response and expected data in order
-// to be compatible with Kakarot. This includes :
-//   - removing any block hash field.
-func cleanKakarot(resp, expectedData string) (string, string) {
-	// We remove the data field from the error if it exists in both the response and expected data.
-	// According to <https://www.jsonrpc.org/specification>, data is a reserved field for additional
-	// information from the server on the error. It can be omitted. We remove it from the
-	// response and expected data to make the comparison easier.
-	if gjson.Get(resp, "error").Exists() && gjson.Get(expectedData, "error").Exists() {
-		resp, _ = sjson.Delete(resp, "error.data")
-		expectedData, _ = sjson.Delete(expectedData, "error.data")
-	}
-
-	resp, expectedData = cleanTransactionData(resp, expectedData)
-	resp, expectedData = cleanReceiptData(resp, expectedData)
-
-	resp, expectedData = cleanBlockData(resp, expectedData)
-
-	resp, expectedData = cleanReceiptsData(resp, expectedData)
-
-	return resp, expectedData
-}
-
-// 🚧 WARNING KAKAROT
-// cleanBlockData modifies the response and expected data if the returned values match a block information.
-func cleanBlockData(resp, expectedData string) (string, string) {
-	// TODO: remove the miner, gasLimit and baseFeePerGas skip once we have a way to set these in Kakarot.
-	var fields = []string{
-		"result.hash",
-		"result.parentHash",
-		"result.timestamp",
-		"result.baseFeePerGas",
-		"result.difficulty",
-		"result.gasLimit",
-		"result.miner",
-		"result.size",
-		"result.stateRoot",
-		"result.totalDifficulty",
-		"result.withdrawals",
-	}
-
-	if expected := gjson.Get(expectedData, "result.withdrawalsRoot"); expected.Exists() {
-		expectedRoot := expected.String()
-		if expectedRoot != EMPTY_ROOT {
-			fields = append(fields, "result.withdrawalsRoot")
-		}
-	}
-
-	resp = deleteFields(resp, fields...)
-	expectedData = deleteFields(expectedData, fields...)
-
-	if gjson.Get(resp, "result.transactions").Exists() && gjson.Get(expectedData, "result.transactions").Exists() {
-		transactions := gjson.Get(resp, "result.transactions").Array()
-		for i := range transactions {
-			resp = deleteField(resp, fmt.Sprintf("result.transactions.%d.blockHash", i))
-		}
-
-		transactions = gjson.Get(expectedData, "result.transactions").Array()
-		for i := range transactions {
-			expectedData = deleteField(expectedData, fmt.Sprintf("result.transactions.%d.blockHash", i))
-		}
-	}
-
-	return resp, expectedData
-}
-
-// 🚧 WARNING KAKAROT
-// cleanReceiptsData modifies the response and expected data if the returned values match a []receipt.
-func cleanReceiptsData(resp, expectedData string) (string, string) {
-	receipts := gjson.Get(resp, "result").Array()
-	// TODO: remove the gas removal part once gas accounting is fixed in Kakarot
-	for i := range receipts {
-		var fields = []string{fmt.Sprintf("result.%d.blockHash", i), fmt.Sprintf("result.%d.cumulativeGasUsed", i), fmt.Sprintf("result.%d.gasUsed", i)}
-		resp = deleteFields(resp, fields...)
-	}
-
-	receipts = gjson.Get(expectedData, "result").Array()
-	for i := range receipts {
-		var fields = []string{fmt.Sprintf("result.%d.blockHash", i), fmt.Sprintf("result.%d.cumulativeGasUsed", i), fmt.Sprintf("result.%d.gasUsed", i)}
-		expectedData = deleteFields(expectedData, fields...)
-	}
-
-	return resp, expectedData
-}
-
-// 🚧 WARNING KAKAROT
-// cleanTransactionData modifies the response and expected data if the returned values match a receipt.
-func cleanTransactionData(resp, expectedData string) (string, string) {
-	resp = deleteField(resp, "result.blockHash")
-	expectedData = deleteField(expectedData, "result.blockHash")
-
-	return resp, expectedData
-}
-
-// 🚧 WARNING KAKAROT
-// cleanReceiptData modifies the response and expected data if the returned values match a receipt.
-func cleanReceiptData(resp, expectedData string) (string, string) {
-	if gjson.Get(resp, "result.logs").Exists() && gjson.Get(expectedData, "result.logs").Exists() {
-		logs := gjson.Get(resp, "result.logs").Array()
-		for i := range logs {
-			resp = deleteField(resp, fmt.Sprintf("result.logs.%d.blockHash", i))
-		}
-
-		logs = gjson.Get(expectedData, "result.logs").Array()
-		for i := range logs {
-			expectedData = deleteField(expectedData, fmt.Sprintf("result.logs.%d.blockHash", i))
-		}
-	}
-
-	return resp, expectedData
-}
-
-// deleteFields removes the fields from the JSON string.
-func deleteFields(data string, fields ...string) string {
-	for _, field := range fields {
-		data = deleteField(data, field)
-	}
-	return data
-}
-
-// deleteField removes the field from the JSON string.
-func deleteField(data, field string) string {
-	if gjson.Get(data, field).Exists() {
-		data, _ = sjson.Delete(data, field)
-	}
-	return data
+// compareJSON compares the response with the expected data, which are both
+// objects, or arrays in the case of batch requests. It also returns the decoded
+// response for formatting the diff.
+func compareJSON(resp, expected string) (diff.Diff, any, error) {
+	var got, want any
+	if err := json.Unmarshal([]byte(resp), &got); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		return nil, nil, err
+	}
+	gotArray, ok1 := got.([]any)
+	wantArray, ok2 := want.([]any)
+	if ok1 && ok2 {
+		return diff.New().CompareArrays(gotArray, wantArray), got, nil
+	}
+	gotObject, ok1 := got.(map[string]any)
+	wantObject, ok2 := want.(map[string]any)
+	if !ok1 || !ok2 {
+		// Compare values of different kinds as members of an object, so the
+		// difference can be shown.
+		gotObject = map[string]any{"value": got}
+		wantObject = map[string]any{"value": want}
+	}
+	return diff.New().CompareObjects(gotObject, wantObject), gotObject, nil
 }
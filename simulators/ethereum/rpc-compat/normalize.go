@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// normalizer prepares the messages of a test for comparison. It tracks the methods
+// of the requests, so responses are normalized by the rules of their method, and
+// the subscription ids, which are chosen by the client.
+type normalizer struct {
+	logf  func(format string, args ...any)
+	rules []*rule
+	// methods of the sent requests by id
+	methods map[string]string
+	// method of the last sent request, for responses without a known id
+	lastMethod string
+	// subscription ids of the client by the ids in the test
+	subscriptions map[string]string
+	// set when error messages were removed from the comparison
+	errorRedacted bool
+}
+
+func newNormalizer(logf func(format string, args ...any), rules []*rule) *normalizer {
+	return &normalizer{
+		logf:          logf,
+		rules:         rules,
+		methods:       make(map[string]string),
+		subscriptions: make(map[string]string),
+	}
+}
+
+// request records the methods of a request, and replaces the subscription ids of
+// the test by the ids of the client.
+func (n *normalizer) request(data string) string {
+	for testID, clientID := range n.subscriptions {
+		data = strings.ReplaceAll(data, `"`+testID+`"`, `"`+clientID+`"`)
+	}
+	calls := gjson.Parse(data)
+	if !calls.IsArray() {
+		calls = gjson.Parse("[" + data + "]")
+	}
+	for _, call := range calls.Array() {
+		n.lastMethod = call.Get("method").String()
+		if id := call.Get("id"); id.Exists() {
+			n.methods[id.Raw] = n.lastMethod
+		}
+	}
+	return data
+}
+
+// response normalizes a message of the client and the expected message. The
+// responses of a batch are matched to the expected responses by their id, since
+// the server may return them in any order.
+func (n *normalizer) response(resp, expected string) (string, string) {
+	if !gjson.Parse(resp).IsArray() || !gjson.Parse(expected).IsArray() {
+		return n.normalize("", resp, expected)
+	}
+	var (
+		got  = orderBatch(resp, expected)
+		want = gjson.Parse(expected).Array()
+	)
+	gotElems := make([]string, len(got))
+	wantElems := make([]string, len(want))
+	for i := range want {
+		wantElems[i] = want[i].Raw
+	}
+	for i := range got {
+		gotElems[i] = got[i].Raw
+		if i < len(want) {
+			gotElems[i], wantElems[i] = n.normalize(fmt.Sprintf("batch response %d: ", i), gotElems[i], wantElems[i])
+		}
+	}
+	return "[" + strings.Join(gotElems, ",") + "]", "[" + strings.Join(wantElems, ",") + "]"
+}
+
+// normalize applies the subscription ids, error redaction and rules to a single
+// response or notification. The prefix is the position in the batch, for logging.
+func (n *normalizer) normalize(prefix, resp, expected string) (string, string) {
+	method := n.lastMethod
+	if m := gjson.Get(resp, "method"); m.Exists() {
+		method = m.String()
+	} else if m, ok := n.methods[gjson.Get(resp, "id").Raw]; ok {
+		method = m
+	}
+
+	// Subscription ids are chosen by the client. The id returned by eth_subscribe
+	// is compared as the id in the test, and replaced in later requests and
+	// notifications.
+	if method == "eth_subscribe" {
+		clientID, testID := gjson.Get(resp, "result"), gjson.Get(expected, "result")
+		if clientID.Type == gjson.String && testID.Type == gjson.String {
+			n.subscriptions[testID.String()] = clientID.String()
+			resp, _ = sjson.Set(resp, "result", testID.String())
+			n.logf("note: %ssubscription id %s of client compared as %s", prefix, clientID, testID)
+		}
+	}
+	if sub := gjson.Get(resp, "params.subscription"); method == "eth_subscription" && sub.Exists() {
+		for testID, clientID := range n.subscriptions {
+			if sub.String() == clientID {
+				resp, _ = sjson.Set(resp, "params.subscription", testID)
+			}
+		}
+	}
+
+	// Patch JSON to remove error messages. We only do this in the specific case
+	// where an error is expected AND returned by the client.
+	if gjson.Get(resp, "error").Exists() && gjson.Get(expected, "error").Exists() {
+		resp, _ = sjson.Delete(resp, "error.message")
+		expected, _ = sjson.Delete(expected, "error.message")
+		n.errorRedacted = true
+	}
+
+	// Apply the normalization rules of the client.
+	for _, r := range n.rules {
+		var changes []string
+		resp, expected, changes = r.apply(method, resp, expected)
+		for _, change := range changes {
+			n.logf("note: %srule %q %s", prefix, r.Description, change)
+		}
+	}
+	return resp, expected
+}
+
+// orderBatch returns the elements of a batch response in the order of the expected
+// responses with the same id. Responses without a matching id are moved to the end.
+func orderBatch(resp, expected string) []gjson.Result {
+	var (
+		got     = gjson.Parse(resp).Array()
+		used    = make([]bool, len(got))
+		ordered []gjson.Result
+	)
+	for _, want := range gjson.Parse(expected).Array() {
+		id := want.Get("id")
+		if !id.Exists() {
+			continue
+		}
+		for i, elem := range got {
+			if !used[i] && elem.Get("id").Raw == id.Raw {
+				ordered = append(ordered, elem)
+				used[i] = true
+				break
+			}
+		}
+	}
+	for i, elem := range got {
+		if !used[i] {
+			ordered = append(ordered, elem)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizeBatch(t *testing.T) {
+	rules, err := parseRules([]byte(`{"rules": [
+		{"description": "ignore hash", "methods": ["eth_getBlockByNumber"], "ignore": ["result.hash"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newNormalizer(t.Logf, rules)
+	n.request(`[{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x1",false]},{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}]`)
+
+	// The client returns the responses in a different order, and a different error
+	// message.
+	resp := `[{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"no such method"}},{"jsonrpc":"2.0","id":1,"result":{"hash":"0x01","number":"0x1"}}]`
+	expected := `[{"jsonrpc":"2.0","id":1,"result":{"hash":"0x02","number":"0x1"}},{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method does not exist"}}]`
+	gotResp, gotExpected := n.response(resp, expected)
+	want := `[{"jsonrpc":"2.0","id":1,"result":{"number":"0x1"}},{"jsonrpc":"2.0","id":2,"error":{"code":-32601}}]`
+	if gotResp != want {
+		t.Errorf("wrong response\n got: %s\nwant: %s", gotResp, want)
+	}
+	if gotExpected != want {
+		t.Errorf("wrong expected data\n got: %s\nwant: %s", gotExpected, want)
+	}
+	if !n.errorRedacted {
+		t.Error("error redaction not reported")
+	}
+	if d, _, err := compareJSON(gotResp, gotExpected); err != nil || d.Modified() {
+		t.Errorf("batch responses differ (err %v)", err)
+	}
+}
+
+func TestNormalizeSubscription(t *testing.T) {
+	n := newNormalizer(t.Logf, nil)
+	n.request(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newPendingTransactions"]}`)
+	resp, _ := n.response(`{"jsonrpc":"2.0","id":1,"result":"0xclient"}`, `{"jsonrpc":"2.0","id":1,"result":"0xtest"}`)
+	if want := `{"jsonrpc":"2.0","id":1,"result":"0xtest"}`; resp != want {
+		t.Errorf("wrong subscribe response %s", resp)
+	}
+
+	// Notifications are compared using the id of the test.
+	resp, _ = n.response(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xclient","result":"0xabcd"}}`,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xtest","result":"0xabcd"}}`)
+	if want := `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xtest","result":"0xabcd"}}`; resp != want {
+		t.Errorf("wrong notification %s", resp)
+	}
+
+	// Requests are sent using the id of the client.
+	req := n.request(`{"jsonrpc":"2.0","id":2,"method":"eth_unsubscribe","params":["0xtest"]}`)
+	if want := `{"jsonrpc":"2.0","id":2,"method":"eth_unsubscribe","params":["0xclient"]}`; req != want {
+		t.Errorf("wrong unsubscribe request %s", req)
+	}
+}
+
+// fakeTransport returns the given messages.
+type fakeTransport struct {
+	messages []string
+}
+
+func (t *fakeTransport) send(data string) error { return nil }
+
+func (t *fakeTransport) receive() (string, error) {
+	if len(t.messages) == 0 {
+		return "", errNoMessage
+	}
+	msg := t.messages[0]
+	t.messages = t.messages[1:]
+	return msg, nil
+}
+
+func (t *fakeTransport) close() {}
+
+func TestReceiverOrder(t *testing.T) {
+	var (
+		notification = `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0xabcd"}}`
+		response     = `{"jsonrpc":"2.0","id":2,"result":"0xabcd"}`
+		other        = `{"jsonrpc":"2.0","id":3,"result":true}`
+	)
+	r := &receiver{transport: &fakeTransport{messages: []string{notification, response}}}
+
+	// The response is expected first, but arrives after the notification.
+	if msg, err := r.next(response); err != nil || msg != response {
+		t.Fatalf("wrong response %q (err %v)", msg, err)
+	}
+	if !r.unhandled() {
+		t.Fatal("buffered notification not reported as unhandled")
+	}
+	if msg, err := r.next(notification); err != nil || msg != notification {
+		t.Fatalf("wrong notification %q (err %v)", msg, err)
+	}
+	if r.unhandled() {
+		t.Fatal("unhandled message after all messages were received")
+	}
+
+	// Without a matching message, the first buffered message is returned.
+	r = &receiver{transport: &fakeTransport{messages: []string{response}}}
+	if msg, err := r.next(other); err != nil || msg != response {
+		t.Fatalf("wrong message %q (err %v)", msg, err)
+	}
+	if _, err := r.next(other); err != errNoMessage {
+		t.Fatalf("wrong error %v", err)
+	}
+}
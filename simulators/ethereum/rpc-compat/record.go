@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/hive/hivesim"
+	"github.com/tidwall/gjson"
+)
+
+// Environment variables which enable the recording mode. They are set from the
+// record and record_dir build arguments of the simulator, or directly in hive's
+// --dev mode.
+const (
+	// Directory containing .io files with the requests to record. Responses in
+	// the files are ignored.
+	envRecord = "HIVE_RPC_COMPAT_RECORD"
+	// Directory to which the recorded .io files are written, the request
+	// directory by default.
+	envRecordDir = "HIVE_RPC_COMPAT_RECORD_DIR"
+)
+
+// recordAllTests sends the requests of the tests in the request directory to the
+// reference client, and writes the tests with the recorded responses to the
+// output directory.
+func recordAllTests(t *hivesim.T, c *hivesim.Client, requestDir, outDir string) {
+	_, testPattern := t.Sim.TestPattern()
+	re := regexp.MustCompile(testPattern)
+	tests := loadTests(t, requestDir, re)
+	t.Logf("recording %d tests using %s", len(tests), c.Type)
+	for _, test := range tests {
+		test := test
+		t.Run(hivesim.TestSpec{
+			Name:        test.name + " (record)",
+			Description: test.comment,
+			Run: func(t *hivesim.T) {
+				recorded, err := recordTest(c, &test)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content := formatTestFile(recorded)
+				file := filepath.Join(outDir, test.name+".io")
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("recorded %s:\n%s", file, content)
+			},
+		})
+	}
+}
+
+// recordTest sends the requests of a test and returns the test with the responses
+// of the client. Subscription notifications are not recorded, they must be added
+// to the test by hand.
+func recordTest(c *hivesim.Client, test *rpcTest) (rpcTest, error) {
+	recorded := rpcTest{name: test.name, comment: test.comment, speconly: test.speconly, websocket: test.websocket}
+	tr, err := dialTransport(c, test, transportHTTP)
+	if err != nil {
+		return recorded, err
+	}
+	defer tr.close()
+	for _, msg := range test.messages {
+		if !msg.send {
+			continue
+		}
+		if err := tr.send(msg.data); err != nil {
+			return recorded, err
+		}
+		respData, err := receiveResponse(tr)
+		if err != nil {
+			return recorded, fmt.Errorf("no response to %s: %v", msg.data, err)
+		}
+		// Responses must fit on a single line.
+		var resp bytes.Buffer
+		if err := json.Compact(&resp, []byte(respData)); err != nil {
+			return recorded, fmt.Errorf("invalid JSON response to %s: %v", msg.data, err)
+		}
+		recorded.messages = append(recorded.messages, msg, rpcTestMessage{data: resp.String()})
+	}
+	if len(recorded.messages) == 0 {
+		return recorded, fmt.Errorf("test has no requests")
+	}
+	return recorded, nil
+}
+
+// receiveResponse returns the next message of the client which is not a
+// notification.
+func receiveResponse(tr transport) (string, error) {
+	for {
+		msg, err := tr.receive()
+		if err != nil {
+			return "", err
+		}
+		if !gjson.Get(msg, "method").Exists() {
+			return msg, nil
+		}
+	}
+}
+
+// formatTestFile encodes a test in the format read by loadTestFile.
+func formatTestFile(test rpcTest) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(test.comment, "\n"), "\n") {
+		if line != "" {
+			b.WriteString("// " + line + "\n")
+		}
+	}
+	for _, msg := range test.messages {
+		if msg.send {
+			b.WriteString(">> " + msg.data + "\n")
+		} else {
+			b.WriteString("<< " + msg.data + "\n")
+		}
+	}
+	return b.String()
+}
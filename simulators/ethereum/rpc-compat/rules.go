@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// rulesFile contains the normalization rules applied to the responses of the clients.
+const rulesFile = "rules.json"
+
+// ruleSet is the content of the rules file.
+type ruleSet struct {
+	Rules []*rule `json:"rules"`
+}
+
+// rule relaxes the comparison of the responses of the matching clients and methods.
+// Both the response and the expected data are normalized, so they compare equal
+// at the given paths.
+//
+// Paths use the gjson syntax, e.g. "result.hash", where a "*" component matches
+// every element of an array or object, e.g. "result.transactions.*.blockHash".
+type rule struct {
+	// Reason of the rule, which is logged when it is applied
+	Description string `json:"description"`
+	// Client names to which the rule applies, as glob patterns. The rule applies
+	// to all clients if empty.
+	Clients []string `json:"clients"`
+	// Methods of the request to which the rule applies, as glob patterns. The rule
+	// applies to all requests if empty.
+	Methods []string `json:"methods"`
+	// Conditions which must all hold for the rule to apply
+	When []condition `json:"when"`
+	// Conditions of which none may hold for the rule to apply
+	Unless []condition `json:"unless"`
+	// Paths removed from the response and the expected data
+	Ignore []string `json:"ignore"`
+	// Values set in the response and the expected data
+	Rewrite []rewrite `json:"rewrite"`
+}
+
+// rewrite sets the value at a path, where it exists.
+type rewrite struct {
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// condition matches a value of the response or the expected data.
+type condition struct {
+	// Path of the value, which must exist
+	Path string `json:"path"`
+	// Data in which the value is matched, "response" or "expected". The value
+	// must match in both if empty.
+	In string `json:"in"`
+	// Value the matched value must be equal to. Any value matches if unset.
+	Value json.RawMessage `json:"value"`
+}
+
+// loadRules reads the rules file. A missing file contains no rules.
+func loadRules(file string) ([]*rule, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRules(data)
+}
+
+func parseRules(data []byte) ([]*rule, error) {
+	var set ruleSet
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid rules: %v", err)
+	}
+	for i, r := range set.Rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d (%q): %v", i, r.Description, err)
+		}
+	}
+	return set.Rules, nil
+}
+
+func (r *rule) validate() error {
+	if r.Description == "" {
+		return errors.New("missing description")
+	}
+	if len(r.Ignore) == 0 && len(r.Rewrite) == 0 {
+		return errors.New("rule has no effect")
+	}
+	for _, pattern := range append(append([]string{}, r.Clients...), r.Methods...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	for _, c := range append(append([]condition{}, r.When...), r.Unless...) {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	for _, p := range r.Ignore {
+		if p == "" {
+			return errors.New("empty ignore path")
+		}
+	}
+	for _, rw := range r.Rewrite {
+		if rw.Path == "" {
+			return errors.New("empty rewrite path")
+		}
+		if !json.Valid(rw.Value) {
+			return fmt.Errorf("invalid rewrite value for %s", rw.Path)
+		}
+	}
+	return nil
+}
+
+func (c *condition) validate() error {
+	if c.Path == "" {
+		return errors.New("empty condition path")
+	}
+	switch c.In {
+	case "", "response", "expected":
+	default:
+		return fmt.Errorf("invalid condition data %q for %s", c.In, c.Path)
+	}
+	if c.Value != nil && !json.Valid(c.Value) {
+		return fmt.Errorf("invalid condition value for %s", c.Path)
+	}
+	return nil
+}
+
+// holds reports whether the condition matches the response and expected data.
+func (c *condition) holds(resp, expected string) bool {
+	switch c.In {
+	case "response":
+		return c.matches(resp)
+	case "expected":
+		return c.matches(expected)
+	default:
+		return c.matches(resp) && c.matches(expected)
+	}
+}
+
+// matches reports whether any value matching the path exists in data, and is
+// equal to the condition value if it is set.
+func (c *condition) matches(data string) bool {
+	for _, concrete := range expandPath(data, c.Path) {
+		if c.Value == nil || jsonEqual(gjson.Get(data, concrete).Raw, c.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual reports whether the JSON values are equal, ignoring whitespace.
+func jsonEqual(a string, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, []byte(a)) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// rulesForClient returns the rules which apply to the client.
+func rulesForClient(rules []*rule, client string) []*rule {
+	var matching []*rule
+	for _, r := range rules {
+		if len(r.Clients) == 0 || matchAny(r.Clients, client) {
+			matching = append(matching, r)
+		}
+	}
+	return matching
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// apply normalizes the response and expected data of a request to the given
+// method. It returns the changes made by the rule, which are empty if the rule
+// does not apply.
+func (r *rule) apply(method, resp, expected string) (string, string, []string) {
+	if len(r.Methods) > 0 && !matchAny(r.Methods, method) {
+		return resp, expected, nil
+	}
+	for _, c := range r.When {
+		if !c.holds(resp, expected) {
+			return resp, expected, nil
+		}
+	}
+	for _, c := range r.Unless {
+		if c.holds(resp, expected) {
+			return resp, expected, nil
+		}
+	}
+	var changes []string
+	for _, p := range r.Ignore {
+		var changed bool
+		resp, changed = deletePath(resp, p)
+		if changed {
+			changes = append(changes, "ignored "+p+" in response")
+		}
+		expected, changed = deletePath(expected, p)
+		if changed {
+			changes = append(changes, "ignored "+p+" in expected data")
+		}
+	}
+	for _, rw := range r.Rewrite {
+		var changed bool
+		resp, changed = setPath(resp, rw.Path, rw.Value)
+		if changed {
+			changes = append(changes, fmt.Sprintf("rewrote %s to %s in response", rw.Path, rw.Value))
+		}
+		expected, changed = setPath(expected, rw.Path, rw.Value)
+		if changed {
+			changes = append(changes, fmt.Sprintf("rewrote %s to %s in expected data", rw.Path, rw.Value))
+		}
+	}
+	return resp, expected, changes
+}
+
+// deletePath removes all values matching the path, and reports whether any value
+// was removed.
+func deletePath(data, p string) (string, bool) {
+	paths := expandPath(data, p)
+	// Delete in reverse order, so removing an array element doesn't shift the
+	// indexes of the remaining matches.
+	for i := len(paths) - 1; i >= 0; i-- {
+		data, _ = sjson.Delete(data, paths[i])
+	}
+	return data, len(paths) > 0
+}
+
+// setPath sets all values matching the path, and reports whether any value was
+// changed.
+func setPath(data, p string, value json.RawMessage) (string, bool) {
+	var changed bool
+	for _, concrete := range expandPath(data, p) {
+		if gjson.Get(data, concrete).Raw == string(value) {
+			continue
+		}
+		data, _ = sjson.SetRaw(data, concrete, string(value))
+		changed = true
+	}
+	return data, changed
+}
+
+// expandPath returns the concrete paths of the existing values matching p, where
+// "*" components are replaced by the indexes or keys of the matched values.
+func expandPath(data, p string) []string {
+	paths := []string{""}
+	for _, component := range strings.Split(p, ".") {
+		var next []string
+		for _, prefix := range paths {
+			value := gjson.Parse(data)
+			if prefix != "" {
+				value = gjson.Get(data, prefix)
+			}
+			if component != "*" {
+				if value.Get(component).Exists() {
+					next = append(next, joinPath(prefix, component))
+				}
+				continue
+			}
+			if value.IsArray() {
+				for i := range value.Array() {
+					next = append(next, joinPath(prefix, strconv.Itoa(i)))
+				}
+			} else if value.IsObject() {
+				value.ForEach(func(key, _ gjson.Result) bool {
+					next = append(next, joinPath(prefix, key.String()))
+					return true
+				})
+			}
+		}
+		paths = next
+	}
+	if len(paths) == 1 && paths[0] == "" {
+		return nil
+	}
+	return paths
+}
+
+func joinPath(prefix, component string) string {
+	if prefix == "" {
+		return component
+	}
+	return prefix + "." + component
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := parseRules([]byte(`{"rules": [
+		{"description": "a", "clients": ["kakarot*"], "ignore": ["result.hash"]},
+		{"description": "b", "rewrite": [{"path": "result.size", "value": "0x0"}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rulesForClient(rules, "kakarot")) != 2 {
+		t.Error("wrong rules for kakarot")
+	}
+	if r := rulesForClient(rules, "go-ethereum"); len(r) != 1 || r[0].Description != "b" {
+		t.Error("wrong rules for go-ethereum")
+	}
+
+	for _, invalid := range []string{
+		`{"rules": [{"ignore": ["result.hash"]}]}`,
+		`{"rules": [{"description": "a"}]}`,
+		`{"rules": [{"description": "a", "clients": ["["], "ignore": ["result.hash"]}]}`,
+		`{"rules": [{"description": "a", "unknown": true, "ignore": ["result.hash"]}]}`,
+		`{"rules": [{"description": "a", "when": [{"in": "expected"}], "ignore": ["result.hash"]}]}`,
+		`{"rules": [{"description": "a", "unless": [{"path": "error", "in": "request"}], "ignore": ["result.hash"]}]}`,
+	} {
+		if _, err := parseRules([]byte(invalid)); err == nil {
+			t.Errorf("no error for invalid rules %s", invalid)
+		}
+	}
+}
+
+func TestRuleApply(t *testing.T) {
+	r := &rule{
+		Description: "test",
+		Methods:     []string{"eth_getBlockBy*"},
+		Ignore:      []string{"result.hash", "result.transactions.*.blockHash"},
+		Rewrite:     []rewrite{{Path: "result.size", Value: []byte(`"0x0"`)}},
+	}
+	resp := `{"result":{"hash":"0x1","size":"0x10","transactions":[{"blockHash":"0x1","nonce":"0x0"},{"blockHash":"0x1","nonce":"0x1"}]}}`
+	expected := `{"result":{"hash":"0x2","size":"0x0","transactions":[{"blockHash":"0x2","nonce":"0x0"},{"blockHash":"0x2","nonce":"0x1"}]}}`
+
+	gotResp, gotExpected, changes := r.apply("eth_getBlockByNumber", resp, expected)
+	want := `{"result":{"size":"0x0","transactions":[{"nonce":"0x0"},{"nonce":"0x1"}]}}`
+	if gotResp != want {
+		t.Errorf("wrong response\ngot:  %s\nwant: %s", gotResp, want)
+	}
+	if gotExpected != want {
+		t.Errorf("wrong expected data\ngot:  %s\nwant: %s", gotExpected, want)
+	}
+	wantChanges := []string{
+		"ignored result.hash in response",
+		"ignored result.hash in expected data",
+		"ignored result.transactions.*.blockHash in response",
+		"ignored result.transactions.*.blockHash in expected data",
+		`rewrote result.size to "0x0" in response`,
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("wrong changes %q", changes)
+	}
+
+	// Other methods are not changed.
+	gotResp, gotExpected, changes = r.apply("eth_getTransactionByHash", resp, expected)
+	if gotResp != resp || gotExpected != expected || changes != nil {
+		t.Error("rule applied to other method")
+	}
+}
+
+func TestRuleConditions(t *testing.T) {
+	errorData := &rule{
+		Description: "error data",
+		When:        []condition{{Path: "error"}},
+		Ignore:      []string{"error.data"},
+	}
+	withdrawalsRoot := &rule{
+		Description: "withdrawals root",
+		When:        []condition{{Path: "result.withdrawalsRoot", In: "expected"}},
+		Unless:      []condition{{Path: "result.withdrawalsRoot", In: "expected", Value: []byte(`"0x00"`)}},
+		Ignore:      []string{"result.withdrawalsRoot"},
+	}
+	tests := []struct {
+		rule                   *rule
+		resp, expected         string
+		wantResp, wantExpected string
+	}{
+		// The error data is ignored only if both have an error.
+		{
+			errorData,
+			`{"error":{"code":3,"data":"0x1"}}`, `{"error":{"code":3,"data":"0x2"}}`,
+			`{"error":{"code":3}}`, `{"error":{"code":3}}`,
+		},
+		{
+			errorData,
+			`{"error":{"code":3,"data":"0x1"}}`, `{"result":"0x1"}`,
+			`{"error":{"code":3,"data":"0x1"}}`, `{"result":"0x1"}`,
+		},
+		// The withdrawals root is ignored unless the expected root is the empty root.
+		{
+			withdrawalsRoot,
+			`{"result":{"withdrawalsRoot":"0x01"}}`, `{"result":{"withdrawalsRoot":"0x02"}}`,
+			`{"result":{}}`, `{"result":{}}`,
+		},
+		{
+			withdrawalsRoot,
+			`{"result":{"withdrawalsRoot":"0x01"}}`, `{"result":{"withdrawalsRoot":"0x00"}}`,
+			`{"result":{"withdrawalsRoot":"0x01"}}`, `{"result":{"withdrawalsRoot":"0x00"}}`,
+		},
+		{
+			withdrawalsRoot,
+			`{"result":{"withdrawalsRoot":"0x01"}}`, `{"result":{}}`,
+			`{"result":{"withdrawalsRoot":"0x01"}}`, `{"result":{}}`,
+		},
+	}
+	for i, test := range tests {
+		gotResp, gotExpected, _ := test.rule.apply("eth_getBlockByNumber", test.resp, test.expected)
+		if gotResp != test.wantResp || gotExpected != test.wantExpected {
+			t.Errorf("test %d (%s): wrong result\ngot:  %s %s\nwant: %s %s", i, test.rule.Description, gotResp, gotExpected, test.wantResp, test.wantExpected)
+		}
+	}
+}
+
+func TestRulesFile(t *testing.T) {
+	if _, err := loadRules(rulesFile); err != nil {
+		t.Fatal(err)
+	}
+}
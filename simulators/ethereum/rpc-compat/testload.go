@@ -17,7 +17,11 @@ type rpcTest struct {
 	name     string
 	comment  string
 	speconly bool
-	messages []rpcTestMessage
+	// if true, the test is run over WebSocket
+	websocket bool
+	// relax the comparison of the responses
+	directives []*directive
+	messages   []rpcTestMessage
 }
 
 type rpcTestMessage struct {
@@ -48,6 +52,16 @@ func loadTestFile(name string, r io.Reader) (rpcTest, error) {
 			if strings.HasPrefix(text, "speconly:") {
 				test.speconly = true
 			}
+			if strings.HasPrefix(text, "websocket:") {
+				test.websocket = true
+			}
+			d, err := parseDirective(text)
+			if err != nil {
+				return test, err
+			}
+			if d != nil {
+				test.directives = append(test.directives, d)
+			}
 
 		case strings.HasPrefix(line, ">>") || strings.HasPrefix(line, "<<"):
 			inHeader = false
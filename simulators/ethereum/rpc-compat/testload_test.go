@@ -10,6 +10,7 @@ func TestLoad(t *testing.T) {
 	data := `// this is a test comment
 // this is the second line
 // speconly: lalalala
+// websocket: uses subscriptions
 >> {"type":"send"}
 << {"type":"recv"}
 `
@@ -17,6 +18,7 @@ func TestLoad(t *testing.T) {
 	expectedComment := `this is a test comment
 this is the second line
 speconly: lalalala
+websocket: uses subscriptions
 `
 	expectedMessages := []rpcTestMessage{
 		{
@@ -42,7 +44,29 @@ speconly: lalalala
 	if !result.speconly {
 		t.Error("test is not marked speconly")
 	}
+	if !result.websocket {
+		t.Error("test is not marked websocket")
+	}
 	if !reflect.DeepEqual(result.messages, expectedMessages) {
 		t.Errorf("wrong test messages %+v", result.messages)
 	}
 }
+
+func TestFormatTestFile(t *testing.T) {
+	test := rpcTest{
+		name:     "the-test",
+		comment:  "this is a test comment\nspeconly: lalalala\n",
+		speconly: true,
+		messages: []rpcTestMessage{
+			{data: `{"type":"send"}`, send: true},
+			{data: `{"type":"recv"}`},
+		},
+	}
+	result, err := loadTestFile("the-test", strings.NewReader(formatTestFile(test)))
+	if err != nil {
+		t.Fatal("error:", err)
+	}
+	if !reflect.DeepEqual(result, test) {
+		t.Errorf("wrong test after round trip %+v", result)
+	}
+}
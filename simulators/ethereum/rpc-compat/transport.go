@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/hive/hivesim"
+	"github.com/gorilla/websocket"
+	"github.com/tidwall/gjson"
+)
+
+// envTransport selects the transport of all tests, "http" or "ws". Tests with a
+// "websocket:" header line always use WebSocket.
+const envTransport = "HIVE_RPC_COMPAT_TRANSPORT"
+
+// Transports of the tests.
+const (
+	transportHTTP      = "http"
+	transportWebSocket = "ws"
+)
+
+// responseTimeout is the time to wait for a message of the client.
+const responseTimeout = 5 * time.Second
+
+// errNoMessage is returned by transport.receive when the client has no further
+// message.
+var errNoMessage = errors.New("no message from client")
+
+// transport exchanges the messages of a test with the client.
+type transport interface {
+	// send sends a request, which is a single call or a batch.
+	send(data string) error
+	// receive returns the next message of the client.
+	receive() (string, error)
+	close()
+}
+
+// transportFromEnv returns the transport configured in the environment.
+func transportFromEnv() (string, error) {
+	switch v := os.Getenv(envTransport); v {
+	case "", transportHTTP:
+		return transportHTTP, nil
+	case transportWebSocket:
+		return transportWebSocket, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q, want %q or %q", envTransport, v, transportHTTP, transportWebSocket)
+	}
+}
+
+// dialTransport connects to the client using the transport of the test.
+func dialTransport(c *hivesim.Client, test *rpcTest, defaultTransport string) (transport, error) {
+	if test.websocket || defaultTransport == transportWebSocket {
+		url := fmt.Sprintf("ws://%s", net.JoinHostPort(c.IP.String(), "8546"))
+		dialer := websocket.Dialer{HandshakeTimeout: responseTimeout}
+		conn, _, err := dialer.Dial(url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("can't connect to %s: %v", url, err)
+		}
+		return &wsTransport{conn: conn}, nil
+	}
+	return &httpTransport{
+		client: &http.Client{Timeout: responseTimeout},
+		url:    fmt.Sprintf("http://%s", net.JoinHostPort(c.IP.String(), "8545")),
+	}, nil
+}
+
+// httpTransport sends each request as a POST request. The responses are queued
+// until they are received.
+type httpTransport struct {
+	client  *http.Client
+	url     string
+	pending []string
+}
+
+func (t *httpTransport) send(data string) error {
+	respBytes, err := postHttp(t.client, t.url, strings.NewReader(data))
+	if err != nil {
+		return err
+	}
+	t.pending = append(t.pending, string(bytes.TrimSpace(respBytes)))
+	return nil
+}
+
+func (t *httpTransport) receive() (string, error) {
+	if len(t.pending) == 0 {
+		return "", errNoMessage
+	}
+	msg := t.pending[0]
+	t.pending = t.pending[1:]
+	return msg, nil
+}
+
+func (t *httpTransport) close() {}
+
+// wsTransport sends each request as a message over a WebSocket connection, which
+// also receives the subscription notifications of the client.
+type wsTransport struct {
+	conn *websocket.Conn
+}
+
+func (t *wsTransport) send(data string) error {
+	if err := t.conn.WriteMessage(websocket.TextMessage, []byte(data)); err != nil {
+		return fmt.Errorf("write error: %v", err)
+	}
+	return nil
+}
+
+func (t *wsTransport) receive() (string, error) {
+	t.conn.SetReadDeadline(time.Now().Add(responseTimeout))
+	_, msg, err := t.conn.ReadMessage()
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return "", errNoMessage
+		}
+		return "", fmt.Errorf("read error: %v", err)
+	}
+	return string(bytes.TrimSpace(msg)), nil
+}
+
+func (t *wsTransport) close() {
+	t.conn.Close()
+}
+
+// receiver matches the messages of the client to the expected messages of a
+// test. Over WebSocket, responses and notifications may arrive in a different
+// order than listed in the test, so messages which don't match are buffered
+// until they are expected.
+type receiver struct {
+	transport
+	buffered []string
+}
+
+// next returns the message of the client matching the expected message. If no
+// such message arrives, it returns the first buffered message, so the mismatch
+// is reported by the comparison.
+func (r *receiver) next(expected string) (string, error) {
+	key := messageKey(expected)
+	for i, msg := range r.buffered {
+		if messageKey(msg) == key {
+			r.buffered = append(r.buffered[:i], r.buffered[i+1:]...)
+			return msg, nil
+		}
+	}
+	for {
+		msg, err := r.receive()
+		if err == errNoMessage && len(r.buffered) > 0 {
+			msg, r.buffered = r.buffered[0], r.buffered[1:]
+			return msg, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if messageKey(msg) == key {
+			return msg, nil
+		}
+		r.buffered = append(r.buffered, msg)
+	}
+}
+
+// unhandled reports whether the client sent a message which wasn't expected by
+// the test. Over WebSocket, only messages received so far are considered.
+func (r *receiver) unhandled() bool {
+	if h, ok := r.transport.(*httpTransport); ok && len(h.pending) > 0 {
+		return true
+	}
+	return len(r.buffered) > 0
+}
+
+// messageKey identifies a message: responses by their id, notifications by their
+// method. All batches have the same key, since they are sent in order.
+func messageKey(data string) string {
+	v := gjson.Parse(data)
+	switch {
+	case v.IsArray():
+		return "batch"
+	case v.Get("method").Exists():
+		return "notification " + v.Get("method").String()
+	default:
+		return "response " + v.Get("id").Raw
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// checkState verifies that the state of the genesis accounts is available at the
+// head of the node. The eth_getProof results must match those of the source, and
+// the account proofs must be valid for the state root of the head block.
+func (n *node) checkState(t *hivesim.T, source *node) error {
+	var genesis struct {
+		Alloc map[common.Address]json.RawMessage `json:"alloc"`
+	}
+	if err := common.LoadJSON("chain/genesis.json", &genesis); err != nil {
+		return fmt.Errorf("can't load genesis: %v", err)
+	}
+	accounts := make([]common.Address, 0, len(genesis.Alloc))
+	for addr := range genesis.Alloc {
+		accounts = append(accounts, addr)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Cmp(accounts[j]) < 0 })
+
+	head, err := n.head()
+	if err != nil {
+		return fmt.Errorf("can't query chain head: %v", err)
+	}
+	for _, addr := range accounts {
+		want, err := source.proof(addr, head.Number)
+		if err != nil {
+			return fmt.Errorf("eth_getProof(%v) failed on source: %v", addr, err)
+		}
+		got, err := n.proof(addr, head.Number)
+		if err != nil {
+			return fmt.Errorf("eth_getProof(%v) failed: %v", addr, err)
+		}
+		if got.Nonce != want.Nonce || got.Balance.Cmp(want.Balance) != 0 || got.StorageHash != want.StorageHash || got.CodeHash != want.CodeHash {
+			return fmt.Errorf("account %v is %s, source has %s", addr, jsonString(accountFields(got)), jsonString(accountFields(want)))
+		}
+		if err := verifyAccountProof(head.Root, got); err != nil {
+			return fmt.Errorf("invalid proof of account %v: %v", addr, err)
+		}
+	}
+	t.Logf("verified state of %d accounts at block %d", len(accounts), head.Number)
+	return nil
+}
+
+// proof returns the eth_getProof result of an account at the given block.
+func (n *node) proof(addr common.Address, number *big.Int) (*gethclient.AccountResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return gethclient.New(n.RPC()).GetProof(ctx, addr, nil, number)
+}
+
+// accountFields returns the account fields of an eth_getProof result, for logging.
+func accountFields(res *gethclient.AccountResult) map[string]any {
+	return map[string]any{
+		"nonce":       res.Nonce,
+		"balance":     (*hexutil.Big)(res.Balance),
+		"storageHash": res.StorageHash,
+		"codeHash":    res.CodeHash,
+	}
+}
+
+// verifyAccountProof checks that the account proof of an eth_getProof result
+// proves the returned account fields in the state trie with the given root.
+func verifyAccountProof(root common.Hash, res *gethclient.AccountResult) error {
+	db := memorydb.New()
+	for _, node := range res.AccountProof {
+		blob, err := hexutil.Decode(node)
+		if err != nil {
+			return fmt.Errorf("invalid proof node: %v", err)
+		}
+		db.Put(crypto.Keccak256(blob), blob)
+	}
+	value, err := trie.VerifyProof(root, crypto.Keccak256(res.Address.Bytes()), db)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("account not in state trie")
+	}
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return fmt.Errorf("invalid account in state trie: %v", err)
+	}
+	if account.Nonce != res.Nonce || account.Balance.Cmp(res.Balance) != 0 || account.Root != res.StorageHash || common.BytesToHash(account.CodeHash) != res.CodeHash {
+		return fmt.Errorf("proven account doesn't match result")
+	}
+	return nil
+}
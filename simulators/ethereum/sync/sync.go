@@ -1,21 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/hive/hivesim"
 )
 
 var (
 	// the number of seconds before a sync is considered stalled or failed
 	syncTimeout = 60 * time.Second
+	// how long the sink is paused in the resume test
+	pauseDuration = 10 * time.Second
+	// the sync modes requested from the sink with HIVE_SYNC_MODE, and the client
+	// roles declaring support for them
+	syncModes = []syncMode{
+		{name: "full", role: "eth1_full_sync"},
+		{name: "snap", role: "eth1_snap_sync"},
+		{name: "checkpoint", role: "eth1_checkpoint_sync"},
+	}
 	sourceFiles = map[string]string{
 		"genesis.json": "./chain/genesis.json",
 		"chain.rlp":    "./chain/chain.rlp",
@@ -25,6 +41,11 @@ var (
 	}
 )
 
+type syncMode struct {
+	name string
+	role string
+}
+
 func main() {
 	// Load fork environment.
 	var params hivesim.Params
@@ -53,7 +74,7 @@ For each client, we test if it can serve as a sync source for all other clients
 
 func runSourceTest(t *hivesim.T, c *hivesim.Client, params hivesim.Params) {
 	// Check whether the source has imported its chain.rlp correctly.
-	source := &node{c}
+	source := &node{Client: c}
 	if err := source.checkHead(); err != nil {
 		t.Fatal(err)
 	}
@@ -71,35 +92,150 @@ func runSourceTest(t *hivesim.T, c *hivesim.Client, params hivesim.Params) {
 	}
 	sinkParams := params.Set("HIVE_BOOTNODE", enode)
 
-	// Sync all sink nodes against the source.
+	// Sync all sink nodes against the source in their default mode, and in
+	// every sync mode they support.
 	t.RunAllClients(hivesim.ClientTestSpec{
 		Role:        "eth1",
 		Name:        fmt.Sprintf("sync %s -> CLIENT", source.Type),
-		Description: fmt.Sprintf("This test attempts to sync the chain from a %s node.", source.Type),
+		Description: fmt.Sprintf("This test attempts to sync the chain from a %s node, and verifies the state of the synced chain.", source.Type),
+		Parameters:  sinkParams,
+		Files:       sinkFiles,
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			runSyncTest(t, c, source, "")
+		},
+	})
+	for _, mode := range syncModes {
+		mode := mode
+		t.RunAllClients(hivesim.ClientTestSpec{
+			Role:        mode.role,
+			Name:        fmt.Sprintf("sync %s -> CLIENT (%s)", source.Type, mode.name),
+			Description: fmt.Sprintf("This test attempts to %s sync the chain from a %s node, and verifies the state of the synced chain.", mode.name, source.Type),
+			Parameters:  sinkParams.Set("HIVE_SYNC_MODE", mode.name),
+			Files:       sinkFiles,
+			Run: func(t *hivesim.T, c *hivesim.Client) {
+				runSyncTest(t, c, source, mode.name)
+			},
+		})
+	}
+
+	// Interrupt the sync of all sink nodes, by pausing the container and by
+	// restarting the client.
+	t.RunAllClients(hivesim.ClientTestSpec{
+		Role:        "eth1",
+		Name:        fmt.Sprintf("sync %s -> CLIENT (pause)", source.Type),
+		Description: fmt.Sprintf("This test pauses the client while it syncs the chain from a %s node, and verifies that the sync resumes.", source.Type),
 		Parameters:  sinkParams,
 		Files:       sinkFiles,
-		Run:         runSyncTest,
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			runResumeTest(t, c, source, false)
+		},
+	})
+	t.RunAllClients(hivesim.ClientTestSpec{
+		Role:        "eth1",
+		Name:        fmt.Sprintf("sync %s -> CLIENT (restart)", source.Type),
+		Description: fmt.Sprintf("This test stops and restarts the client while it syncs the chain from a %s node, and verifies that the sync resumes from the data on disk.", source.Type),
+		Parameters:  sinkParams,
+		Files:       sinkFiles,
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			runResumeTest(t, c, source, true)
+		},
 	})
 }
 
-func runSyncTest(t *hivesim.T, c *hivesim.Client) {
-	node := &node{c}
-	err := node.checkSync(t)
-	if err != nil {
+func runSyncTest(t *hivesim.T, c *hivesim.Client, source *node, mode string) {
+	sink := &node{Client: c}
+
+	// In checkpoint mode, the sink is given an older finalized block of the
+	// source chain to start its sync from.
+	var checkpoint *types.Header
+	if mode == "checkpoint" {
+		var err error
+		if checkpoint, err = source.checkpoint(); err != nil {
+			t.Fatal("can't get checkpoint block from source:", err)
+		}
+		t.Logf("syncing from checkpoint block %d (%s)", checkpoint.Number, checkpoint.Hash().TerminalString())
+		sink.finalized = checkpoint.Hash()
+	}
+
+	start := time.Now()
+	if err := sink.checkSync(t); err != nil {
 		t.Fatal("sync failed:", err)
 	}
+	t.Logf("%s reached the head in %v", sink.Type, time.Since(start))
+	if checkpoint != nil {
+		if err := sink.checkFinalized(checkpoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.checkState(t, source); err != nil {
+		t.Fatal("state not available after sync:", err)
+	}
+}
+
+// runResumeTest interrupts the sink after its sync has started, and checks that
+// it reaches the head after it is resumed. The sink is either paused and
+// unpaused, or stopped and started again.
+func runResumeTest(t *hivesim.T, c *hivesim.Client, source *node, restart bool) {
+	sink := &node{Client: c}
+	expectedHead, err := loadHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.triggerSync(t); err != nil {
+		t.Fatal("can't trigger sync:", err)
+	}
+	number, err := sink.waitProgress(t, expectedHead.Number.Uint64())
+	if err != nil {
+		t.Fatal("can't interrupt sync:", err)
+	}
+	if restart {
+		t.Logf("restarting %s at block %d", sink.Type, number)
+		if err := sink.restart(t); err != nil {
+			t.Fatal("can't restart client:", err)
+		}
+	} else {
+		t.Logf("pausing %s at block %d for %v", sink.Type, number, pauseDuration)
+		if err := t.Sim.PauseClient(t.SuiteID, t.TestID, c.Container); err != nil {
+			t.Fatal("can't pause client:", err)
+		}
+		time.Sleep(pauseDuration)
+		if err := t.Sim.UnpauseClient(t.SuiteID, t.TestID, c.Container); err != nil {
+			t.Fatal("can't unpause client:", err)
+		}
+	}
+
+	start := time.Now()
+	if err := sink.checkSync(t); err != nil {
+		t.Fatal("sync didn't resume:", err)
+	}
+	t.Logf("%s reached the head in %v after resuming", sink.Type, time.Since(start))
+	if err := sink.checkState(t, source); err != nil {
+		t.Fatal("state not available after sync:", err)
+	}
 }
 
 type node struct {
 	*hivesim.Client
+
+	// finalized overrides the safe and finalized block of the forkchoice
+	// update sent to the node.
+	finalized common.Hash
+}
+
+// loadHead returns the head block of the test chain.
+func loadHead() (*types.Header, error) {
+	var head types.Header
+	if err := common.LoadJSON("chain/headblock.json", &head); err != nil {
+		return nil, fmt.Errorf("can't load expected header: %v", err)
+	}
+	return &head, nil
 }
 
 // checkSync waits for the node to reach the head of the chain.
 func (n *node) checkSync(t *hivesim.T) error {
-	var expectedHead types.Header
-	err := common.LoadJSON("chain/headblock.json", &expectedHead)
+	expectedHead, err := loadHead()
 	if err != nil {
-		return fmt.Errorf("can't load expected header: %v", err)
+		return err
 	}
 	wantHash := expectedHead.Hash()
 
@@ -138,6 +274,32 @@ func (n *node) checkSync(t *hivesim.T) error {
 	}
 }
 
+// waitProgress waits for the node to import blocks after the sync was triggered,
+// and returns its head block number once it is between the genesis block and the
+// target head.
+func (n *node) waitProgress(t *hivesim.T, target uint64) (uint64, error) {
+	timeout := time.After(syncTimeout)
+	for {
+		select {
+		case <-timeout:
+			return 0, fmt.Errorf("timeout (%v elapsed, no new head)", syncTimeout)
+		default:
+			head, err := n.head()
+			if err != nil {
+				return 0, err
+			}
+			number := head.Number.Uint64()
+			if number >= target {
+				return 0, fmt.Errorf("sync reached head %d before it could be interrupted", number)
+			}
+			if number > 0 {
+				return number, nil
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
 type rpcRequest struct {
 	Method string
 	Params []json.RawMessage
@@ -165,6 +327,19 @@ func (n *node) sendForkchoiceUpdated(t *hivesim.T) error {
 	if err := common.LoadJSON("chain/headfcu.json", &fcu); err != nil {
 		return err
 	}
+	if n.finalized != (common.Hash{}) {
+		var state engine.ForkchoiceStateV1
+		if err := json.Unmarshal(fcu.Params[0], &state); err != nil {
+			return err
+		}
+		state.SafeBlockHash = n.finalized
+		state.FinalizedBlockHash = n.finalized
+		enc, err := json.Marshal(&state)
+		if err != nil {
+			return err
+		}
+		fcu.Params[0] = enc
+	}
 	t.Logf("%s: %s", fcu.Method, jsonString(fcu.Params))
 	var fcuresp engine.ForkChoiceResponse
 	if err := n.EngineAPI().Call(&fcuresp, fcu.Method, conv2any(fcu.Params)...); err != nil {
@@ -192,9 +367,66 @@ func (n *node) checkHead() error {
 	return nil
 }
 
+// checkpoint returns the block the sink starts from in checkpoint sync. This is the
+// block halfway between genesis and the head of the node.
+func (n *node) checkpoint() (*types.Header, error) {
+	head, err := n.head()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	number := new(big.Int).Rsh(head.Number, 1)
+	return ethclient.NewClient(n.RPC()).HeaderByNumber(ctx, number)
+}
+
+// checkFinalized checks that the finalized block of the node is the given checkpoint.
+func (n *node) checkFinalized(checkpoint *types.Header) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	number := big.NewInt(int64(rpc.FinalizedBlockNumber))
+	finalized, err := ethclient.NewClient(n.RPC()).HeaderByNumber(ctx, number)
+	if err != nil {
+		return fmt.Errorf("can't query finalized block: %v", err)
+	}
+	if finalized.Hash() != checkpoint.Hash() {
+		return fmt.Errorf("wrong finalized block %d (%s), want checkpoint %d (%s)", finalized.Number, finalized.Hash().TerminalString(), checkpoint.Number, checkpoint.Hash().TerminalString())
+	}
+	return nil
+}
+
+// restart stops the node and starts it again through the restart endpoint of the
+// simulation API. The client is replaced, since the restarted container may have
+// a different IP address.
+func (n *node) restart(t *hivesim.T) error {
+	url := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/restart", os.Getenv("HIVE_SIMULATOR"), t.SuiteID, t.TestID, n.Container)
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var info struct {
+		IP string `json:"ip"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return err
+	}
+	ip := net.ParseIP(info.IP)
+	if ip == nil {
+		return fmt.Errorf("no IP address returned")
+	}
+	n.Client = &hivesim.Client{Type: n.Type, Container: n.Container, IP: ip}
+	return nil
+}
+
 // head returns the node's chain head.
 func (n *node) head() (*types.Header, error) {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	return ethclient.NewClient(n.RPC()).HeaderByNumber(ctx, nil)
 }
 